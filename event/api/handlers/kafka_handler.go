@@ -2,21 +2,44 @@ package handlers
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
+    "strconv"
+    "strings"
 
+    "event/api/models"
     "event/config"
     "event/services"
+    "event/utils"
+    "github.com/go-chi/chi/v5"
     "go.uber.org/zap"
 )
 
 type KafkaHandler struct {
-    Cfg    config.Config
-    Logger *zap.Logger
-    Admin  *services.KafkaAdmin
+    Cfg       config.Config
+    Logger    *zap.Logger
+    Admin     *services.KafkaAdmin
+    Pipelines *services.PipelineService
+    Configs   *services.TopicConfigHistory
+    Sampler   *services.ThroughputSampler
 }
 
-func NewKafkaHandler(cfg config.Config, logger *zap.Logger) *KafkaHandler {
-    return &KafkaHandler{Cfg: cfg, Logger: logger, Admin: services.NewKafkaAdmin(cfg)}
+func NewKafkaHandler(cfg config.Config, logger *zap.Logger, store *services.Store, sr *services.StarRocksClient, sampler *services.ThroughputSampler) *KafkaHandler {
+    return &KafkaHandler{
+        Cfg: cfg, Logger: logger,
+        Admin:     services.NewKafkaAdmin(cfg),
+        Pipelines: services.NewPipelineService(cfg, store, sr),
+        Configs:   services.NewTopicConfigHistory(store),
+        Sampler:   sampler,
+    }
+}
+
+// kafkaStatus 将 Kafka 管理操作的错误映射为 HTTP 状态码；无法连接或 broker 返回未知错误时为 502
+func kafkaStatus(err error) int {
+    if errors.Is(err, utils.ErrNotFound) || errors.Is(err, utils.ErrInvalid) || errors.Is(err, utils.ErrConflict) {
+        return errStatus(err)
+    }
+    return http.StatusBadGateway
 }
 
 type TopicInfo struct {
@@ -34,4 +57,379 @@ func (h *KafkaHandler) ListTopics(w http.ResponseWriter, r *http.Request) {
         return
     }
     _ = json.NewEncoder(w).Encode(topics)
-}
\ No newline at end of file
+}
+
+// GetTopic 返回主题的分区明细：leader、副本、ISR 与高低水位
+func (h *KafkaHandler) GetTopic(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    d, err := h.Admin.DescribeTopic(r.Context(), name)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.describe_topic.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(d)
+}
+
+// ListThroughput 返回各主题在 5m/1h/24h 窗口内的写入速率；?partitions=true 附带分区明细
+func (h *KafkaHandler) ListThroughput(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    withParts, _ := strconv.ParseBool(r.URL.Query().Get("partitions"))
+    _ = json.NewEncoder(w).Encode(h.Sampler.Rates(withParts))
+}
+
+// TopicThroughput 返回单个主题及其各分区的写入速率；主题尚未被采样时返回 404
+func (h *KafkaHandler) TopicThroughput(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    tr, err := h.Sampler.TopicRates(name)
+    if err != nil {
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(tr)
+}
+
+// GetTopicConfig 返回主题的全部配置及最近的修改记录
+func (h *KafkaHandler) GetTopicConfig(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    configs, err := h.Admin.TopicConfigs(r.Context(), name)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.topic_config.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(map[string]any{"topic": name, "configs": configs, "history": h.Configs.List(name, 50)})
+}
+
+// UpdateTopicConfig 修改主题配置：{"configs":{"retention.ms":"259200000","cleanup.policy":null}}，
+// null 表示恢复默认值；未出现的配置项保持不变，实际变化记入修改历史
+func (h *KafkaHandler) UpdateTopicConfig(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var req struct {
+        Configs map[string]*string `json:"configs"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    changes, err := h.Admin.AlterTopicConfigs(r.Context(), name, req.Configs)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.alter_topic_config.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    ctx := services.WithOperator(r.Context(), operator(r))
+    if err := h.Configs.Record(ctx, changes); err != nil {
+        h.Logger.Sugar().Warnw("kafka.alter_topic_config.record_failed", "topic", name, "err", err)
+    }
+    for _, c := range changes {
+        h.Logger.Sugar().Infow("kafka.alter_topic_config", "topic", name, "config", c.Config, "old", c.Old, "new", c.New, "reset", c.Reset, "operator", operator(r))
+    }
+    if changes == nil { changes = []models.TopicConfigChange{} }
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "changes": changes})
+}
+
+// AddPartitions 增加主题分区：{"count":6,"restart_jobs":false}，返回消费该主题的 Routine Load 是否已覆盖新分区；
+// restart_jobs 为 true 时对尚未覆盖的运行中作业执行暂停与恢复
+func (h *KafkaHandler) AddPartitions(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var req struct {
+        Count       int  `json:"count"`
+        RestartJobs bool `json:"restart_jobs"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    old, err := h.Admin.AddPartitions(r.Context(), name, req.Count)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.add_partitions.failed", "topic", name, "count", req.Count, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    h.Logger.Sugar().Infow("kafka.add_partitions", "topic", name, "from", old, "to", req.Count, "operator", operator(r))
+    resp := map[string]any{"ok": true, "topic": name, "previous": old, "partitions": req.Count}
+    // 分区已增加，作业检查失败只在响应中说明；按请求的分区数检查，不依赖可能尚未更新的元数据
+    jobs, err := h.Pipelines.CheckTopicJobs(r.Context(), name, req.Count, req.RestartJobs)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.add_partitions.check_jobs_failed", "topic", name, "err", err)
+        resp["jobs_error"] = err.Error()
+    } else {
+        resp["jobs"] = jobs
+    }
+    _ = json.NewEncoder(w).Encode(resp)
+}
+
+// TopicJobs 返回消费该主题的 Routine Load 作业的分区覆盖情况
+func (h *KafkaHandler) TopicJobs(w http.ResponseWriter, r *http.Request) {
+    h.topicJobs(w, r, false)
+}
+
+// RestartTopicJobs 暂停并恢复尚未覆盖全部分区的运行中作业
+func (h *KafkaHandler) RestartTopicJobs(w http.ResponseWriter, r *http.Request) {
+    h.topicJobs(w, r, true)
+}
+
+func (h *KafkaHandler) topicJobs(w http.ResponseWriter, r *http.Request, restart bool) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    jobs, err := h.Pipelines.CheckTopicJobs(r.Context(), name, 0, restart)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.topic_jobs.failed", "topic", name, "restart", restart, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    if restart {
+        for _, j := range jobs {
+            if j.Restarted { h.Logger.Sugar().Infow("kafka.topic_jobs.restarted", "topic", name, "job", j.Job, "operator", operator(r)) }
+        }
+    }
+    _ = json.NewEncoder(w).Encode(jobs)
+}
+
+// GetCluster 返回集群 ID、controller、各 broker 及配置地址的连通性；
+// 配置地址全部不可达时返回 502，响应中仍带有每个地址的错误
+func (h *KafkaHandler) GetCluster(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    info, err := h.Admin.Cluster(r.Context())
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.cluster.failed", "err", err)
+        w.WriteHeader(http.StatusBadGateway)
+        _ = json.NewEncoder(w).Encode(map[string]any{"error": err.Error(), "configured": info.Configured})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(info)
+}
+
+// ListGroups 返回消费组及其成员；Kafka 不可达时返回 502
+func (h *KafkaHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    groups, err := h.Admin.ListGroups(r.Context())
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.list_groups.failed", "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(groups)
+}
+
+// GroupLag 返回消费组在每个分区上的已提交 offset、高水位与积压，以及总积压
+func (h *KafkaHandler) GroupLag(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    id := chi.URLParam(r, "id")
+    lag, err := h.Admin.GroupLag(r.Context(), id)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.group_lag.failed", "group", id, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(lag)
+}
+
+// ResetGroupOffsets 重置消费组在某个主题上的位置：
+// {"topic":"page_views","to":"timestamp","ago":"1h","partitions":[0,1],"dry_run":true}；组内有活跃成员时返回 409
+func (h *KafkaHandler) ResetGroupOffsets(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    id := chi.URLParam(r, "id")
+    var req struct {
+        services.OffsetTarget
+        Topic  string `json:"topic"`
+        DryRun bool   `json:"dry_run"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    res, err := h.Admin.ResetGroupOffsets(r.Context(), id, strings.TrimSpace(req.Topic), req.OffsetTarget, req.DryRun)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.reset_offsets.failed", "group", id, "topic", req.Topic, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    if !req.DryRun {
+        h.Logger.Sugar().Infow("kafka.reset_offsets", "group", id, "topic", req.Topic, "to", req.To, "operator", operator(r))
+    }
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+// ListMessages 浏览主题消息：?partition=0&offset=100&limit=20 从指定位置顺序读取；
+// 不带 offset 时返回最新的 limit 条（可用 partition 限定分区）
+func (h *KafkaHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    q := r.URL.Query()
+    mq := services.MessageQuery{Partition: -1}
+    var bad string
+    if v := strings.TrimSpace(q.Get("partition")); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 0 { bad = "invalid partition" }
+        mq.Partition = n
+    }
+    if v := strings.TrimSpace(q.Get("offset")); v != "" {
+        n, err := strconv.ParseInt(v, 10, 64)
+        if err != nil || n < 0 { bad = "invalid offset" }
+        mq.Offset = &n
+    }
+    if v := strings.TrimSpace(q.Get("limit")); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 { bad = "invalid limit" }
+        mq.Limit = n
+    }
+    if bad != "" {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": bad})
+        return
+    }
+    msgs, err := h.Admin.ReadMessages(r.Context(), name, mq)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.read_messages.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(msgs)
+}
+
+// ProduceMessages 向主题写入消息，请求体为单条记录或记录数组，如
+// {"key":"u1","value":{"event":"click"},"headers":{"source":"smoke-test"},"partition":0}；
+// 有消息写入失败时返回 502 并在 results 中给出每条的结果
+func (h *KafkaHandler) ProduceMessages(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var raw json.RawMessage
+    if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    var recs []services.ProduceRecord
+    var err error
+    if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+        err = json.Unmarshal(raw, &recs)
+    } else {
+        var rec services.ProduceRecord
+        err = json.Unmarshal(raw, &rec)
+        recs = []services.ProduceRecord{rec}
+    }
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid record: " + err.Error()})
+        return
+    }
+    results, err := h.Admin.Produce(r.Context(), name, recs)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.produce.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    failed := 0
+    for _, res := range results {
+        if res.Error != "" { failed++ }
+    }
+    h.Logger.Sugar().Infow("kafka.produce", "topic", name, "records", len(results), "failed", failed, "operator", operator(r))
+    if failed > 0 { w.WriteHeader(http.StatusBadGateway) }
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": failed == 0, "results": results})
+}
+
+// InferSchema 采样主题最新消息推断 JSON 字段，返回建议的建表语句、jsonpaths 与 Routine Load columns；
+// ?samples=100&table=page_views
+func (h *KafkaHandler) InferSchema(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    q := r.URL.Query()
+    samples := 0
+    if v := strings.TrimSpace(q.Get("samples")); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 {
+            w.WriteHeader(http.StatusBadRequest)
+            _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid samples"})
+            return
+        }
+        samples = n
+    }
+    s, err := h.Admin.InferSchema(r.Context(), name, strings.TrimSpace(q.Get("table")), samples)
+    if err != nil {
+        h.Logger.Sugar().Warnw("kafka.infer_schema.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(s)
+}
+
+// CreateTopic 创建主题，如 {"name":"page_views","partitions":6,"replication_factor":3,"configs":{"retention.ms":"604800000","cleanup.policy":"delete"}}
+func (h *KafkaHandler) CreateTopic(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    var spec services.TopicSpec
+    if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    if spec.Partitions < 0 || spec.ReplicationFactor < 0 {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "partitions and replication_factor must be positive"})
+        return
+    }
+    if err := h.Admin.CreateTopic(r.Context(), spec); err != nil {
+        h.Logger.Sugar().Warnw("kafka.create_topic.failed", "topic", spec.Name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    h.Logger.Sugar().Infow("kafka.create_topic", "topic", spec.Name, "partitions", spec.Partitions,
+        "replication_factor", spec.ReplicationFactor, "operator", operator(r))
+    w.WriteHeader(http.StatusCreated)
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "name": spec.Name})
+}
+
+// DeleteTopic 删除主题；仍有管道或 Routine Load 作业消费该主题时返回 409，?force=true 时强制删除
+func (h *KafkaHandler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+    if !force {
+        pipes, jobs, err := h.Pipelines.TopicConsumers(r.Context(), name)
+        if err != nil {
+            h.Logger.Sugar().Warnw("kafka.delete_topic.check_failed", "topic", name, "err", err)
+            w.WriteHeader(http.StatusBadGateway)
+            _ = json.NewEncoder(w).Encode(map[string]string{"error": "cannot verify consumers of topic: " + err.Error()})
+            return
+        }
+        if len(pipes) > 0 || len(jobs) > 0 {
+            w.WriteHeader(http.StatusConflict)
+            _ = json.NewEncoder(w).Encode(map[string]any{
+                "error":     "topic is in use; pass force=true to delete anyway",
+                "pipelines": pipes,
+                "jobs":      jobs,
+            })
+            return
+        }
+    }
+    if err := h.Admin.DeleteTopic(r.Context(), name); err != nil {
+        h.Logger.Sugar().Warnw("kafka.delete_topic.failed", "topic", name, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    h.Logger.Sugar().Infow("kafka.delete_topic", "topic", name, "force", force, "operator", operator(r))
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
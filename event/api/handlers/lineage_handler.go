@@ -0,0 +1,60 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strings"
+
+    "event/config"
+    "event/services"
+    "event/utils"
+    "github.com/go-chi/chi/v5"
+    "go.uber.org/zap"
+)
+
+type LineageHandler struct {
+    Cfg     config.Config
+    Logger  *zap.Logger
+    Lineage *services.LineageService
+}
+
+func NewLineageHandler(cfg config.Config, logger *zap.Logger, store *services.Store, sr *services.StarRocksClient) *LineageHandler {
+    return &LineageHandler{Cfg: cfg, Logger: logger, Lineage: services.NewLineageService(cfg, store, sr)}
+}
+
+// lineageStatus 将血缘构建错误映射为状态码：StarRocks 不可达属于上游故障
+func lineageStatus(err error) int {
+    if errors.Is(err, utils.ErrNotFound) || errors.Is(err, utils.ErrInvalid) { return errStatus(err) }
+    return http.StatusBadGateway
+}
+
+// Get 返回完整血缘图；?node=table:page_views 时只返回该节点的上下游
+func (h *LineageHandler) Get(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    g, err := h.Lineage.Build(r.Context())
+    if err == nil {
+        if node := strings.TrimSpace(r.URL.Query().Get("node")); node != "" { g, err = g.Around(node) }
+    }
+    if err != nil {
+        h.Logger.Sugar().Warnw("lineage.get.failed", "err", err)
+        w.WriteHeader(lineageStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(g)
+}
+
+// GetPipeline 返回单条管道的血缘：主题 → 作业 → 表 → 下游物化视图
+func (h *LineageHandler) GetPipeline(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    g, err := h.Lineage.ForPipeline(r.Context(), name)
+    if err != nil {
+        h.Logger.Sugar().Warnw("lineage.pipeline.failed", "name", name, "err", err)
+        w.WriteHeader(lineageStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(g)
+}
\ No newline at end of file
@@ -2,28 +2,313 @@ package handlers
 
 import (
     "encoding/json"
+    "errors"
+    "io"
     "net/http"
+    "strconv"
+    "strings"
 
+    "event/api/models"
     "event/config"
+    "event/services"
+    "event/utils"
+    "github.com/go-chi/chi/v5"
     "go.uber.org/zap"
+    "gopkg.in/yaml.v3"
 )
 
 type PipelinesHandler struct {
-    Cfg    config.Config
-    Logger *zap.Logger
+    Cfg        config.Config
+    Logger     *zap.Logger
+    Pipelines  *services.PipelineService
+    Reconciler *services.Reconciler
+    Health     *services.HealthMonitor
 }
 
-func NewPipelinesHandler(cfg config.Config, logger *zap.Logger) *PipelinesHandler {
-    return &PipelinesHandler{Cfg: cfg, Logger: logger}
+func NewPipelinesHandler(cfg config.Config, logger *zap.Logger, store *services.Store, sr *services.StarRocksClient, rec *services.Reconciler, health *services.HealthMonitor) *PipelinesHandler {
+    return &PipelinesHandler{Cfg: cfg, Logger: logger, Pipelines: services.NewPipelineService(cfg, store, sr), Reconciler: rec, Health: health}
 }
 
-type PipelineItem struct {
-    Name   string `json:"name"`
-    Status string `json:"status"`
+// operator 返回请求方标识（由网关或前端通过 X-User 头传入），用于版本记录
+func operator(r *http.Request) string {
+    return strings.TrimSpace(r.Header.Get("X-User"))
 }
 
+// errStatus 将服务层错误映射为 HTTP 状态码
+func errStatus(err error) int {
+    switch {
+    case errors.Is(err, utils.ErrNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, utils.ErrConflict):
+        return http.StatusConflict
+    case errors.Is(err, utils.ErrInvalid):
+        return http.StatusBadRequest
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+// List 返回管道列表；?selector=team=growth,tier=gold 按标签过滤，?q= 按名称、描述、负责人、主题等全文匹配
 func (h *PipelinesHandler) List(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
-    // 初稿返回空列表，后续接入 services/pipeline_service
-    _ = json.NewEncoder(w).Encode([]PipelineItem{})
+    sel, err := services.ParseSelector(r.URL.Query().Get("selector"))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    pipes, err := h.Pipelines.List(r.Context())
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.list.failed", "err", err)
+        w.WriteHeader(http.StatusInternalServerError)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    pipes = services.FilterPipelines(pipes, services.PipelineFilter{Selector: sel, Query: strings.TrimSpace(r.URL.Query().Get("q"))})
+    _ = json.NewEncoder(w).Encode(pipes)
+}
+
+// Get 返回单个管道定义
+func (h *PipelinesHandler) Get(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    p, err := h.Pipelines.Get(r.Context(), name)
+    if err != nil {
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(p)
+}
+
+// Create 新建管道定义
+func (h *PipelinesHandler) Create(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    var req models.Pipeline
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    p, err := h.Pipelines.Create(services.WithOperator(r.Context(), operator(r)), req)
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.create.failed", "name", req.Name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    w.WriteHeader(http.StatusCreated)
+    _ = json.NewEncoder(w).Encode(p)
+}
+
+// Update 整体替换管道定义
+func (h *PipelinesHandler) Update(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var req models.Pipeline
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    p, err := h.Pipelines.Update(services.WithOperator(r.Context(), operator(r)), name, req)
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.update.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(p)
+}
+
+// Delete 删除管道定义（不影响已存在的 Routine Load）
+func (h *PipelinesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    if err := h.Pipelines.Delete(r.Context(), name); err != nil {
+        h.Logger.Sugar().Warnw("pipelines.delete.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+// Apply 接收 YAML/JSON 管道描述，依次创建主题、表与 Routine Load，失败时自动回滚
+func (h *PipelinesHandler) Apply(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "read body failed"})
+        return
+    }
+    spec, err := services.ParsePipelineSpec(body)
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    res, err := h.Pipelines.Apply(services.WithOperator(r.Context(), operator(r)), *spec)
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.apply.rejected", "name", spec.Name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    if !res.OK {
+        h.Logger.Sugar().Warnw("pipelines.apply.failed", "name", spec.Name, "steps", res.Steps)
+        w.WriteHeader(http.StatusInternalServerError)
+    } else {
+        w.WriteHeader(http.StatusCreated)
+    }
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+// GetHealth 返回全部管道的健康评估（healthy/degraded/stalled/failed 及原因）
+func (h *PipelinesHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    list, err := h.Health.Check(r.Context())
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.health.failed", "err", err)
+        w.WriteHeader(http.StatusBadGateway)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(list)
+}
+
+// GetReconcileReport 返回最近一轮收敛的结果
+func (h *PipelinesHandler) GetReconcileReport(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    rep := h.Reconciler.LastReport()
+    if rep == nil {
+        w.WriteHeader(http.StatusNotFound)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "no reconcile run yet"})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(rep)
+}
+
+// Reconcile 立即执行一轮收敛；?dry_run=true 时只返回计划动作
+func (h *PipelinesHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+    rep := h.Reconciler.Reconcile(r.Context(), dryRun)
+    if rep.Error != "" {
+        h.Logger.Sugar().Warnw("pipelines.reconcile.failed", "err", rep.Error)
+        w.WriteHeader(http.StatusBadGateway)
+    }
+    _ = json.NewEncoder(w).Encode(rep)
+}
+
+// ListVersions 返回管道的版本历史
+func (h *PipelinesHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    versions, err := h.Pipelines.ListVersions(r.Context(), name)
+    if err != nil {
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(versions)
+}
+
+// DiffVersions 比较两个版本：?from=1&to=2
+func (h *PipelinesHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+    to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+    if errFrom != nil || errTo != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "from and to must be version numbers"})
+        return
+    }
+    diff, err := h.Pipelines.DiffVersions(r.Context(), name, from, to)
+    if err != nil {
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(diff)
+}
+
+// Rollback 回滚到指定版本，属性通过 暂停 → ALTER → 恢复 重新应用
+func (h *PipelinesHandler) Rollback(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    version, err := strconv.Atoi(chi.URLParam(r, "version"))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid version"})
+        return
+    }
+    res, err := h.Pipelines.Rollback(services.WithOperator(r.Context(), operator(r)), name, version)
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.rollback.failed", "name", name, "version", version, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(res)
+}
+
+// Export 导出全部管道为可迁移的包，默认 YAML，?format=json 时返回 JSON
+func (h *PipelinesHandler) Export(w http.ResponseWriter, r *http.Request) {
+    b, err := h.Pipelines.Export(r.Context())
+    if err != nil {
+        h.Logger.Sugar().Warnw("pipelines.export.failed", "err", err)
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusInternalServerError)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    for _, warn := range b.Warnings {
+        h.Logger.Sugar().Warnw("pipelines.export.warning", "detail", warn)
+    }
+    if strings.EqualFold(r.URL.Query().Get("format"), "json") {
+        w.Header().Set("Content-Type", "application/json")
+        w.Header().Set("Content-Disposition", "attachment; filename=pipelines-"+h.Cfg.Server.Env+".json")
+        _ = json.NewEncoder(w).Encode(b)
+        return
+    }
+    out, err := yaml.Marshal(b)
+    if err != nil {
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusInternalServerError)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    w.Header().Set("Content-Type", "application/yaml")
+    w.Header().Set("Content-Disposition", "attachment; filename=pipelines-"+h.Cfg.Server.Env+".yaml")
+    _, _ = w.Write(out)
+}
+
+// Import 回放导出包；?broker_list= 可将作业的 kafka_broker_list 替换为目标环境地址
+func (h *PipelinesHandler) Import(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    body, err := io.ReadAll(io.LimitReader(r.Body, 8<<20))
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "read body failed"})
+        return
+    }
+    b, err := services.ParseBundle(body)
+    if err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    opts := services.ImportOptions{BrokerList: strings.TrimSpace(r.URL.Query().Get("broker_list"))}
+    results := h.Pipelines.Import(services.WithOperator(r.Context(), operator(r)), *b, opts)
+    ok := true
+    for _, res := range results {
+        if res.Action == "failed" {
+            ok = false
+            h.Logger.Sugar().Warnw("pipelines.import.failed", "pipeline", res.Pipeline, "err", res.Error, "steps", res.Steps)
+        }
+    }
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": ok, "source_env": b.SourceEnv, "results": results})
 }
\ No newline at end of file
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "event/api/models"
+    "event/config"
+    "event/services"
+    "github.com/go-chi/chi/v5"
+    "go.uber.org/zap"
+)
+
+type SchedulesHandler struct {
+    Cfg       config.Config
+    Logger    *zap.Logger
+    Scheduler *services.Scheduler
+}
+
+func NewSchedulesHandler(cfg config.Config, logger *zap.Logger, sched *services.Scheduler) *SchedulesHandler {
+    return &SchedulesHandler{Cfg: cfg, Logger: logger, Scheduler: sched}
+}
+
+// scheduleRequest 为新增/修改计划窗口的请求体；enabled 缺省为 true
+type scheduleRequest struct {
+    Cron        string `json:"cron"`
+    DurationMin int    `json:"duration_min"`
+    Timezone    string `json:"timezone"`
+    Comment     string `json:"comment"`
+    Enabled     *bool  `json:"enabled"`
+}
+
+// ListAll 返回全部管道的计划窗口
+func (h *SchedulesHandler) ListAll(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(w).Encode(h.Scheduler.List(""))
+}
+
+// List 返回单个管道的计划窗口
+func (h *SchedulesHandler) List(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(w).Encode(h.Scheduler.List(chi.URLParam(r, "name")))
+}
+
+// Create 为管道新增计划窗口，如 {"cron":"0 2 * * *","duration_min":60,"comment":"compaction"}
+func (h *SchedulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var req scheduleRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    sc := models.PipelineSchedule{
+        Cron:        req.Cron,
+        DurationMin: req.DurationMin,
+        Timezone:    req.Timezone,
+        Comment:     strings.TrimSpace(req.Comment),
+        Enabled:     req.Enabled == nil || *req.Enabled,
+    }
+    ctx := services.WithOperator(r.Context(), operator(r))
+    out, err := h.Scheduler.Add(ctx, name, sc)
+    if err != nil {
+        h.Logger.Sugar().Warnw("schedules.create.failed", "pipeline", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    w.WriteHeader(http.StatusCreated)
+    _ = json.NewEncoder(w).Encode(out)
+}
+
+// Update 启用或停用计划窗口：{"enabled":false}
+func (h *SchedulesHandler) Update(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name, id := chi.URLParam(r, "name"), chi.URLParam(r, "id")
+    var req scheduleRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "body must be {\"enabled\": true|false}"})
+        return
+    }
+    out, err := h.Scheduler.SetEnabled(r.Context(), name, id, *req.Enabled)
+    if err != nil {
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(out)
+}
+
+// Delete 删除计划窗口
+func (h *SchedulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name, id := chi.URLParam(r, "name"), chi.URLParam(r, "id")
+    if err := h.Scheduler.Delete(r.Context(), name, id); err != nil {
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+// Runs 返回计划窗口的执行记录：?pipeline=orders&limit=50
+func (h *SchedulesHandler) Runs(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    _ = json.NewEncoder(w).Encode(h.Scheduler.Runs(r.URL.Query().Get("pipeline"), limit))
+}
\ No newline at end of file
@@ -3,6 +3,7 @@ package handlers
 import (
     "encoding/json"
     "net/http"
+    "sort"
     "strconv"
     "strings"
 
@@ -13,13 +14,40 @@ import (
 )
 
 type StarRocksHandler struct {
-    Cfg    config.Config
-    Logger *zap.Logger
-    Client *services.StarRocksClient
+    Cfg       config.Config
+    Logger    *zap.Logger
+    Client    *services.StarRocksClient
+    Kafka     *services.KafkaAdmin
+    Pipelines *services.PipelineService
 }
 
-func NewStarRocksHandler(cfg config.Config, logger *zap.Logger) *StarRocksHandler {
-    return &StarRocksHandler{Cfg: cfg, Logger: logger, Client: services.NewStarRocksClient(cfg)}
+func NewStarRocksHandler(cfg config.Config, logger *zap.Logger, store *services.Store, sr *services.StarRocksClient) *StarRocksHandler {
+    return &StarRocksHandler{
+        Cfg: cfg, Logger: logger,
+        Client:    sr,
+        Kafka:     services.NewKafkaAdmin(cfg),
+        Pipelines: services.NewPipelineService(cfg, store, sr),
+    }
+}
+
+// alterFailure 为暂停后修改失败的响应体，附带作业是否已恢复，恢复失败时作业仍处于 PAUSED
+func alterFailure(err error, out services.AlterOutcome) map[string]any {
+    body := map[string]any{"error": err.Error(), "paused": out.Paused, "resumed": out.Resumed}
+    if out.ResumeErr != nil { body["resume_error"] = out.ResumeErr.Error() }
+    return body
+}
+
+// syncDesiredState 将手动操作同步为管道的期望状态，失败只记录日志
+func (h *StarRocksHandler) syncDesiredState(name, state string) {
+    if err := h.Pipelines.SetDesiredStateByJob(name, state); err != nil {
+        h.Logger.Sugar().Warnw("starrocks.sync_desired_state.failed", "name", name, "state", state, "err", err)
+    }
+}
+
+// PoolStats 返回到 FE 的共享连接池统计
+func (h *StarRocksHandler) PoolStats(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(w).Encode(h.Client.PoolStats())
 }
 
 type RLJob struct {
@@ -70,7 +98,12 @@ func (h *StarRocksHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("X-Total-Count", strconv.Itoa(total))
     w.Header().Set("X-Page", strconv.Itoa(page))
     w.Header().Set("X-Page-Size", strconv.Itoa(pageSize))
-    _ = json.NewEncoder(w).Encode(jobs[start:end])
+    // 仅为当前页的作业计算积压；Kafka 不可达时积压字段为空
+    pageJobs := jobs[start:end]
+    if err := h.Kafka.FillRoutineLoadLag(r.Context(), pageJobs); err != nil {
+        h.Logger.Sugar().Warnw("starrocks.list_jobs.lag_failed", "err", err)
+    }
+    _ = json.NewEncoder(w).Encode(pageJobs)
 }
 
 // GetJob 返回指定 Routine Load 的详细配置
@@ -85,7 +118,7 @@ func (h *StarRocksHandler) GetJob(w http.ResponseWriter, r *http.Request) {
     d, err := h.Client.GetRoutineLoadDetails(r.Context(), name)
     if err != nil {
         h.Logger.Sugar().Warnw("starrocks.get_job.failed", "name", name, "err", err)
-        w.WriteHeader(http.StatusInternalServerError)
+        w.WriteHeader(errStatus(err))
         _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
         return
     }
@@ -113,7 +146,7 @@ func (h *StarRocksHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 
     if err := h.Client.CreateRoutineLoad(r.Context(), req); err != nil {
         h.Logger.Sugar().Warnw("starrocks.create_job.failed", "err", err)
-        w.WriteHeader(http.StatusInternalServerError)
+        w.WriteHeader(errStatus(err))
         _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
         return
     }
@@ -131,6 +164,7 @@ func (h *StarRocksHandler) PauseJob(w http.ResponseWriter, r *http.Request) {
         _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
         return
     }
+    h.syncDesiredState(name, "PAUSED")
     _ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 }
 
@@ -145,6 +179,7 @@ func (h *StarRocksHandler) ResumeJob(w http.ResponseWriter, r *http.Request) {
         _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
         return
     }
+    h.syncDesiredState(name, "RUNNING")
     _ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 }
 
@@ -159,6 +194,7 @@ func (h *StarRocksHandler) StopJob(w http.ResponseWriter, r *http.Request) {
         _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
         return
     }
+    h.syncDesiredState(name, "STOPPED")
     _ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 }
 
@@ -184,45 +220,127 @@ func (h *StarRocksHandler) UpdateJobProperties(w http.ResponseWriter, r *http.Re
         _ = json.NewEncoder(w).Encode(map[string]string{"error": "no properties"})
         return
     }
-    // 根据官方要求，仅允许在 PAUSED 状态下修改。若非 PAUSED，则先暂停。
-    paused := false
-    resumed := false
-    // 查询当前状态
-    d, derr := h.Client.GetRoutineLoadDetails(r.Context(), name)
-    if derr == nil {
-        st := strings.ToUpper(strings.TrimSpace(d.State))
-        if st != "PAUSED" {
-            if err := h.Client.PauseRoutineLoad(r.Context(), name); err == nil {
-                paused = true
-            } else {
-                h.Logger.Sugar().Warnw("starrocks.update_job.pause_failed", "name", name, "err", err)
-            }
-        } else {
-            paused = true
-        }
-        // 执行修改
-        if err := h.Client.UpdateRoutineLoadProperties(r.Context(), name, req.Properties); err != nil {
-            h.Logger.Sugar().Warnw("starrocks.update_job.failed", "name", name, "err", err)
-            w.WriteHeader(http.StatusInternalServerError)
-            _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-            return
-        }
-        // 如果原状态是 RUNNING，修改后自动恢复
-        if st == "RUNNING" {
-            if err := h.Client.ResumeRoutineLoad(r.Context(), name); err == nil {
-                resumed = true
-            } else {
-                h.Logger.Sugar().Warnw("starrocks.update_job.resume_failed", "name", name, "err", err)
-            }
-        }
-    } else {
-        // 查询状态失败时，仍尝试修改（可能被后端拒绝）。
-        if err := h.Client.UpdateRoutineLoadProperties(r.Context(), name, req.Properties); err != nil {
-            h.Logger.Sugar().Warnw("starrocks.update_job.failed_no_state", "name", name, "err", err)
-            w.WriteHeader(http.StatusInternalServerError)
-            _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-            return
-        }
+    // 根据官方要求，仅允许在 PAUSED 状态下修改。若非 PAUSED，则先暂停，原本 RUNNING 的作业修改后自动恢复。
+    out, err := h.Client.AlterWithPause(r.Context(), name, req.Properties)
+    if out.PauseErr != nil {
+        h.Logger.Sugar().Warnw("starrocks.update_job.pause_failed", "name", name, "err", out.PauseErr)
+    }
+    if out.ResumeErr != nil {
+        h.Logger.Sugar().Warnw("starrocks.update_job.resume_failed", "name", name, "err", out.ResumeErr)
+    }
+    if err != nil {
+        event := "starrocks.update_job.failed"
+        // 查询状态失败时仍尝试修改（可能被后端拒绝）。
+        if out.Before == nil { event = "starrocks.update_job.failed_no_state" }
+        h.Logger.Sugar().Warnw(event, "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(alterFailure(err, out))
+        return
+    }
+    // 作业属于某条管道时记录一个新版本，便于追溯与回滚
+    ctx := services.WithOperator(r.Context(), operator(r))
+    version, err := h.Pipelines.RecordJobProperties(ctx, name, out.Before, out.Applied)
+    if err != nil {
+        h.Logger.Sugar().Warnw("starrocks.update_job.record_version_failed", "name", name, "err", err)
+    }
+    // 白名单之外的属性不会写入，在响应中列出
+    ignored := []string{}
+    for k := range req.Properties {
+        if _, ok := out.Applied[k]; !ok { ignored = append(ignored, k) }
+    }
+    sort.Strings(ignored)
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "paused": out.Paused, "resumed": out.Resumed, "version": version, "applied": out.Applied, "ignored": ignored})
+}
+
+// ListTasks 返回作业当前的导入任务（SHOW ROUTINE LOAD TASK）
+func (h *StarRocksHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    tasks, err := h.Client.ListRoutineLoadTasks(r.Context(), name)
+    if err != nil {
+        h.Logger.Sugar().Warnw("starrocks.list_tasks.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
     }
-    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "paused": paused, "resumed": resumed})
-}
\ No newline at end of file
+    _ = json.NewEncoder(w).Encode(tasks)
+}
+
+// JobErrors 从 ErrorLogUrls 读取 BE 错误日志，返回前 ?limit=（默认 50，最多 1000）条被拒绝的行及原因。
+// 所有日志地址都读取失败时返回 502，响应中仍带有地址与各自的错误
+func (h *StarRocksHandler) JobErrors(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    log, err := h.Client.RoutineLoadErrors(r.Context(), name, limit)
+    if err != nil {
+        h.Logger.Sugar().Warnw("starrocks.job_errors.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    if len(log.Rows) == 0 && len(log.FetchErrors) == len(log.URLs) && len(log.URLs) > 0 {
+        h.Logger.Sugar().Warnw("starrocks.job_errors.fetch_failed", "name", name, "errors", log.FetchErrors)
+        w.WriteHeader(http.StatusBadGateway)
+    }
+    _ = json.NewEncoder(w).Encode(log)
+}
+
+// SeekJob 修改作业的消费起点：{"to":"timestamp","ago":"1h"} / {"to":"offset","offset":100,"partitions":[0]}。
+// 目标位置由 Kafka 解析为各分区的具体 offset，再按“暂停 → ALTER → 恢复”流程写入；dry_run 只返回解析结果
+func (h *StarRocksHandler) SeekJob(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var req struct {
+        services.OffsetTarget
+        DryRun bool `json:"dry_run"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    d, err := h.Client.GetRoutineLoadDetails(r.Context(), name)
+    if err != nil {
+        h.Logger.Sugar().Warnw("starrocks.seek_job.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    topic := d.Kafka["topic"]
+    if topic == "" {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "job has no kafka topic"})
+        return
+    }
+    // ALTER ROUTINE LOAD ... FROM KAFKA 只接受作业已在消费的分区，未指定时取作业当前的分区
+    // （而不是主题的全部分区：显式指定了 kafka_partitions 或尚未感知新增分区的作业会被 FE 拒绝）
+    if len(req.Partitions) == 0 { req.Partitions = d.CurrentPartitions() }
+    offsets, err := h.Kafka.ResolveOffsets(r.Context(), topic, req.OffsetTarget)
+    if err != nil {
+        h.Logger.Sugar().Warnw("starrocks.seek_job.resolve_failed", "name", name, "topic", topic, "err", err)
+        w.WriteHeader(kafkaStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    if req.DryRun {
+        _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "dry_run": true, "topic": topic, "partitions": offsets})
+        return
+    }
+    parts, offs := services.RoutineLoadOffsets(offsets)
+    out, err := h.Client.SeekWithPause(r.Context(), name, parts, offs)
+    if out.PauseErr != nil {
+        h.Logger.Sugar().Warnw("starrocks.seek_job.pause_failed", "name", name, "err", out.PauseErr)
+    }
+    if out.ResumeErr != nil {
+        h.Logger.Sugar().Warnw("starrocks.seek_job.resume_failed", "name", name, "err", out.ResumeErr)
+    }
+    if err != nil {
+        h.Logger.Sugar().Warnw("starrocks.seek_job.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(alterFailure(err, out))
+        return
+    }
+    h.Logger.Sugar().Infow("starrocks.seek_job", "name", name, "topic", topic, "to", req.To, "operator", operator(r))
+    _ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "paused": out.Paused, "resumed": out.Resumed, "topic": topic, "partitions": offsets})
+}
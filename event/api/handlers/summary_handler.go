@@ -2,7 +2,9 @@ package handlers
 
 import (
     "encoding/json"
+    "fmt"
     "net/http"
+    "sort"
 
     "event/config"
     "event/services"
@@ -14,10 +16,14 @@ type SummaryHandler struct {
     Logger *zap.Logger
     Admin  *services.KafkaAdmin
     SR     *services.StarRocksClient
+    Pipelines *services.PipelineService
+    Health    *services.HealthMonitor
+    Scheduler *services.Scheduler
+    Sampler   *services.ThroughputSampler
 }
 
-func NewSummaryHandler(cfg config.Config, logger *zap.Logger) *SummaryHandler {
-    return &SummaryHandler{Cfg: cfg, Logger: logger, Admin: services.NewKafkaAdmin(cfg), SR: services.NewStarRocksClient(cfg)}
+func NewSummaryHandler(cfg config.Config, logger *zap.Logger, store *services.Store, sr *services.StarRocksClient, health *services.HealthMonitor, sched *services.Scheduler, sampler *services.ThroughputSampler) *SummaryHandler {
+    return &SummaryHandler{Cfg: cfg, Logger: logger, Admin: services.NewKafkaAdmin(cfg), SR: sr, Pipelines: services.NewPipelineService(cfg, store, sr), Health: health, Scheduler: sched, Sampler: sampler}
 }
 
 type Summary struct {
@@ -35,6 +41,15 @@ type PipelinesSummary struct {
     Running      int `json:"running"`
     Paused       int `json:"paused"`
     NeedSchedule int `json:"need_schedule"`
+    // 配置了计划维护窗口的管道数，以及当前处于窗口内的管道数
+    Scheduled int `json:"scheduled"`
+    InWindow  int `json:"in_window"`
+    // 健康度分布；Unhealthy 列出非 healthy 的管道及原因，按严重程度排序
+    Healthy   int                       `json:"healthy"`
+    Degraded  int                       `json:"degraded"`
+    Stalled   int                       `json:"stalled"`
+    Failed    int                       `json:"failed"`
+    Unhealthy []services.PipelineHealth `json:"unhealthy"`
 }
 
 type JobsSummary struct {
@@ -45,21 +60,29 @@ type JobsSummary struct {
 }
 
 type KafkaSummary struct {
-    Topics          int `json:"topics"`
-    Partitions      int `json:"partitions"`
-    UnderReplicated int `json:"under_replicated"`
+    Topics            int `json:"topics"`
+    Partitions        int `json:"partitions"`
+    UnderReplicated   int `json:"under_replicated"`
+    OfflinePartitions int `json:"offline_partitions"` // 没有 leader 的分区
 }
 
+// ThroughputInfo 中 Current 为 StarRocks 侧近 1 分钟入库行数；KafkaPerMin 为 Kafka 侧近 5 分钟的平均写入条数/分钟，
+// 由高水位采样计算，采样不足时为空
 type ThroughputInfo struct {
-    Current int   `json:"current"`     // rows/min 或 messages/min
+    Current     int      `json:"current"`     // rows/min 或 messages/min
+    KafkaPerMin *float64 `json:"kafka_per_min,omitempty"`
 }
 
 type ErrorsInfo struct {
     Last10m int `json:"last_10m"`
 }
 
+// LagInfo 中 P95ms 为表数据新鲜度的近似；Messages 为 Routine Load 按 Progress 与高水位计算的总积压，
+// TopJob 为积压最大的作业
 type LagInfo struct {
-    P95ms int `json:"p95_ms"`
+    P95ms    int    `json:"p95_ms"`
+    Messages int64  `json:"messages"`
+    TopJob   string `json:"top_job,omitempty"`
 }
 
 type AnomalyItem struct {
@@ -67,6 +90,7 @@ type AnomalyItem struct {
     Type  string `json:"type"` // pipeline/job/topic
     State string `json:"state"`
     Count int    `json:"count"` // 可选计数，如错误数
+    Reason string `json:"reason,omitempty"`
 }
 
 func (h *SummaryHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -78,14 +102,17 @@ func (h *SummaryHandler) Get(w http.ResponseWriter, r *http.Request) {
         h.Logger.Sugar().Warnw("summary.kafka.failed", "err", err)
         topics = []services.TopicInfo{}
     }
-    kafka := KafkaSummary{Topics: len(topics), UnderReplicated: 0}
-    // 估算分区总数
-    parts := 0
-    for _, t := range topics { parts += t.Partitions }
-    kafka.Partitions = parts
+    // 分区总数与副本健康（ISR 少于副本数为欠副本）
+    kafka := KafkaSummary{Topics: len(topics)}
+    for _, t := range topics {
+        kafka.Partitions += t.Partitions
+        kafka.UnderReplicated += t.UnderReplicated
+        kafka.OfflinePartitions += t.Offline
+    }
 
     // StarRocks Jobs
     jobs, err := h.SR.ListRoutineLoad(r.Context())
+    jobsOK := err == nil
     if err != nil {
         h.Logger.Sugar().Warnw("summary.jobs.failed", "err", err)
         jobs = []services.RLJob{}
@@ -103,8 +130,52 @@ func (h *SummaryHandler) Get(w http.ResponseWriter, r *http.Request) {
     }
     jobsSum := JobsSummary{Total: len(jobs), Running: jobsRunning, Paused: jobsPaused, Failed: jobsFailed}
 
-    // Pipelines（暂未实现服务，返回0摘要）
-    pipes := PipelinesSummary{Total: 0, Running: 0, Paused: 0, NeedSchedule: 0}
+    // Pipelines：按管道定义关联的作业状态汇总（作业列表获取失败时仅统计总数）
+    var states map[string]string
+    if jobsOK { states = services.JobStates(jobs) }
+    pipeList, err := h.Pipelines.ListWithStates(states)
+    if err != nil {
+        h.Logger.Sugar().Warnw("summary.pipelines.failed", "err", err)
+    }
+    pipes := PipelinesSummary{Total: len(pipeList), Unhealthy: []services.PipelineHealth{}}
+    for _, p := range pipeList {
+        switch normalizeState(p.Status) {
+        case "RUNNING":
+            pipes.Running++
+        case "PAUSED":
+            pipes.Paused++
+        case "NEED_SCHEDULE", "NEED_SCHEDULING":
+            pipes.NeedSchedule++
+        }
+    }
+    scheduled, inWindow := map[string]bool{}, map[string]bool{}
+    for _, sc := range h.Scheduler.List("") {
+        if !sc.Enabled { continue }
+        scheduled[sc.Pipeline] = true
+        if sc.Active { inWindow[sc.Pipeline] = true }
+    }
+    pipes.Scheduled, pipes.InWindow = len(scheduled), len(inWindow)
+    // 健康度：需要作业列表，获取失败时不评估，避免全部误判为 failed
+    if jobsOK {
+        healthList, err := h.Health.Evaluate(r.Context(), jobs)
+        if err != nil { h.Logger.Sugar().Warnw("summary.health.failed", "err", err) }
+        for _, ph := range healthList {
+            switch ph.Health {
+            case services.HealthHealthy:
+                pipes.Healthy++
+                continue
+            case services.HealthDegraded:
+                pipes.Degraded++
+            case services.HealthStalled:
+                pipes.Stalled++
+            case services.HealthFailed:
+                pipes.Failed++
+            }
+            pipes.Unhealthy = append(pipes.Unhealthy, ph)
+        }
+        rank := map[string]int{services.HealthFailed: 0, services.HealthStalled: 1, services.HealthDegraded: 2}
+        sort.SliceStable(pipes.Unhealthy, func(i, j int) bool { return rank[pipes.Unhealthy[i].Health] < rank[pipes.Unhealthy[j].Health] })
+    }
 
     // 指标：基于事件表的实时近似统计
     // 事件表集合（包含 event_time 列的表）
@@ -117,6 +188,10 @@ func (h *SummaryHandler) Get(w http.ResponseWriter, r *http.Request) {
     tpVal, err := h.SR.CountRowsLastMinutes(r.Context(), eventTables, 1)
     if err != nil { h.Logger.Sugar().Warnw("summary.throughput.failed", "err", err); tpVal = 0 }
     tp := ThroughputInfo{Current: tpVal}
+    if perSec, ok := h.Sampler.TotalPerSec(); ok {
+        perMin := perSec * 60
+        tp.KafkaPerMin = &perMin
+    }
     // 错误行（近10分钟）：errors 表的最近 10 分钟
     err10m, err := h.SR.CountErrorsLastMinutes(r.Context(), 10)
     if err != nil { h.Logger.Sugar().Warnw("summary.errors.failed", "err", err); err10m = 0 }
@@ -125,10 +200,34 @@ func (h *SummaryHandler) Get(w http.ResponseWriter, r *http.Request) {
     lagMs, err := h.SR.ComputeFreshnessLagMs(r.Context(), eventTables)
     if err != nil { h.Logger.Sugar().Warnw("summary.lag.failed", "err", err); lagMs = 0 }
     lag := LagInfo{P95ms: lagMs}
+    // 积压由健康度评估时补齐到 jobs 中
+    var topLag int64
+    for _, j := range jobs {
+        if j.Lag == nil { continue }
+        lag.Messages += *j.Lag
+        if *j.Lag > topLag { topLag, lag.TopJob = *j.Lag, j.Name }
+    }
 
-    // 异常 Top3：从 jobs 中选非 RUNNING 的前三
+    // 异常 Top3：优先取健康度异常的管道（附原因），不足时从 jobs 中补非 RUNNING 的作业
     anomalies := make([]AnomalyItem, 0, 3)
+    listed := map[string]bool{}
+    for _, ph := range pipes.Unhealthy {
+        if len(anomalies) == 3 { break }
+        reason := ""
+        if len(ph.Reasons) > 0 { reason = ph.Reasons[0] }
+        anomalies = append(anomalies, AnomalyItem{Name: ph.Pipeline, Type: "pipeline", State: ph.Health, Count: ph.ErrorGrowth, Reason: reason})
+        listed[ph.Job] = true
+    }
+    // 有分区失去 leader 的主题：该分区无法读写
+    for _, t := range topics {
+        if len(anomalies) == 3 { break }
+        if t.Offline == 0 { continue }
+        anomalies = append(anomalies, AnomalyItem{Name: t.Name, Type: "topic", State: "OFFLINE", Count: t.Offline,
+            Reason: fmt.Sprintf("%d of %d partitions have no leader", t.Offline, t.Partitions)})
+    }
     for _, j := range jobs {
+        if len(anomalies) == 3 { break }
+        if listed[j.Name] { continue }
         s := normalizeState(j.State)
         if s != "RUNNING" {
             anomalies = append(anomalies, AnomalyItem{Name: j.Name, Type: "job", State: s, Count: 0})
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+    "encoding/json"
+    "net/http"
+
+    "event/config"
+    "event/services"
+    "github.com/go-chi/chi/v5"
+    "go.uber.org/zap"
+)
+
+type TemplatesHandler struct {
+    Cfg       config.Config
+    Logger    *zap.Logger
+    Templates *services.TemplateService
+}
+
+func NewTemplatesHandler(cfg config.Config, logger *zap.Logger) *TemplatesHandler {
+    return &TemplatesHandler{Cfg: cfg, Logger: logger, Templates: services.NewTemplateService(cfg)}
+}
+
+// List 返回模板目录中的全部模板
+func (h *TemplatesHandler) List(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    list, err := h.Templates.List()
+    if err != nil {
+        h.Logger.Sugar().Warnw("templates.list.failed", "dir", h.Cfg.Templates.Dir, "err", err)
+        w.WriteHeader(http.StatusInternalServerError)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(list)
+}
+
+// Get 返回单个模板
+func (h *TemplatesHandler) Get(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    t, err := h.Templates.Get(chi.URLParam(r, "name"))
+    if err != nil {
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(t)
+}
+
+// Expand 填入主题与表名，返回 RLCreateRequest、建表 DDL 与完整管道描述
+func (h *TemplatesHandler) Expand(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    name := chi.URLParam(r, "name")
+    var req services.TemplateParams
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        w.WriteHeader(http.StatusBadRequest)
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid json"})
+        return
+    }
+    exp, err := h.Templates.Expand(name, req)
+    if err != nil {
+        h.Logger.Sugar().Warnw("templates.expand.failed", "name", name, "err", err)
+        w.WriteHeader(errStatus(err))
+        _ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+        return
+    }
+    _ = json.NewEncoder(w).Encode(exp)
+}
\ No newline at end of file
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TopicConfigChange 记录一次主题配置修改；New 为空且 Reset 为 true 表示恢复为默认值
+type TopicConfigChange struct {
+    Topic  string    `json:"topic"`
+    Config string    `json:"config"`
+    Old    string    `json:"old"`
+    New    string    `json:"new"`
+    Reset  bool      `json:"reset,omitempty"`
+    Author string    `json:"author"`
+    At     time.Time `json:"at"`
+}
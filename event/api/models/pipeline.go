@@ -1,6 +1,69 @@
 package models
 
+import "time"
+
+// Pipeline 描述一条 Kafka 主题 → Routine Load → StarRocks 表 的管道定义
+// Status 不落盘，由 Routine Load 的实时状态填充；DesiredState 为期望状态（RUNNING/PAUSED/STOPPED，缺省 RUNNING）
 type Pipeline struct {
-    Name   string `json:"name"`
-    Status string `json:"status"`
+    Name        string            `json:"name" yaml:"name"`
+    Status      string            `json:"status" yaml:"status,omitempty"`
+    SourceTopic string            `json:"source_topic" yaml:"source_topic"`
+    TargetTable string            `json:"target_table" yaml:"target_table"`
+    Columns     []string          `json:"columns,omitempty" yaml:"columns,omitempty"`
+    Set         map[string]string `json:"set,omitempty" yaml:"set,omitempty"`
+    JobName     string            `json:"job_name" yaml:"job_name"`
+    BrokerList  string            `json:"broker_list,omitempty" yaml:"broker_list,omitempty"`
+    GroupID     string            `json:"group_id,omitempty" yaml:"group_id,omitempty"`
+    Properties  map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"`
+    DesiredState string           `json:"desired_state,omitempty" yaml:"desired_state,omitempty"`
+    Owner       string            `json:"owner,omitempty" yaml:"owner,omitempty"`
+    Team        string            `json:"team,omitempty" yaml:"team,omitempty"`
+    Description string            `json:"description,omitempty" yaml:"description,omitempty"`
+    Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+    SLA         PipelineSLA       `json:"sla" yaml:"sla"`
+    CreatedAt   time.Time         `json:"created_at" yaml:"created_at"`
+    UpdatedAt   time.Time         `json:"updated_at" yaml:"updated_at"`
+}
+
+// PipelineSLA 为管道的服务等级约定，0 表示不约束
+type PipelineSLA struct {
+    FreshnessSec   int   `json:"freshness_sec,omitempty" yaml:"freshness_sec,omitempty"`       // 目标表最新数据距今的最大秒数
+    MaxLagMessages int64 `json:"max_lag_messages,omitempty" yaml:"max_lag_messages,omitempty"` // 允许的最大消费积压条数
+}
+
+// PipelineVersion 是管道定义的一次变更记录；Definition 为变更后的完整快照
+type PipelineVersion struct {
+    Pipeline      string            `json:"pipeline" yaml:"pipeline"`
+    Version       int               `json:"version" yaml:"version"`
+    Source        string            `json:"source" yaml:"source"` // create/update/job_properties/rollback
+    Author        string            `json:"author" yaml:"author"`
+    CreatedAt     time.Time         `json:"created_at" yaml:"created_at"`
+    Comment       string            `json:"comment,omitempty" yaml:"comment,omitempty"`
+    OldProperties map[string]string `json:"old_properties" yaml:"old_properties"`
+    NewProperties map[string]string `json:"new_properties" yaml:"new_properties"`
+    Definition    Pipeline          `json:"definition" yaml:"definition"`
+}
+
+// PipelineSchedule 为管道的计划维护窗口：按 Cron 命中时暂停作业，持续 DurationMin 分钟后恢复
+type PipelineSchedule struct {
+    ID          string    `json:"id" yaml:"id"`
+    Pipeline    string    `json:"pipeline" yaml:"pipeline"`
+    Cron        string    `json:"cron" yaml:"cron"` // 窗口开始时间，5 段 cron，如 "0 2 * * *"
+    DurationMin int       `json:"duration_min" yaml:"duration_min"`
+    Timezone    string    `json:"timezone,omitempty" yaml:"timezone,omitempty"` // 缺省为服务所在时区
+    Comment     string    `json:"comment,omitempty" yaml:"comment,omitempty"`
+    Enabled     bool      `json:"enabled" yaml:"enabled"`
+    Author      string    `json:"author" yaml:"author"`
+    CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
+}
+
+// ScheduleRun 记录计划窗口的一次执行
+type ScheduleRun struct {
+    ScheduleID string    `json:"schedule_id"`
+    Pipeline   string    `json:"pipeline"`
+    Job        string    `json:"job"`
+    Action     string    `json:"action"` // pause/resume
+    Result     string    `json:"result"` // ok/skipped/failed
+    Detail     string    `json:"detail,omitempty"`
+    At         time.Time `json:"at"`
 }
\ No newline at end of file
@@ -1,14 +1,18 @@
 package main
 
 import (
+    "context"
     "fmt"
     "net/http"
     "os"
+    "os/signal"
+    "syscall"
     "time"
 
     "event/config"
     "event/logs"
     "event/routers"
+    "event/services"
 )
 
 func main() {
@@ -16,7 +20,37 @@ func main() {
     logger := logs.NewLogger()
     defer logger.Sync()
 
-    r := routers.NewRouter(cfg, logger)
+    store, err := services.NewStore(cfg)
+    if err != nil {
+        logger.Sugar().Errorw("store.open.failed", "dir", cfg.Storage.DataDir, "err", err)
+        os.Exit(1)
+    }
+
+    // 全进程共用一个 StarRocks 客户端及其连接池
+    sr, err := services.NewStarRocksClient(cfg, logger)
+    if err != nil {
+        logger.Sugar().Errorw("starrocks.open.failed", "err", err)
+        os.Exit(1)
+    }
+    defer sr.Close()
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    // 计划维护窗口：按 cron 暂停/恢复作业
+    sched := services.NewScheduler(cfg, logger, store, sr)
+    go sched.Run(ctx)
+    // 后台收敛循环：让 Routine Load 向管道定义收敛（计划窗口内不恢复作业）
+    rec := services.NewReconciler(cfg, logger, store, sr, sched)
+    go rec.Run(ctx)
+    // 健康采样：积累作业计数以判定错误增长与停滞
+    monitor := services.NewHealthMonitor(cfg, logger, store, sr, sched)
+    go monitor.Run(ctx)
+    // 吞吐采样：定期记录各主题高水位，计算 Kafka 侧写入速率
+    sampler := services.NewThroughputSampler(cfg, logger)
+    go sampler.Run(ctx)
+
+    r := routers.NewRouter(cfg, logger, store, sr, rec, monitor, sched, sampler)
 
     addr := fmt.Sprintf(":%d", cfg.Server.Port)
     logger.Sugar().Infow("server.start",
@@ -33,6 +67,13 @@ func main() {
         IdleTimeout:       60 * time.Second,
     }
 
+    go func() {
+        <-ctx.Done()
+        shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+        defer cancel()
+        _ = srv.Shutdown(shutdownCtx)
+    }()
+
     if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
         logger.Sugar().Errorw("server.error", "err", err)
         os.Exit(1)
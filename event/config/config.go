@@ -14,29 +14,101 @@ type ServerConfig struct {
     Env       string `yaml:"env"`
 }
 
+// KafkaConfig 中 AddressMap 将 broker 的 advertised 地址映射为本服务可达的地址，
+// 如 {"kafka:9092": "127.0.0.1:9092"}（在宿主机上访问容器内的 broker）；未列出的地址按原样连接
 type KafkaConfig struct {
-    Brokers []string `yaml:"brokers"`
+    Brokers    []string          `yaml:"brokers"`
+    AddressMap map[string]string `yaml:"addressMap"`
+    SASL       KafkaSASLConfig   `yaml:"sasl"`
+    TLS        KafkaTLSConfig    `yaml:"tls"`
+}
+
+// KafkaSASLConfig 为 SASL 认证配置；Mechanism 为 PLAIN、SCRAM-SHA-256 或 SCRAM-SHA-512，为空表示不启用
+type KafkaSASLConfig struct {
+    Mechanism string `yaml:"mechanism"`
+    Username  string `yaml:"username"`
+    Password  string `yaml:"password"`
+}
+
+// KafkaTLSConfig 为 TLS 配置；CAFile/CertFile/KeyFile 为本服务使用的 PEM 文件，
+// RoutineLoadCA 为 StarRocks 中通过 CREATE FILE 上传的 CA（如 FILE:kafka_ca.pem），创建 Routine Load 时透传
+type KafkaTLSConfig struct {
+    Enabled            bool   `yaml:"enabled"`
+    CAFile             string `yaml:"caFile"`
+    CertFile           string `yaml:"certFile"`
+    KeyFile            string `yaml:"keyFile"`
+    InsecureSkipVerify bool   `yaml:"insecureSkipVerify"` // 仅用于开发环境
+    RoutineLoadCA      string `yaml:"routineLoadCA"`
 }
 
 type StarRocksConfig struct {
-    FEHost   string `yaml:"feHost"`
-    FEPort   int    `yaml:"fePort"`
-    User     string `yaml:"user"`
-    Password string `yaml:"password"`
-    Database string `yaml:"database"`
+    FEHost   string              `yaml:"feHost"`
+    FEPort   int                 `yaml:"fePort"`
+    User     string              `yaml:"user"`
+    Password string              `yaml:"password"`
+    Database string              `yaml:"database"`
+    Pool     StarRocksPoolConfig `yaml:"pool"`
+}
+
+// StarRocksPoolConfig 为到 FE 的连接池参数，为 0 时使用默认值
+type StarRocksPoolConfig struct {
+    MaxOpenConns       int `yaml:"maxOpenConns"`
+    MaxIdleConns       int `yaml:"maxIdleConns"`
+    ConnMaxLifetimeSec int `yaml:"connMaxLifetimeSec"`
+}
+
+type StorageConfig struct {
+    DataDir string `yaml:"dataDir"`
+}
+
+// TemplatesConfig 指定管道模板目录，目录下的 *.yaml 每个文件即一个模板
+type TemplatesConfig struct {
+    Dir string `yaml:"dir"`
+}
+
+// ReconcileConfig 控制管道收敛循环；DryRun 时仅输出计划动作不执行。DryRun 缺省为 true，
+// 需显式配置 dryRun: false 才会重建或恢复作业
+type ReconcileConfig struct {
+    Enabled     bool `yaml:"enabled"`
+    IntervalSec int  `yaml:"intervalSec"`
+    DryRun      bool `yaml:"dryRun"`
+}
+
+// HealthConfig 控制管道健康评估：按 IntervalSec 采样作业计数，
+// 运行中但 StallAfterSec 内无新增处理行视为停滞，ErrorWindowSec 内错误行增长视为降级
+type HealthConfig struct {
+    IntervalSec    int `yaml:"intervalSec"`
+    StallAfterSec  int `yaml:"stallAfterSec"`
+    ErrorWindowSec int `yaml:"errorWindowSec"`
+}
+
+// ThroughputConfig 控制 Kafka 高水位采样间隔，用于计算各主题的写入速率
+type ThroughputConfig struct {
+    IntervalSec int `yaml:"intervalSec"`
 }
 
 type Config struct {
-    Server     ServerConfig    `yaml:"server"`
-    Kafka      KafkaConfig     `yaml:"kafka"`
-    StarRocks  StarRocksConfig `yaml:"starrocks"`
+    Server     ServerConfig     `yaml:"server"`
+    Kafka      KafkaConfig      `yaml:"kafka"`
+    StarRocks  StarRocksConfig  `yaml:"starrocks"`
+    Storage    StorageConfig    `yaml:"storage"`
+    Reconcile  ReconcileConfig  `yaml:"reconcile"`
+    Templates  TemplatesConfig  `yaml:"templates"`
+    Health     HealthConfig     `yaml:"health"`
+    Throughput ThroughputConfig `yaml:"throughput"`
 }
 
 func defaultConfig() Config {
     return Config{
         Server: ServerConfig{Port: 8088, StaticDir: "ui", Env: "dev"},
         Kafka:  KafkaConfig{Brokers: []string{"kafka:9092"}},
-        StarRocks: StarRocksConfig{FEHost: "starrocks-fe", FEPort: 9030, User: "root", Password: "", Database: "eventdb"},
+        StarRocks: StarRocksConfig{FEHost: "starrocks-fe", FEPort: 9030, User: "root", Password: "", Database: "eventdb",
+            Pool: StarRocksPoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetimeSec: 300}},
+        Storage:   StorageConfig{DataDir: "data"},
+        Reconcile: ReconcileConfig{Enabled: true, IntervalSec: 60, DryRun: true},
+        Templates: TemplatesConfig{Dir: "templates"},
+        Health:    HealthConfig{IntervalSec: 30, StallAfterSec: 900, ErrorWindowSec: 600},
+        Throughput: ThroughputConfig{IntervalSec: 60},
     }
 }
 
@@ -50,10 +122,12 @@ func Load() Config {
 
     b, err := os.ReadFile(path)
     if err != nil {
+        applyEnv(&cfg)
         return cfg
     }
     var fileCfg Config
     if err := yaml.Unmarshal(b, &fileCfg); err != nil {
+        applyEnv(&cfg)
         return cfg
     }
     // 覆盖默认
@@ -61,6 +135,31 @@ func Load() Config {
     if fileCfg.Server.StaticDir != "" { cfg.Server.StaticDir = fileCfg.Server.StaticDir }
     if fileCfg.Server.Env != "" { cfg.Server.Env = fileCfg.Server.Env }
     if len(fileCfg.Kafka.Brokers) > 0 { cfg.Kafka = fileCfg.Kafka }
-    if fileCfg.StarRocks.FEHost != "" { cfg.StarRocks = fileCfg.StarRocks }
+    if fileCfg.StarRocks.FEHost != "" {
+        pool := cfg.StarRocks.Pool
+        cfg.StarRocks = fileCfg.StarRocks
+        if cfg.StarRocks.Pool.MaxOpenConns == 0 { cfg.StarRocks.Pool.MaxOpenConns = pool.MaxOpenConns }
+        if cfg.StarRocks.Pool.MaxIdleConns == 0 { cfg.StarRocks.Pool.MaxIdleConns = pool.MaxIdleConns }
+        if cfg.StarRocks.Pool.ConnMaxLifetimeSec == 0 { cfg.StarRocks.Pool.ConnMaxLifetimeSec = pool.ConnMaxLifetimeSec }
+    }
+    if fileCfg.Storage.DataDir != "" { cfg.Storage.DataDir = fileCfg.Storage.DataDir }
+    if fileCfg.Reconcile.IntervalSec != 0 { cfg.Reconcile.IntervalSec = fileCfg.Reconcile.IntervalSec }
+    // 布尔项需区分“未配置”与“配置为 false”，单独按指针解析
+    var flags struct {
+        Reconcile struct {
+            Enabled *bool `yaml:"enabled"`
+            DryRun  *bool `yaml:"dryRun"`
+        } `yaml:"reconcile"`
+    }
+    if err := yaml.Unmarshal(b, &flags); err == nil {
+        if flags.Reconcile.Enabled != nil { cfg.Reconcile.Enabled = *flags.Reconcile.Enabled }
+        if flags.Reconcile.DryRun != nil { cfg.Reconcile.DryRun = *flags.Reconcile.DryRun }
+    }
+    if fileCfg.Templates.Dir != "" { cfg.Templates.Dir = fileCfg.Templates.Dir }
+    if fileCfg.Health.IntervalSec != 0 { cfg.Health.IntervalSec = fileCfg.Health.IntervalSec }
+    if fileCfg.Health.StallAfterSec != 0 { cfg.Health.StallAfterSec = fileCfg.Health.StallAfterSec }
+    if fileCfg.Health.ErrorWindowSec != 0 { cfg.Health.ErrorWindowSec = fileCfg.Health.ErrorWindowSec }
+    if fileCfg.Throughput.IntervalSec != 0 { cfg.Throughput.IntervalSec = fileCfg.Throughput.IntervalSec }
+    applyEnv(&cfg)
     return cfg
 }
\ No newline at end of file
@@ -0,0 +1,54 @@
+package config
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestLoadReconcile(t *testing.T) {
+    cases := []struct {
+        name string
+        yaml string
+        want ReconcileConfig
+    }{
+        {"no file", "", ReconcileConfig{Enabled: true, IntervalSec: 60, DryRun: true}},
+        {"no reconcile block", "server:\n  port: 9000\n", ReconcileConfig{Enabled: true, IntervalSec: 60, DryRun: true}},
+        {"interval only", "reconcile:\n  intervalSec: 30\n", ReconcileConfig{Enabled: true, IntervalSec: 30, DryRun: true}},
+        {"disabled", "reconcile:\n  enabled: false\n", ReconcileConfig{Enabled: false, IntervalSec: 60, DryRun: true}},
+        {"apply actions", "reconcile:\n  dryRun: false\n", ReconcileConfig{Enabled: true, IntervalSec: 60, DryRun: false}},
+        {"all fields", "reconcile:\n  enabled: true\n  intervalSec: 120\n  dryRun: false\n", ReconcileConfig{Enabled: true, IntervalSec: 120, DryRun: false}},
+    }
+    for _, tc := range cases {
+        path := filepath.Join(t.TempDir(), "config.yaml")
+        if tc.yaml != "" {
+            if err := os.WriteFile(path, []byte(tc.yaml), 0o644); err != nil { t.Fatal(err) }
+        }
+        t.Setenv("CONFIG_PATH", path)
+        if got := Load().Reconcile; got != tc.want {
+            t.Errorf("%s: reconcile = %+v, want %+v", tc.name, got, tc.want)
+        }
+    }
+}
+
+func TestLoadStarRocksPool(t *testing.T) {
+    cases := []struct {
+        name string
+        yaml string
+        want StarRocksPoolConfig
+    }{
+        {"defaults", "", StarRocksPoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetimeSec: 300}},
+        {"host without pool", "starrocks:\n  feHost: sr-fe\n", StarRocksPoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetimeSec: 300}},
+        {"partial pool", "starrocks:\n  feHost: sr-fe\n  pool:\n    maxOpenConns: 20\n", StarRocksPoolConfig{MaxOpenConns: 20, MaxIdleConns: 5, ConnMaxLifetimeSec: 300}},
+    }
+    for _, tc := range cases {
+        path := filepath.Join(t.TempDir(), "config.yaml")
+        if tc.yaml != "" {
+            if err := os.WriteFile(path, []byte(tc.yaml), 0o644); err != nil { t.Fatal(err) }
+        }
+        t.Setenv("CONFIG_PATH", path)
+        if got := Load().StarRocks.Pool; got != tc.want {
+            t.Errorf("%s: pool = %+v, want %+v", tc.name, got, tc.want)
+        }
+    }
+}
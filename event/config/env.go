@@ -1,3 +1,9 @@
 package config
 
-// 预留：如需将 env 变量覆盖 YAML，可在此扩展
\ No newline at end of file
+import "os"
+
+// applyEnv 用环境变量覆盖 YAML 中的敏感配置，避免把密码写进配置文件
+func applyEnv(cfg *Config) {
+    if v := os.Getenv("KAFKA_SASL_USERNAME"); v != "" { cfg.Kafka.SASL.Username = v }
+    if v := os.Getenv("KAFKA_SASL_PASSWORD"); v != "" { cfg.Kafka.SASL.Password = v }
+}
@@ -1,6 +1,6 @@
 module event
 
-go 1.23
+go 1.23.0
 
 toolchain go1.23.2
 
@@ -16,5 +16,9 @@ require (
 	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/klauspost/compress v1.15.9 // indirect
 	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
+	github.com/xdg-go/scram v1.1.2 // indirect
+	github.com/xdg-go/stringprep v1.0.4 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
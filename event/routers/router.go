@@ -6,12 +6,13 @@ import (
     "event/api/handlers"
     "event/config"
     "event/logs"
+    "event/services"
     "github.com/go-chi/chi/v5"
     "github.com/go-chi/chi/v5/middleware"
     "go.uber.org/zap"
 )
 
-func NewRouter(cfg config.Config, logger *zap.Logger) *chi.Mux {
+func NewRouter(cfg config.Config, logger *zap.Logger, store *services.Store, srClient *services.StarRocksClient, rec *services.Reconciler, monitor *services.HealthMonitor, sched *services.Scheduler, sampler *services.ThroughputSampler) *chi.Mux {
     r := chi.NewRouter()
     r.Use(middleware.RequestID)
     r.Use(middleware.RealIP)
@@ -20,16 +21,61 @@ func NewRouter(cfg config.Config, logger *zap.Logger) *chi.Mux {
 
     // /api 路由组
     health := handlers.NewHealthHandler(cfg, logger)
-    pipelines := handlers.NewPipelinesHandler(cfg, logger)
-    kafka := handlers.NewKafkaHandler(cfg, logger)
-    sr := handlers.NewStarRocksHandler(cfg, logger)
-    summary := handlers.NewSummaryHandler(cfg, logger)
+    pipelines := handlers.NewPipelinesHandler(cfg, logger, store, srClient, rec, monitor)
+    kafka := handlers.NewKafkaHandler(cfg, logger, store, srClient, sampler)
+    sr := handlers.NewStarRocksHandler(cfg, logger, store, srClient)
+    summary := handlers.NewSummaryHandler(cfg, logger, store, srClient, monitor, sched, sampler)
+    templates := handlers.NewTemplatesHandler(cfg, logger)
+    lineage := handlers.NewLineageHandler(cfg, logger, store, srClient)
+    schedules := handlers.NewSchedulesHandler(cfg, logger, sched)
 
     r.Route("/api", func(api chi.Router) {
         api.Get("/health", health.GetHealth)
         api.Get("/summary", summary.Get)
         api.Get("/pipelines", pipelines.List)
+        api.Post("/pipelines", pipelines.Create)
+        api.Post("/pipelines/apply", pipelines.Apply)
+        api.Get("/pipelines/export", pipelines.Export)
+        api.Post("/pipelines/import", pipelines.Import)
+        api.Get("/pipelines/health", pipelines.GetHealth)
+        api.Get("/pipelines/reconcile", pipelines.GetReconcileReport)
+        api.Post("/pipelines/reconcile", pipelines.Reconcile)
+        api.Get("/pipelines/{name}", pipelines.Get)
+        api.Put("/pipelines/{name}", pipelines.Update)
+        api.Delete("/pipelines/{name}", pipelines.Delete)
+        api.Get("/pipelines/{name}/versions", pipelines.ListVersions)
+        api.Get("/pipelines/{name}/versions/diff", pipelines.DiffVersions)
+        api.Post("/pipelines/{name}/versions/{version}/rollback", pipelines.Rollback)
+        api.Get("/pipelines/{name}/lineage", lineage.GetPipeline)
+        api.Get("/pipelines/{name}/schedules", schedules.List)
+        api.Post("/pipelines/{name}/schedules", schedules.Create)
+        api.Put("/pipelines/{name}/schedules/{id}", schedules.Update)
+        api.Delete("/pipelines/{name}/schedules/{id}", schedules.Delete)
+        api.Get("/schedules", schedules.ListAll)
+        api.Get("/schedules/runs", schedules.Runs)
+        api.Get("/lineage", lineage.Get)
+        api.Get("/templates", templates.List)
+        api.Get("/templates/{name}", templates.Get)
+        api.Post("/templates/{name}/expand", templates.Expand)
+        api.Get("/kafka/cluster", kafka.GetCluster)
         api.Get("/kafka/topics", kafka.ListTopics)
+        api.Get("/kafka/throughput", kafka.ListThroughput)
+        api.Post("/kafka/topics", kafka.CreateTopic)
+        api.Get("/kafka/topics/{name}", kafka.GetTopic)
+        api.Delete("/kafka/topics/{name}", kafka.DeleteTopic)
+        api.Get("/kafka/topics/{name}/messages", kafka.ListMessages)
+        api.Post("/kafka/topics/{name}/messages", kafka.ProduceMessages)
+        api.Get("/kafka/topics/{name}/schema", kafka.InferSchema)
+        api.Get("/kafka/topics/{name}/config", kafka.GetTopicConfig)
+        api.Get("/kafka/topics/{name}/throughput", kafka.TopicThroughput)
+        api.Put("/kafka/topics/{name}/config", kafka.UpdateTopicConfig)
+        api.Post("/kafka/topics/{name}/partitions", kafka.AddPartitions)
+        api.Get("/kafka/topics/{name}/jobs", kafka.TopicJobs)
+        api.Post("/kafka/topics/{name}/jobs/restart", kafka.RestartTopicJobs)
+        api.Get("/kafka/groups", kafka.ListGroups)
+        api.Get("/kafka/groups/{id}/lag", kafka.GroupLag)
+        api.Post("/kafka/groups/{id}/offsets", kafka.ResetGroupOffsets)
+        api.Get("/starrocks/pool", sr.PoolStats)
         api.Get("/starrocks/jobs", sr.ListJobs)
         api.Get("/starrocks/jobs/{name}", sr.GetJob)
         api.Post("/starrocks/jobs", sr.CreateJob)
@@ -37,6 +83,9 @@ func NewRouter(cfg config.Config, logger *zap.Logger) *chi.Mux {
         api.Post("/starrocks/jobs/{name}/resume", sr.ResumeJob)
         api.Post("/starrocks/jobs/{name}/stop", sr.StopJob)
         api.Put("/starrocks/jobs/{name}", sr.UpdateJobProperties)
+        api.Post("/starrocks/jobs/{name}/offsets", sr.SeekJob)
+        api.Get("/starrocks/jobs/{name}/tasks", sr.ListTasks)
+        api.Get("/starrocks/jobs/{name}/errors", sr.JobErrors)
     })
 
     // 静态资源（默认挂载到仓库 ui/）
@@ -1,3 +1,94 @@
 package services
 
-// 预留：数据库服务（如 SQLite，存储管道定义与状态）
\ No newline at end of file
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sync"
+
+    "event/api/models"
+    "event/config"
+)
+
+// storeData 是落盘的全部状态，新增集合时在此追加字段即可
+type storeData struct {
+    Pipelines          map[string]models.Pipeline          `json:"pipelines"`
+    Versions           map[string][]models.PipelineVersion `json:"versions"`
+    Schedules          map[string]models.PipelineSchedule  `json:"schedules"`
+    ScheduleRuns       []models.ScheduleRun                `json:"schedule_runs"`
+    SchedulePauses     map[string]string                   `json:"schedule_pauses"` // 管道 → 暂停其作业的计划 ID
+    TopicConfigChanges []models.TopicConfigChange          `json:"topic_config_changes"`
+}
+
+// Store 是基于单个 JSON 文件的嵌入式存储，写入时整体落盘（先写临时文件再 rename）
+type Store struct {
+    mu   sync.RWMutex
+    path string
+    data storeData
+}
+
+// NewStore 打开（或初始化）cfg.Storage.DataDir 下的 store.json
+func NewStore(cfg config.Config) (*Store, error) {
+    dir := cfg.Storage.DataDir
+    if dir == "" { dir = "data" }
+    if err := os.MkdirAll(dir, 0o755); err != nil { return nil, err }
+    s := &Store{path: filepath.Join(dir, "store.json")}
+    b, err := os.ReadFile(s.path)
+    if err != nil && !errors.Is(err, os.ErrNotExist) { return nil, err }
+    if len(b) > 0 {
+        if err := json.Unmarshal(b, &s.data); err != nil {
+            return nil, fmt.Errorf("load store %s: %w", s.path, err)
+        }
+    }
+    s.data.init()
+    return s, nil
+}
+
+func (d *storeData) init() {
+    if d.Pipelines == nil { d.Pipelines = map[string]models.Pipeline{} }
+    if d.Versions == nil { d.Versions = map[string][]models.PipelineVersion{} }
+    if d.Schedules == nil { d.Schedules = map[string]models.PipelineSchedule{} }
+    if d.SchedulePauses == nil { d.SchedulePauses = map[string]string{} }
+}
+
+// View 在读锁内访问数据，fn 不得修改 d
+func (s *Store) View(fn func(d *storeData) error) error {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    return fn(&s.data)
+}
+
+// Update 在写锁内修改数据，fn 返回 nil 时落盘；落盘失败会回滚内存中的修改
+func (s *Store) Update(fn func(d *storeData) error) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    snapshot, err := json.Marshal(s.data)
+    if err != nil { return err }
+    if err := fn(&s.data); err != nil {
+        s.restore(snapshot)
+        return err
+    }
+    if err := s.flush(); err != nil {
+        s.restore(snapshot)
+        return err
+    }
+    return nil
+}
+
+func (s *Store) restore(snapshot []byte) {
+    var d storeData
+    if err := json.Unmarshal(snapshot, &d); err == nil {
+        d.init()
+        s.data = d
+    }
+}
+
+func (s *Store) flush() error {
+    b, err := json.MarshalIndent(s.data, "", "  ")
+    if err != nil { return err }
+    tmp := s.path + ".tmp"
+    if err := os.WriteFile(tmp, b, 0o644); err != nil { return err }
+    return os.Rename(tmp, s.path)
+}
\ No newline at end of file
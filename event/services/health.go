@@ -0,0 +1,276 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sync"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "go.uber.org/zap"
+)
+
+// 健康等级，按严重程度递增
+const (
+    HealthHealthy  = "healthy"
+    HealthDegraded = "degraded"
+    HealthStalled  = "stalled"
+    HealthFailed   = "failed"
+)
+
+var healthRank = map[string]int{HealthHealthy: 0, HealthDegraded: 1, HealthStalled: 2, HealthFailed: 3}
+
+// PipelineHealth 为单条管道的健康评估结果；LagMessages 为空表示积压未知，
+// FreshnessMs 为空表示新鲜度未知，目标表没有数据时 NoData 为 true
+type PipelineHealth struct {
+    Pipeline    string   `json:"pipeline"`
+    Job         string   `json:"job"`
+    Table       string   `json:"table"`
+    Team        string   `json:"team,omitempty"`
+    State       string   `json:"state"`
+    Health      string   `json:"health"`
+    Reasons     []string `json:"reasons,omitempty"`
+    ErrorGrowth int      `json:"error_growth"`
+    IdleSec     int      `json:"idle_sec"`
+    FreshnessMs *int     `json:"freshness_ms,omitempty"`
+    NoData      bool     `json:"no_data,omitempty"`
+    LagMessages *int64   `json:"lag_messages,omitempty"`
+}
+
+func (h *PipelineHealth) mark(level, reason string) {
+    if healthRank[level] > healthRank[h.Health] { h.Health = level }
+    h.Reasons = append(h.Reasons, reason)
+}
+
+// jobSample 为某一时刻的作业累计计数
+type jobSample struct {
+    at        time.Time
+    processed int
+    errors    int
+}
+
+// jobHistory 保存作业在评估窗口内的采样，以及处理行最近一次增长的时间
+type jobHistory struct {
+    samples      []jobSample
+    lastProgress time.Time
+}
+
+// tableFreshness 为一次查询到的目标表最新 event_time；latest 为 nil 表示表中没有数据
+type tableFreshness struct {
+    at     time.Time
+    latest *time.Time
+    err    error
+}
+
+// HealthMonitor 周期性采样 Routine Load 的处理行与错误行，结合目标表新鲜度与 SLA 计算管道健康度；
+// 采样仅保存在内存中，重启后需重新积累一个停滞窗口才会判定 stalled
+type HealthMonitor struct {
+    cfg       config.Config
+    logger    *zap.Logger
+    pipelines *PipelineService
+    sr        *StarRocksClient
+    ka        *KafkaAdmin
+    scheduler *Scheduler
+
+    mu    sync.Mutex
+    jobs  map[string]*jobHistory
+    fresh map[string]tableFreshness // 目标表 → 最新 event_time，每个采样周期最多查询一次
+}
+
+func NewHealthMonitor(cfg config.Config, logger *zap.Logger, store *Store, sr *StarRocksClient, scheduler *Scheduler) *HealthMonitor {
+    return &HealthMonitor{
+        cfg:       cfg,
+        logger:    logger,
+        pipelines: NewPipelineService(cfg, store, sr),
+        sr:        sr,
+        ka:        NewKafkaAdmin(cfg),
+        scheduler: scheduler,
+        jobs:      map[string]*jobHistory{},
+        fresh:     map[string]tableFreshness{},
+    }
+}
+
+// interval 返回采样间隔，最短 5 秒
+func (m *HealthMonitor) interval() time.Duration {
+    return max(time.Duration(m.cfg.Health.IntervalSec)*time.Second, 5*time.Second)
+}
+
+// Run 按配置的间隔采样作业计数，直到 ctx 结束
+func (m *HealthMonitor) Run(ctx context.Context) {
+    interval := m.interval()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            jobs, err := m.sr.ListRoutineLoad(ctx)
+            if err != nil {
+                m.logger.Sugar().Warnw("health.sample.failed", "err", err)
+                continue
+            }
+            m.Observe(jobs)
+        }
+    }
+}
+
+// Observe 记录一次作业采样；作业被重建导致计数回退时重新开始计时
+func (m *HealthMonitor) Observe(jobs []RLJob) {
+    now := time.Now()
+    keep := time.Duration(max(m.cfg.Health.StallAfterSec, m.cfg.Health.ErrorWindowSec)) * time.Second
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    seen := make(map[string]bool, len(jobs))
+    for _, j := range jobs {
+        seen[j.Name] = true
+        s := jobSample{at: now, processed: j.Processed, errors: j.Errors}
+        h, ok := m.jobs[j.Name]
+        if !ok {
+            m.jobs[j.Name] = &jobHistory{samples: []jobSample{s}, lastProgress: now}
+            continue
+        }
+        last := h.samples[len(h.samples)-1]
+        if s.processed < last.processed || s.errors < last.errors {
+            m.jobs[j.Name] = &jobHistory{samples: []jobSample{s}, lastProgress: now}
+            continue
+        }
+        if s.processed > last.processed { h.lastProgress = now }
+        h.samples = append(h.samples, s)
+        // 保留窗口内的采样，另留一条窗口外的作为基线
+        cut := 0
+        for cut < len(h.samples)-1 && now.Sub(h.samples[cut+1].at) >= keep { cut++ }
+        h.samples = h.samples[cut:]
+    }
+    for name := range m.jobs {
+        if !seen[name] { delete(m.jobs, name) }
+    }
+}
+
+// latestEventTime 返回目标表的最新 event_time，结果在一个采样周期内复用，
+// 避免每次健康检查都对每张表执行 MAX(event_time)
+func (m *HealthMonitor) latestEventTime(ctx context.Context, table string) (*time.Time, error) {
+    m.mu.Lock()
+    f, ok := m.fresh[table]
+    m.mu.Unlock()
+    if ok && time.Since(f.at) < m.interval() { return f.latest, f.err }
+    f = tableFreshness{at: time.Now()}
+    f.latest, f.err = m.sr.LatestEventTime(ctx, table)
+    m.mu.Lock()
+    m.fresh[table] = f
+    m.mu.Unlock()
+    return f.latest, f.err
+}
+
+// errorGrowth 返回错误行在窗口内的增量，以及处理行停止增长的秒数
+func (m *HealthMonitor) errorGrowth(job string, window time.Duration) (int, int) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    h, ok := m.jobs[job]
+    if !ok || len(h.samples) == 0 { return 0, 0 }
+    latest := h.samples[len(h.samples)-1]
+    base := h.samples[0]
+    for _, s := range h.samples {
+        if latest.at.Sub(s.at) <= window { break }
+        base = s
+    }
+    return latest.errors - base.errors, int(time.Since(h.lastProgress).Seconds())
+}
+
+// Evaluate 用最新的作业列表计算全部管道的健康度（会同时记录一次采样，并为 jobs 补齐积压）；
+// Kafka 不可达时积压未知，仍按其余信号评估
+func (m *HealthMonitor) Evaluate(ctx context.Context, jobs []RLJob) ([]PipelineHealth, error) {
+    m.Observe(jobs)
+    if err := m.ka.FillRoutineLoadLag(ctx, jobs); err != nil {
+        m.logger.Sugar().Warnw("health.lag.failed", "err", err)
+    }
+    pipes, err := m.pipelines.ListWithStates(JobStates(jobs))
+    if err != nil { return nil, err }
+    byName := make(map[string]RLJob, len(jobs))
+    for _, j := range jobs { byName[j.Name] = j }
+    out := make([]PipelineHealth, 0, len(pipes))
+    for _, p := range pipes {
+        job := byName[p.JobName]
+        out = append(out, m.evaluateOne(ctx, p, job, job.Lag))
+    }
+    return out, nil
+}
+
+func (m *HealthMonitor) evaluateOne(ctx context.Context, p models.Pipeline, job RLJob, lag *int64) PipelineHealth {
+    h := PipelineHealth{
+        Pipeline: p.Name, Job: p.JobName, Table: p.TargetTable, Team: p.Team,
+        State: p.Status, Health: HealthHealthy, LagMessages: lag,
+    }
+    stall := time.Duration(m.cfg.Health.StallAfterSec) * time.Second
+
+    switch p.Status {
+    case "MISSING", "STOPPED", "CANCELLED":
+        if p.DesiredState == "STOPPED" { return h }
+        h.mark(HealthFailed, fmt.Sprintf("job %s is %s", p.JobName, p.Status))
+        return h
+    case "PAUSED":
+        if p.DesiredState == "PAUSED" { return h }
+        if m.scheduler != nil && m.scheduler.InWindow(p.Name, time.Now()) {
+            h.Reasons = append(h.Reasons, "paused by maintenance window")
+            return h
+        }
+        h.mark(HealthDegraded, "job paused but desired state is "+p.DesiredState)
+        return h
+    case "NEED_SCHEDULE":
+        h.mark(HealthDegraded, "job waiting to be scheduled")
+    case "RUNNING":
+        if p.DesiredState == "PAUSED" { h.mark(HealthDegraded, "job running but desired state is PAUSED") }
+    default:
+        h.mark(HealthDegraded, "job state "+p.Status)
+    }
+
+    growth, idle := m.errorGrowth(p.JobName, time.Duration(m.cfg.Health.ErrorWindowSec)*time.Second)
+    h.ErrorGrowth, h.IdleSec = growth, idle
+    if growth > 0 {
+        h.mark(HealthDegraded, fmt.Sprintf("%d error rows in last %dm (total %d)", growth, m.cfg.Health.ErrorWindowSec/60, job.Errors))
+    }
+
+    latest, err := m.latestEventTime(ctx, p.TargetTable)
+    switch {
+    case err != nil:
+        m.logger.Sugar().Warnw("health.freshness.failed", "table", p.TargetTable, "err", err)
+    case latest == nil:
+        h.NoData = true
+        if p.SLA.FreshnessSec > 0 {
+            h.mark(HealthDegraded, fmt.Sprintf("table %s has no data", p.TargetTable))
+        } else {
+            h.Reasons = append(h.Reasons, fmt.Sprintf("table %s has no data", p.TargetTable))
+        }
+    default:
+        fresh := max(int(time.Since(*latest).Milliseconds()), 0)
+        h.FreshnessMs = &fresh
+    }
+    if sla := p.SLA.FreshnessSec; sla > 0 && h.FreshnessMs != nil && *h.FreshnessMs > sla*1000 {
+        h.mark(HealthDegraded, fmt.Sprintf("table %s freshness %ds exceeds SLA %ds", p.TargetTable, *h.FreshnessMs/1000, sla))
+    }
+    if lag != nil && p.SLA.MaxLagMessages > 0 && *lag > p.SLA.MaxLagMessages {
+        h.mark(HealthDegraded, fmt.Sprintf("lag %d messages exceeds SLA %d", *lag, p.SLA.MaxLagMessages))
+    }
+
+    // 运行中但长时间无新增处理行：有积压或表数据已陈旧时判定停滞，积压为 0 则只是主题空闲
+    if p.Status == "RUNNING" && stall > 0 && time.Duration(idle)*time.Second >= stall {
+        switch {
+        case lag != nil && *lag == 0:
+        case lag != nil:
+            h.mark(HealthStalled, fmt.Sprintf("no rows loaded for %dm with %d messages pending", idle/60, *lag))
+        case h.FreshnessMs != nil && time.Duration(*h.FreshnessMs)*time.Millisecond >= stall:
+            h.mark(HealthStalled, fmt.Sprintf("no rows loaded for %dm and table %s is %dm stale", idle/60, p.TargetTable, *h.FreshnessMs/60000))
+        default:
+            h.mark(HealthDegraded, fmt.Sprintf("no rows loaded for %dm", idle/60))
+        }
+    }
+    return h
+}
+
+// Check 拉取作业列表并评估全部管道
+func (m *HealthMonitor) Check(ctx context.Context) ([]PipelineHealth, error) {
+    jobs, err := m.sr.ListRoutineLoad(ctx)
+    if err != nil { return nil, fmt.Errorf("list routine load: %w", err) }
+    return m.Evaluate(ctx, jobs)
+}
\ No newline at end of file
@@ -0,0 +1,91 @@
+package services
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "go.uber.org/zap"
+)
+
+// newTestHealthMonitor 返回不访问 StarRocks 的 HealthMonitor：目标表新鲜度预先写入缓存
+func newTestHealthMonitor(t *testing.T, fresh map[string]*time.Time) *HealthMonitor {
+    t.Helper()
+    cfg := config.Config{
+        Storage: config.StorageConfig{DataDir: t.TempDir()},
+        Health:  config.HealthConfig{IntervalSec: 60, StallAfterSec: 600, ErrorWindowSec: 900},
+    }
+    store, err := NewStore(cfg)
+    if err != nil { t.Fatal(err) }
+    m := NewHealthMonitor(cfg, zap.NewNop(), store, nil, nil)
+    for table, latest := range fresh {
+        m.fresh[table] = tableFreshness{at: time.Now(), latest: latest}
+    }
+    return m
+}
+
+func TestObserveErrorGrowth(t *testing.T) {
+    m := newTestHealthMonitor(t, nil)
+    m.Observe([]RLJob{{Name: "a_rl", Processed: 100, Errors: 1}, {Name: "b_rl", Processed: 10}})
+    m.Observe([]RLJob{{Name: "a_rl", Processed: 100, Errors: 6}})
+    if growth, _ := m.errorGrowth("a_rl", time.Hour); growth != 5 {
+        t.Errorf("growth = %d, want 5", growth)
+    }
+    if _, ok := m.jobs["b_rl"]; ok {
+        t.Error("job gone from the list should be forgotten")
+    }
+    // 作业重建后计数回退，重新开始计时
+    m.jobs["a_rl"].lastProgress = time.Now().Add(-time.Hour)
+    m.Observe([]RLJob{{Name: "a_rl", Processed: 3, Errors: 0}})
+    if growth, idle := m.errorGrowth("a_rl", time.Hour); growth != 0 || idle != 0 {
+        t.Errorf("after reset: growth %d idle %d, want 0 0", growth, idle)
+    }
+    if growth, idle := m.errorGrowth("unknown_rl", time.Hour); growth != 0 || idle != 0 {
+        t.Errorf("unknown job: growth %d idle %d", growth, idle)
+    }
+}
+
+func TestEvaluateOne(t *testing.T) {
+    now := time.Now()
+    recent, stale := now.Add(-10*time.Second), now.Add(-30*time.Minute)
+    m := newTestHealthMonitor(t, map[string]*time.Time{"fresh": &recent, "stale": &stale, "empty": nil})
+    lag := func(n int64) *int64 { return &n }
+    pipe := func(status, desired, table string, sla models.PipelineSLA) models.Pipeline {
+        return models.Pipeline{Name: "p", JobName: "p_rl", TargetTable: table, Status: status, DesiredState: desired, SLA: sla}
+    }
+    cases := []struct {
+        name   string
+        p      models.Pipeline
+        idle   time.Duration
+        errors int
+        lag    *int64
+        want   string
+    }{
+        {"running and fresh", pipe("RUNNING", "RUNNING", "fresh", models.PipelineSLA{FreshnessSec: 60}), 0, 0, lag(0), HealthHealthy},
+        {"missing job", pipe("MISSING", "RUNNING", "fresh", models.PipelineSLA{}), 0, 0, nil, HealthFailed},
+        {"stopped on purpose", pipe("STOPPED", "STOPPED", "fresh", models.PipelineSLA{}), 0, 0, nil, HealthHealthy},
+        {"paused on purpose", pipe("PAUSED", "PAUSED", "fresh", models.PipelineSLA{}), 0, 0, nil, HealthHealthy},
+        {"paused unexpectedly", pipe("PAUSED", "RUNNING", "fresh", models.PipelineSLA{}), 0, 0, nil, HealthDegraded},
+        {"error rows", pipe("RUNNING", "RUNNING", "fresh", models.PipelineSLA{}), 0, 4, nil, HealthDegraded},
+        {"freshness sla", pipe("RUNNING", "RUNNING", "stale", models.PipelineSLA{FreshnessSec: 60}), 0, 0, nil, HealthDegraded},
+        {"no data without sla", pipe("RUNNING", "RUNNING", "empty", models.PipelineSLA{}), 0, 0, nil, HealthHealthy},
+        {"no data with sla", pipe("RUNNING", "RUNNING", "empty", models.PipelineSLA{FreshnessSec: 60}), 0, 0, nil, HealthDegraded},
+        {"lag sla", pipe("RUNNING", "RUNNING", "fresh", models.PipelineSLA{MaxLagMessages: 100}), 0, 0, lag(500), HealthDegraded},
+        {"idle topic", pipe("RUNNING", "RUNNING", "stale", models.PipelineSLA{}), 20 * time.Minute, 0, lag(0), HealthHealthy},
+        {"stalled with lag", pipe("RUNNING", "RUNNING", "fresh", models.PipelineSLA{}), 20 * time.Minute, 0, lag(42), HealthStalled},
+        {"stalled and stale", pipe("RUNNING", "RUNNING", "stale", models.PipelineSLA{}), 20 * time.Minute, 0, nil, HealthStalled},
+        {"idle with lag unknown", pipe("RUNNING", "RUNNING", "fresh", models.PipelineSLA{}), 20 * time.Minute, 0, nil, HealthDegraded},
+    }
+    for _, tc := range cases {
+        m.jobs = map[string]*jobHistory{"p_rl": {
+            samples:      []jobSample{{at: now.Add(-time.Minute)}, {at: now, errors: tc.errors}},
+            lastProgress: now.Add(-tc.idle),
+        }}
+        h := m.evaluateOne(context.Background(), tc.p, RLJob{Name: "p_rl", Errors: tc.errors}, tc.lag)
+        if h.Health != tc.want {
+            t.Errorf("%s: health = %s (%q), want %s", tc.name, h.Health, h.Reasons, tc.want)
+        }
+    }
+}
@@ -2,51 +2,384 @@ package services
 
 import (
     "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "net"
+    "regexp"
     "sort"
+    "strconv"
+    "strings"
+    "time"
 
     "event/config"
+    "event/utils"
     "github.com/segmentio/kafka-go"
+    "github.com/segmentio/kafka-go/sasl"
 )
 
+// adminTimeout 为管理类请求（建删主题等）设置的连接超时
+const adminTimeout = 15 * time.Second
+
 type KafkaAdmin struct {
-    addrs []string
+    addrs   []string
+    addrMap map[string]string // advertised 地址 → 实际连接地址，见 config.KafkaConfig.AddressMap
+    client  *kafka.Client     // 消费组、offset 等需要按协调者或 leader 路由的请求
+    sasl    sasl.Mechanism
+    tls     *tls.Config
+    secErr  error // SASL/TLS 配置错误，所有连接均返回该错误
 }
 
 func NewKafkaAdmin(cfg config.Config) *KafkaAdmin {
-    return &KafkaAdmin{addrs: cfg.Kafka.Brokers}
+    ka := &KafkaAdmin{addrs: cfg.Kafka.Brokers, addrMap: cfg.Kafka.AddressMap}
+    ka.sasl, ka.secErr = saslMechanism(cfg.Kafka.SASL)
+    if ka.secErr == nil { ka.tls, ka.secErr = tlsConfig(cfg.Kafka.TLS) }
+    ka.client = &kafka.Client{Addr: kafka.TCP(ka.addrs...), Timeout: adminTimeout, Transport: ka.transport()}
+    return ka
 }
 
+// TopicInfo 为主题概要；UnderReplicated 为 ISR 少于副本数的分区数，Offline 为没有 leader 的分区数
 type TopicInfo struct {
-    Name       string `json:"name"`
-    Partitions int    `json:"partitions"`
+    Name              string `json:"name"`
+    Partitions        int    `json:"partitions"`
+    ReplicationFactor int    `json:"replication_factor"`
+    UnderReplicated   int    `json:"under_replicated"`
+    Offline           int    `json:"offline"`
 }
 
-func (ka *KafkaAdmin) ListTopics(ctx context.Context) ([]TopicInfo, error) {
-    // 依次尝试地址，使用 kafka-go 的单连接读取分区，避免 advertised address 问题
-    var lastErr error
+// PartitionDetail 为单个分区的副本分布与 offset 范围；Leader 为 -1 表示当前没有 leader
+type PartitionDetail struct {
+    Partition       int    `json:"partition"`
+    Leader          int    `json:"leader"`
+    Replicas        []int  `json:"replicas"`
+    ISR             []int  `json:"isr"`
+    OfflineReplicas []int  `json:"offline_replicas,omitempty"`
+    UnderReplicated bool   `json:"under_replicated"`
+    LowWatermark    int64  `json:"low_watermark"`
+    HighWatermark   int64  `json:"high_watermark"`
+    Messages        int64  `json:"messages"`
+    Error           string `json:"error,omitempty"` // 读取 offset 失败的原因
+}
+
+// TopicDetail 为主题的分区明细
+type TopicDetail struct {
+    TopicInfo
+    Messages      int64             `json:"messages"`
+    PartitionList []PartitionDetail `json:"partition_list"`
+}
+
+// TopicSpec 描述待创建主题的分区、副本与配置覆盖
+type TopicSpec struct {
+    Name              string            `json:"name" yaml:"name"`
+    Partitions        int               `json:"partitions" yaml:"partitions"`
+    ReplicationFactor int               `json:"replication_factor" yaml:"replication_factor"`
+    Configs           map[string]string `json:"configs,omitempty" yaml:"configs,omitempty"`
+}
+
+// topicConfigKeyRe 约束配置项名称的字符集（具体是否合法由 broker 判定）
+var topicConfigKeyRe = regexp.MustCompile(`^[a-z][a-z0-9.]*$`)
+
+// topicConfigRules 为常用主题配置的取值校验；未列出的配置项仅校验名称，交由 broker 校验取值
+var topicConfigRules = map[string]func(string) bool{
+    "retention.ms":           intAtLeast(-1),
+    "retention.bytes":        intAtLeast(-1),
+    "max.message.bytes":      intAtLeast(0),
+    "segment.bytes":          intAtLeast(14),
+    "segment.ms":             intAtLeast(1),
+    "min.insync.replicas":    intAtLeast(1),
+    "delete.retention.ms":    intAtLeast(0),
+    "min.compaction.lag.ms":  intAtLeast(0),
+    "cleanup.policy":         oneOf("delete", "compact", "compact,delete", "delete,compact"),
+    "compression.type":       oneOf("uncompressed", "zstd", "lz4", "snappy", "gzip", "producer"),
+    "message.timestamp.type": oneOf("CreateTime", "LogAppendTime"),
+}
+
+func intAtLeast(min int64) func(string) bool {
+    return func(v string) bool {
+        n, err := strconv.ParseInt(v, 10, 64)
+        return err == nil && n >= min
+    }
+}
+
+func oneOf(values ...string) func(string) bool {
+    return func(v string) bool {
+        v = strings.ReplaceAll(v, " ", "")
+        for _, x := range values {
+            if v == x { return true }
+        }
+        return false
+    }
+}
+
+// ValidateTopicConfigs 校验主题配置覆盖，返回 ErrInvalid 包装的错误
+func ValidateTopicConfigs(configs map[string]string) error {
+    for k, v := range configs {
+        if !topicConfigKeyRe.MatchString(k) {
+            return fmt.Errorf("%w: invalid config name %q", utils.ErrInvalid, k)
+        }
+        if rule, ok := topicConfigRules[k]; ok && !rule(strings.TrimSpace(v)) {
+            return fmt.Errorf("%w: invalid value %q for %s", utils.ErrInvalid, v, k)
+        }
+    }
+    return nil
+}
+
+// adminError 将 broker 返回的错误码转换为仓库统一的错误类型
+func adminError(err error) error {
+    var kerr kafka.Error
+    if !errors.As(err, &kerr) { return err }
+    switch kerr {
+    case kafka.UnknownTopicOrPartition:
+        return fmt.Errorf("%w: %v", utils.ErrNotFound, err)
+    case kafka.TopicAlreadyExists:
+        return fmt.Errorf("%w: %v", utils.ErrConflict, err)
+    case kafka.InvalidTopic, kafka.InvalidPartitionNumber, kafka.InvalidReplicationFactor,
+        kafka.InvalidConfiguration, kafka.PolicyViolation, kafka.TopicDeletionDisabled:
+        return fmt.Errorf("%w: %v", utils.ErrInvalid, err)
+    }
+    return err
+}
+
+// dialAddr 按 AddressMap 改写地址后建立 TCP 连接。TLS 的 ServerName 仍取自原始的 advertised 地址，
+// 因此映射后证书校验不受影响；broker 不可达时直接返回错误，不会改投其他 broker
+func (ka *KafkaAdmin) dialAddr(ctx context.Context, network, addr string) (net.Conn, error) {
+    if ka.secErr != nil { return nil, ka.secErr }
+    if mapped, ok := ka.addrMap[addr]; ok { addr = mapped }
+    d := &net.Dialer{Timeout: adminTimeout}
+    return d.DialContext(ctx, network, addr)
+}
+
+// dialer 返回建立 Kafka 连接所用的 Dialer，所有 Kafka 连接统一经由此处
+func (ka *KafkaAdmin) dialer() *kafka.Dialer {
+    return &kafka.Dialer{Timeout: adminTimeout, SASLMechanism: ka.sasl, TLS: ka.tls, DialFunc: ka.dialAddr}
+}
+
+// transport 返回 kafka.Client 使用的 Transport，TLS 与 SASL 由 Transport 在连接建立后处理
+func (ka *KafkaAdmin) transport() *kafka.Transport {
+    return &kafka.Transport{
+        DialTimeout: adminTimeout,
+        SASL:        ka.sasl,
+        TLS:         ka.tls,
+        Dial:        ka.dialAddr,
+    }
+}
+
+// dial 建立到指定 broker 的连接
+func (ka *KafkaAdmin) dial(ctx context.Context, addr string) (*kafka.Conn, error) {
+    if ka.secErr != nil { return nil, ka.secErr }
+    return ka.dialer().DialContext(ctx, "tcp", addr)
+}
+
+// dialPartition 建立到分区 leader 的连接（advertised 地址按 AddressMap 改写）
+func (ka *KafkaAdmin) dialPartition(ctx context.Context, p kafka.Partition) (*kafka.Conn, error) {
+    if ka.secErr != nil { return nil, ka.secErr }
+    conn, err := ka.dialer().DialPartition(ctx, "tcp", "", p)
+    if err != nil {
+        return nil, fmt.Errorf("leader %s: %w", net.JoinHostPort(p.Leader.Host, strconv.Itoa(p.Leader.Port)), err)
+    }
+    _ = conn.SetDeadline(time.Now().Add(adminTimeout))
+    return conn, nil
+}
+
+// controllerConn 返回到 controller 的连接；controller 的 advertised 地址不可达时
+// （例如本地通过 127.0.0.1 访问容器内 kafka:9092），退回使用引导连接
+func (ka *KafkaAdmin) controllerConn(ctx context.Context) (*kafka.Conn, error) {
+    var errs addrErrors
     for _, addr := range ka.addrs {
-        conn, err := kafka.DialContext(ctx, "tcp", addr)
+        conn, err := ka.dial(ctx, addr)
+        if err != nil {
+            errs.add(addr, err)
+            continue
+        }
+        ctrl, err := conn.Controller()
         if err != nil {
-            lastErr = err
+            _ = conn.Close()
+            errs.add(addr, err)
             continue
         }
-        parts, err := conn.ReadPartitions()
+        ctrlAddr := net.JoinHostPort(ctrl.Host, strconv.Itoa(ctrl.Port))
+        if ctrlAddr != addr {
+            if cc, err := ka.dial(ctx, ctrlAddr); err == nil {
+                _ = conn.Close()
+                conn = cc
+            }
+        }
+        _ = conn.SetDeadline(time.Now().Add(adminTimeout))
+        return conn, nil
+    }
+    return nil, errs.err()
+}
+
+// readPartitions 依次尝试地址，使用 kafka-go 的单连接读取分区元数据，避免 advertised address 问题；
+// 不指定主题时返回全部主题的分区
+func (ka *KafkaAdmin) readPartitions(ctx context.Context, topics ...string) ([]kafka.Partition, error) {
+    var errs addrErrors
+    for _, addr := range ka.addrs {
+        conn, err := ka.dial(ctx, addr)
+        if err != nil {
+            errs.add(addr, err)
+            continue
+        }
+        parts, err := conn.ReadPartitions(topics...)
         _ = conn.Close()
         if err != nil {
-            lastErr = err
+            if err == kafka.UnknownTopicOrPartition && len(topics) == 1 {
+                return nil, fmt.Errorf("topic %s: %w", topics[0], utils.ErrNotFound)
+            }
+            errs.add(addr, err)
             continue
         }
-        // 聚合主题与分区数
-        m := map[string]int{}
-        for _, p := range parts {
-            m[p.Topic]++
+        return parts, nil
+    }
+    return nil, errs.err()
+}
+
+// topicPartitions 返回主题的分区（按分区号排序），主题不存在时返回 ErrNotFound。
+// 读取全部分区后过滤，避免按主题名请求元数据时 broker 自动创建不存在的主题
+func (ka *KafkaAdmin) topicPartitions(ctx context.Context, name string) ([]kafka.Partition, error) {
+    all, err := ka.readPartitions(ctx)
+    if err != nil { return nil, err }
+    var parts []kafka.Partition
+    for _, p := range all {
+        if p.Topic == name { parts = append(parts, p) }
+    }
+    if len(parts) == 0 { return nil, fmt.Errorf("topic %s: %w", name, utils.ErrNotFound) }
+    sort.Slice(parts, func(i, j int) bool { return parts[i].ID < parts[j].ID })
+    return parts, nil
+}
+
+// hasLeader 判断分区当前是否有 leader：leader 为 -1 或已下线时元数据中没有对应 broker 的地址
+func hasLeader(p kafka.Partition) bool { return p.Leader.Host != "" }
+
+func brokerIDs(bs []kafka.Broker) []int {
+    out := make([]int, len(bs))
+    for i, b := range bs { out[i] = b.ID }
+    return out
+}
+
+func (ka *KafkaAdmin) ListTopics(ctx context.Context) ([]TopicInfo, error) {
+    parts, err := ka.readPartitions(ctx)
+    if err != nil { return nil, err }
+    // 聚合主题的分区数、副本因子与副本健康
+    m := map[string]*TopicInfo{}
+    for _, p := range parts {
+        t, ok := m[p.Topic]
+        if !ok {
+            t = &TopicInfo{Name: p.Topic}
+            m[p.Topic] = t
+        }
+        t.Partitions++
+        if len(p.Replicas) > t.ReplicationFactor { t.ReplicationFactor = len(p.Replicas) }
+        if len(p.Isr) < len(p.Replicas) { t.UnderReplicated++ }
+        if !hasLeader(p) { t.Offline++ }
+    }
+    topics := make([]TopicInfo, 0, len(m))
+    for _, t := range m { topics = append(topics, *t) }
+    sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+    return topics, nil
+}
+
+// DescribeTopic 返回主题每个分区的 leader、副本、ISR 与高低水位；
+// 单个分区的 offset 读取失败（如没有 leader）记录在该分区的 Error 中，不影响其他分区
+func (ka *KafkaAdmin) DescribeTopic(ctx context.Context, name string) (*TopicDetail, error) {
+    if !utils.ValidTopicName(name) {
+        return nil, fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, name)
+    }
+    parts, err := ka.topicPartitions(ctx, name)
+    if err != nil { return nil, err }
+    d := &TopicDetail{TopicInfo: TopicInfo{Name: name, Partitions: len(parts)}, PartitionList: make([]PartitionDetail, 0, len(parts))}
+    for _, p := range parts {
+        pd := PartitionDetail{
+            Partition:       p.ID,
+            Leader:          p.Leader.ID,
+            Replicas:        brokerIDs(p.Replicas),
+            ISR:             brokerIDs(p.Isr),
+            UnderReplicated: len(p.Isr) < len(p.Replicas),
+        }
+        if len(p.OfflineReplicas) > 0 { pd.OfflineReplicas = brokerIDs(p.OfflineReplicas) }
+        if len(p.Replicas) > d.ReplicationFactor { d.ReplicationFactor = len(p.Replicas) }
+        if pd.UnderReplicated { d.UnderReplicated++ }
+        if !hasLeader(p) {
+            pd.Leader = -1
+            pd.Error = "partition has no leader"
+            d.Offline++
+        } else if conn, err := ka.dialPartition(ctx, p); err != nil {
+            pd.Error = err.Error()
+        } else {
+            pd.LowWatermark, pd.HighWatermark, err = conn.ReadOffsets()
+            _ = conn.Close()
+            if err != nil { pd.Error = err.Error() }
+            pd.Messages = pd.HighWatermark - pd.LowWatermark
+            d.Messages += pd.Messages
         }
-        topics := make([]TopicInfo, 0, len(m))
-        for name, cnt := range m {
-            topics = append(topics, TopicInfo{Name: name, Partitions: cnt})
+        d.PartitionList = append(d.PartitionList, pd)
+    }
+    return d, nil
+}
+
+// ReadTopicSpec 读取已有主题的分区数与副本因子（副本因子取各分区副本数的最大值）
+func (ka *KafkaAdmin) ReadTopicSpec(ctx context.Context, name string) (*TopicSpec, error) {
+    parts, err := ka.readPartitions(ctx, name)
+    if err != nil { return nil, err }
+    spec := &TopicSpec{Name: name, Partitions: len(parts)}
+    for _, p := range parts {
+        if len(p.Replicas) > spec.ReplicationFactor { spec.ReplicationFactor = len(p.Replicas) }
+    }
+    return spec, nil
+}
+
+// TopicExists 判断主题是否已存在
+func (ka *KafkaAdmin) TopicExists(ctx context.Context, name string) (bool, error) {
+    topics, err := ka.ListTopics(ctx)
+    if err != nil { return false, err }
+    for _, t := range topics {
+        if t.Name == name { return true, nil }
+    }
+    return false, nil
+}
+
+// CreateTopic 通过 controller 创建主题；主题已存在时返回 ErrConflict
+func (ka *KafkaAdmin) CreateTopic(ctx context.Context, spec TopicSpec) error {
+    if !utils.ValidTopicName(spec.Name) {
+        return fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, spec.Name)
+    }
+    if spec.Partitions < 1 { spec.Partitions = 1 }
+    if spec.ReplicationFactor < 1 { spec.ReplicationFactor = 1 }
+    if err := ValidateTopicConfigs(spec.Configs); err != nil { return err }
+    exists, err := ka.TopicExists(ctx, spec.Name)
+    if err != nil { return err }
+    if exists { return fmt.Errorf("topic %s: %w", spec.Name, utils.ErrConflict) }
+
+    entries := make([]kafka.ConfigEntry, 0, len(spec.Configs))
+    for k, v := range spec.Configs {
+        entries = append(entries, kafka.ConfigEntry{ConfigName: k, ConfigValue: v})
+    }
+    sort.Slice(entries, func(i, j int) bool { return entries[i].ConfigName < entries[j].ConfigName })
+
+    conn, err := ka.controllerConn(ctx)
+    if err != nil { return err }
+    defer conn.Close()
+    return adminError(conn.CreateTopics(kafka.TopicConfig{
+        Topic:             spec.Name,
+        NumPartitions:     spec.Partitions,
+        ReplicationFactor: spec.ReplicationFactor,
+        ConfigEntries:     entries,
+    }))
+}
+
+// DeleteTopic 通过 controller 删除主题
+func (ka *KafkaAdmin) DeleteTopic(ctx context.Context, name string) error {
+    if !utils.ValidTopicName(name) {
+        return fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, name)
+    }
+    conn, err := ka.controllerConn(ctx)
+    if err != nil { return err }
+    defer conn.Close()
+    if err := conn.DeleteTopics(name); err != nil {
+        if err == kafka.UnknownTopicOrPartition {
+            return fmt.Errorf("topic %s: %w", name, utils.ErrNotFound)
         }
-        sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
-        return topics, nil
+        return adminError(err)
     }
-    return nil, lastErr
+    return nil
 }
\ No newline at end of file
@@ -0,0 +1,134 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "reflect"
+    "testing"
+
+    "event/config"
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+func TestValidateTopicConfigs(t *testing.T) {
+    cases := []struct {
+        name    string
+        configs map[string]string
+        invalid bool
+    }{
+        {"none", nil, false},
+        {"known values", map[string]string{"retention.ms": "-1", "cleanup.policy": "compact, delete", "compression.type": "zstd", "min.insync.replicas": " 2 "}, false},
+        {"unknown key passes through", map[string]string{"message.downconversion.enable": "anything"}, false},
+        {"bad key", map[string]string{"Retention.ms": "1"}, true},
+        {"key with spaces", map[string]string{"retention ms": "1"}, true},
+        {"retention below -1", map[string]string{"retention.ms": "-2"}, true},
+        {"not a number", map[string]string{"max.message.bytes": "1MB"}, true},
+        {"segment too small", map[string]string{"segment.bytes": "13"}, true},
+        {"unknown policy", map[string]string{"cleanup.policy": "archive"}, true},
+        {"timestamp type is case sensitive", map[string]string{"message.timestamp.type": "createtime"}, true},
+    }
+    for _, tc := range cases {
+        err := ValidateTopicConfigs(tc.configs)
+        if tc.invalid != errors.Is(err, utils.ErrInvalid) || (!tc.invalid && err != nil) {
+            t.Errorf("%s: err = %v, invalid = %v", tc.name, err, tc.invalid)
+        }
+    }
+}
+
+func TestAdminError(t *testing.T) {
+    cases := []struct {
+        err  error
+        want error
+    }{
+        {kafka.UnknownTopicOrPartition, utils.ErrNotFound},
+        {fmt.Errorf("create: %w", kafka.TopicAlreadyExists), utils.ErrConflict},
+        {kafka.InvalidReplicationFactor, utils.ErrInvalid},
+        {kafka.TopicDeletionDisabled, utils.ErrInvalid},
+    }
+    for _, tc := range cases {
+        if got := adminError(tc.err); !errors.Is(got, tc.want) {
+            t.Errorf("adminError(%v) = %v, want %v", tc.err, got, tc.want)
+        }
+    }
+    other := errors.New("connection reset")
+    if got := adminError(other); got != other {
+        t.Errorf("non-kafka error = %v, want unchanged", got)
+    }
+    if got := adminError(kafka.NotController); errors.Is(got, utils.ErrInvalid) || errors.Is(got, utils.ErrNotFound) {
+        t.Errorf("NotController = %v, want unmapped", got)
+    }
+}
+
+func TestTopicValidationBeforeDial(t *testing.T) {
+    // broker 不可达：非法参数须在连接之前返回 ErrInvalid
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}})
+    ctx := context.Background()
+    cases := []struct {
+        name string
+        err  error
+    }{
+        {"create bad name", ka.CreateTopic(ctx, TopicSpec{Name: "a b"})},
+        {"create dot", ka.CreateTopic(ctx, TopicSpec{Name: ".."})},
+        {"create bad config", ka.CreateTopic(ctx, TopicSpec{Name: "clicks", Configs: map[string]string{"retention.ms": "soon"}})},
+        {"delete bad name", ka.DeleteTopic(ctx, "clicks/1")},
+    }
+    for _, tc := range cases {
+        if !errors.Is(tc.err, utils.ErrInvalid) {
+            t.Errorf("%s: err = %v, want ErrInvalid", tc.name, tc.err)
+        }
+    }
+}
+
+func TestPartitionHelpers(t *testing.T) {
+    live := kafka.Partition{ID: 0, Leader: kafka.Broker{ID: 2, Host: "kafka-2"}, Replicas: []kafka.Broker{{ID: 2}, {ID: 1}, {ID: 3}}}
+    offline := kafka.Partition{ID: 1, Leader: kafka.Broker{ID: -1}}
+    if !hasLeader(live) || hasLeader(offline) {
+        t.Errorf("hasLeader(live) = %v, hasLeader(offline) = %v", hasLeader(live), hasLeader(offline))
+    }
+    // 保持元数据中的副本顺序，第一个为优先副本
+    if got := brokerIDs(live.Replicas); !reflect.DeepEqual(got, []int{2, 1, 3}) {
+        t.Errorf("brokerIDs = %v, want [2 1 3]", got)
+    }
+    if got := brokerIDs(nil); got == nil || len(got) != 0 {
+        t.Errorf("brokerIDs(nil) = %#v, want empty slice for JSON []", got)
+    }
+}
+
+func TestDescribeTopicErrors(t *testing.T) {
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}})
+    if _, err := ka.DescribeTopic(context.Background(), "a b"); !errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("invalid name: err = %v, want ErrInvalid", err)
+    }
+    // broker 不可达不是主题不存在
+    _, err := ka.DescribeTopic(context.Background(), "clicks")
+    if err == nil || errors.Is(err, utils.ErrNotFound) {
+        t.Errorf("unreachable broker: err = %v, want a connection error", err)
+    }
+}
+
+func TestDialAddrUsesAddressMap(t *testing.T) {
+    ln, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil { t.Fatal(err) }
+    defer ln.Close()
+    go func() {
+        for {
+            c, err := ln.Accept()
+            if err != nil { return }
+            c.Close()
+        }
+    }()
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{
+        Brokers:    []string{"127.0.0.1:1"},
+        AddressMap: map[string]string{"kafka:9092": ln.Addr().String()},
+    }})
+    conn, err := ka.dialAddr(context.Background(), "tcp", "kafka:9092")
+    if err != nil { t.Fatalf("mapped address: %v", err) }
+    conn.Close()
+    // 未映射的地址原样连接，不可达时直接报错
+    if _, err := ka.dialAddr(context.Background(), "tcp", "127.0.0.1:1"); err == nil {
+        t.Error("unmapped unreachable address: want error")
+    }
+}
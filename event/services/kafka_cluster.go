@@ -0,0 +1,122 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+)
+
+// BrokerInfo 为集群元数据中的 broker；Reachable 表示从本服务能否连上其 advertised 地址
+type BrokerInfo struct {
+    ID         int    `json:"id"`
+    Host       string `json:"host"`
+    Port       int    `json:"port"`
+    Rack       string `json:"rack,omitempty"`
+    Controller bool   `json:"controller"`
+    Reachable  bool   `json:"reachable"`
+    LatencyMs  int64  `json:"latency_ms,omitempty"`
+    Error      string `json:"error,omitempty"`
+}
+
+// AddrStatus 为单个配置地址（cfg.Kafka.Brokers）的连通性
+type AddrStatus struct {
+    Address   string `json:"address"`
+    Reachable bool   `json:"reachable"`
+    LatencyMs int64  `json:"latency_ms,omitempty"`
+    Error     string `json:"error,omitempty"`
+}
+
+// ClusterInfo 为 Kafka 集群概览；元数据读取失败时只有 Configured 有效
+type ClusterInfo struct {
+    ClusterID  string       `json:"cluster_id"`
+    Controller int          `json:"controller"`
+    Brokers    []BrokerInfo `json:"brokers"`
+    Configured []AddrStatus `json:"configured"`
+}
+
+// addrErrors 汇总依次尝试多个地址时每个地址的错误，避免只保留最后一个错误而看不出具体哪个 broker 不可达
+type addrErrors []error
+
+func (e *addrErrors) add(addr string, err error) { *e = append(*e, fmt.Errorf("%s: %w", addr, err)) }
+
+func (e addrErrors) Error() string {
+    msgs := make([]string, len(e))
+    for i, err := range e { msgs[i] = err.Error() }
+    return strings.Join(msgs, "; ")
+}
+
+func (e addrErrors) Unwrap() []error { return e }
+
+// err 返回汇总后的错误；没有尝试任何地址时说明未配置 broker
+func (e addrErrors) err() error {
+    if len(e) == 0 { return fmt.Errorf("no kafka brokers configured") }
+    return e
+}
+
+// probe 连接地址并发送 ApiVersions 请求，确认对端是可用的 Kafka broker
+func (ka *KafkaAdmin) probe(ctx context.Context, addr string) (time.Duration, error) {
+    start := time.Now()
+    conn, err := ka.dial(ctx, addr)
+    if err != nil { return 0, err }
+    defer conn.Close()
+    _ = conn.SetDeadline(time.Now().Add(adminTimeout))
+    if _, err := conn.ApiVersions(); err != nil { return 0, err }
+    return time.Since(start), nil
+}
+
+// probeAll 并发探测一组地址
+func (ka *KafkaAdmin) probeAll(ctx context.Context, addrs []string) []AddrStatus {
+    out := make([]AddrStatus, len(addrs))
+    var wg sync.WaitGroup
+    for i, addr := range addrs {
+        wg.Add(1)
+        go func(i int, addr string) {
+            defer wg.Done()
+            st := AddrStatus{Address: addr}
+            if d, err := ka.probe(ctx, addr); err != nil {
+                st.Error = err.Error()
+            } else {
+                st.Reachable, st.LatencyMs = true, max(d.Milliseconds(), 1)
+            }
+            out[i] = st
+        }(i, addr)
+    }
+    wg.Wait()
+    return out
+}
+
+// Cluster 返回集群 ID、controller、各 broker 及其连通性，以及每个配置地址的连通性。
+// 所有配置地址都不可达时返回错误，同时返回填好 Configured 的 ClusterInfo
+func (ka *KafkaAdmin) Cluster(ctx context.Context) (*ClusterInfo, error) {
+    info := &ClusterInfo{Controller: -1, Brokers: []BrokerInfo{}, Configured: ka.probeAll(ctx, ka.addrs)}
+    var errs addrErrors
+    for _, st := range info.Configured {
+        if !st.Reachable { errs.add(st.Address, errors.New(st.Error)) }
+    }
+    if len(errs) == len(info.Configured) { return info, errs.err() }
+
+    md, err := ka.client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{}})
+    if err != nil { return info, err }
+    info.ClusterID = md.ClusterID
+    info.Controller = md.Controller.ID
+    addrs := make([]string, len(md.Brokers))
+    for i, b := range md.Brokers { addrs[i] = net.JoinHostPort(b.Host, strconv.Itoa(b.Port)) }
+    reach := ka.probeAll(ctx, addrs)
+    for i, b := range md.Brokers {
+        info.Brokers = append(info.Brokers, BrokerInfo{
+            ID: b.ID, Host: b.Host, Port: b.Port, Rack: b.Rack,
+            Controller: b.ID == md.Controller.ID,
+            Reachable:  reach[i].Reachable, LatencyMs: reach[i].LatencyMs, Error: reach[i].Error,
+        })
+    }
+    sort.Slice(info.Brokers, func(i, j int) bool { return info.Brokers[i].ID < info.Brokers[j].ID })
+    return info, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "syscall"
+    "testing"
+
+    "event/config"
+)
+
+func TestAddrErrors(t *testing.T) {
+    var errs addrErrors
+    if err := errs.err(); err == nil || err.Error() != "no kafka brokers configured" {
+        t.Errorf("no addresses: err = %v", err)
+    }
+    errs.add("kafka-1:9092", syscall.ECONNREFUSED)
+    errs.add("kafka-2:9092", errors.New("i/o timeout"))
+    err := errs.err()
+    if !strings.Contains(err.Error(), "kafka-1:9092: ") || !strings.Contains(err.Error(), "; kafka-2:9092: i/o timeout") {
+        t.Errorf("message = %q, want every address", err)
+    }
+    if !errors.Is(err, syscall.ECONNREFUSED) {
+        t.Errorf("errors.Is through addrErrors = false, want true")
+    }
+}
+
+func TestClusterUnreachable(t *testing.T) {
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1", "127.0.0.1:2"}}})
+    info, err := ka.Cluster(context.Background())
+    if err == nil || info == nil {
+        t.Fatalf("info = %+v, err %v, want configured statuses and an error", info, err)
+    }
+    if len(info.Configured) != 2 || info.Configured[0].Address != "127.0.0.1:1" || info.Configured[0].Reachable || info.Configured[1].Error == "" {
+        t.Errorf("configured = %+v", info.Configured)
+    }
+    if info.Controller != -1 || info.Brokers == nil || len(info.Brokers) != 0 {
+        t.Errorf("controller %d brokers %#v", info.Controller, info.Brokers)
+    }
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+// GroupMember 为消费组成员及其分配到的分区
+type GroupMember struct {
+    MemberID    string           `json:"member_id"`
+    ClientID    string           `json:"client_id"`
+    ClientHost  string           `json:"client_host"`
+    Assignments map[string][]int `json:"assignments,omitempty"` // 主题 → 分区
+}
+
+// ConsumerGroup 为消费组概要
+type ConsumerGroup struct {
+    ID           string        `json:"id"`
+    State        string        `json:"state"`
+    ProtocolType string        `json:"protocol_type,omitempty"`
+    Coordinator  int           `json:"coordinator"`
+    Members      []GroupMember `json:"members"`
+}
+
+// PartitionLag 为单个分区的已提交 offset 与高水位；Committed 为 -1 表示该分区尚未提交
+type PartitionLag struct {
+    Topic         string `json:"topic"`
+    Partition     int    `json:"partition"`
+    Committed     int64  `json:"committed"`
+    HighWatermark int64  `json:"high_watermark"`
+    Lag           int64  `json:"lag"`
+    MemberID      string `json:"member_id,omitempty"`
+    Error         string `json:"error,omitempty"`
+}
+
+// GroupLag 为消费组在各分区上的积压
+type GroupLag struct {
+    Group      string         `json:"group"`
+    State      string         `json:"state"`
+    TotalLag   int64          `json:"total_lag"`
+    Partitions []PartitionLag `json:"partitions"`
+}
+
+func groupMembers(g kafka.DescribeGroupsResponseGroup) []GroupMember {
+    out := make([]GroupMember, 0, len(g.Members))
+    for _, m := range g.Members {
+        gm := GroupMember{MemberID: m.MemberID, ClientID: m.ClientID, ClientHost: m.ClientHost}
+        for _, t := range m.MemberAssignments.Topics {
+            if gm.Assignments == nil { gm.Assignments = map[string][]int{} }
+            parts := append([]int(nil), t.Partitions...)
+            sort.Ints(parts)
+            gm.Assignments[t.Topic] = parts
+        }
+        out = append(out, gm)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].MemberID < out[j].MemberID })
+    return out
+}
+
+// ListGroups 返回集群中的全部消费组及其成员
+func (ka *KafkaAdmin) ListGroups(ctx context.Context) ([]ConsumerGroup, error) {
+    lr, err := ka.client.ListGroups(ctx, &kafka.ListGroupsRequest{})
+    if err != nil { return nil, err }
+    if lr.Error != nil { return nil, lr.Error }
+    out := make([]ConsumerGroup, 0, len(lr.Groups))
+    if len(lr.Groups) == 0 { return out, nil }
+    ids := make([]string, 0, len(lr.Groups))
+    byID := make(map[string]int, len(lr.Groups))
+    for _, g := range lr.Groups {
+        byID[g.GroupID] = len(out)
+        ids = append(ids, g.GroupID)
+        out = append(out, ConsumerGroup{ID: g.GroupID, ProtocolType: g.ProtocolType, Coordinator: g.Coordinator, Members: []GroupMember{}})
+    }
+    dr, err := ka.client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: ids})
+    if err != nil { return nil, err }
+    for _, g := range dr.Groups {
+        i, ok := byID[g.GroupID]
+        if !ok || g.Error != nil { continue }
+        out[i].State = g.GroupState
+        out[i].Members = groupMembers(g)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+    return out, nil
+}
+
+// describeGroup 返回单个消费组的状态与成员；组不存在时返回 ErrNotFound
+func (ka *KafkaAdmin) describeGroup(ctx context.Context, id string) (*kafka.DescribeGroupsResponseGroup, error) {
+    dr, err := ka.client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{id}})
+    if err != nil { return nil, err }
+    for _, g := range dr.Groups {
+        if g.GroupID != id { continue }
+        // 不存在的组在多数 broker 版本上返回 Dead 状态而非错误码
+        if errors.Is(g.Error, kafka.GroupIdNotFound) || g.GroupState == "Dead" { break }
+        if g.Error != nil { return nil, g.Error }
+        return &g, nil
+    }
+    return nil, fmt.Errorf("consumer group %s: %w", id, utils.ErrNotFound)
+}
+
+// HighWatermarks 返回各主题分区的高水位（主题 → 分区 → offset）；读取失败的分区不出现在结果中
+func (ka *KafkaAdmin) HighWatermarks(ctx context.Context, topics map[string][]int) (map[string]map[int]int64, error) {
+    return ka.watermarks(ctx, topics, false)
+}
+
+// LowWatermarks 返回各主题分区的低水位（最早仍保留的 offset），数据被 retention 清理后大于 0
+func (ka *KafkaAdmin) LowWatermarks(ctx context.Context, topics map[string][]int) (map[string]map[int]int64, error) {
+    return ka.watermarks(ctx, topics, true)
+}
+
+// watermarks 按 first 读取低水位或高水位；两者分开请求，同一请求中混合 FirstOffset/LastOffset 不可靠
+func (ka *KafkaAdmin) watermarks(ctx context.Context, topics map[string][]int, first bool) (map[string]map[int]int64, error) {
+    req := &kafka.ListOffsetsRequest{Topics: make(map[string][]kafka.OffsetRequest, len(topics))}
+    for t, parts := range topics {
+        for _, p := range parts {
+            if first {
+                req.Topics[t] = append(req.Topics[t], kafka.FirstOffsetOf(p))
+            } else {
+                req.Topics[t] = append(req.Topics[t], kafka.LastOffsetOf(p))
+            }
+        }
+    }
+    out := make(map[string]map[int]int64, len(topics))
+    if len(req.Topics) == 0 { return out, nil }
+    resp, err := ka.client.ListOffsets(ctx, req)
+    if err != nil { return nil, err }
+    for t, parts := range resp.Topics {
+        for _, p := range parts {
+            if p.Error != nil { continue }
+            if out[t] == nil { out[t] = map[int]int64{} }
+            if first {
+                out[t][p.Partition] = p.FirstOffset
+            } else {
+                out[t][p.Partition] = p.LastOffset
+            }
+        }
+    }
+    return out, nil
+}
+
+// GroupLag 返回消费组在每个已提交或已分配分区上的 已提交 offset 与高水位之差，以及总积压。
+// 未提交过 offset 的分区（Committed 为 -1）积压按高水位减低水位计
+func (ka *KafkaAdmin) GroupLag(ctx context.Context, id string) (*GroupLag, error) {
+    out := &GroupLag{Group: id, Partitions: []PartitionLag{}}
+    owner := map[string]map[int]string{} // 主题 → 分区 → 成员
+    if g, err := ka.describeGroup(ctx, id); err == nil {
+        out.State = g.GroupState
+        for _, m := range groupMembers(*g) {
+            for t, parts := range m.Assignments {
+                if owner[t] == nil { owner[t] = map[int]string{} }
+                for _, p := range parts { owner[t][p] = m.MemberID }
+            }
+        }
+    } else if !errors.Is(err, utils.ErrNotFound) {
+        return nil, err
+    }
+
+    fr, err := ka.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: id})
+    if err != nil { return nil, err }
+    if fr.Error != nil { return nil, fr.Error }
+    committed := map[string]map[int]int64{}
+    for t, parts := range fr.Topics {
+        for _, p := range parts {
+            if p.Error != nil || p.CommittedOffset < 0 { continue }
+            if committed[t] == nil { committed[t] = map[int]int64{} }
+            committed[t][p.Partition] = p.CommittedOffset
+        }
+    }
+    if len(committed) == 0 && len(owner) == 0 && out.State == "" {
+        return nil, fmt.Errorf("consumer group %s: %w", id, utils.ErrNotFound)
+    }
+
+    topics := map[string][]int{}
+    seen := map[string]map[int]bool{}
+    add := func(t string, p int) {
+        if seen[t] == nil { seen[t] = map[int]bool{} }
+        if seen[t][p] { return }
+        seen[t][p] = true
+        topics[t] = append(topics[t], p)
+    }
+    for t, parts := range committed {
+        for p := range parts { add(t, p) }
+    }
+    for t, parts := range owner {
+        for p := range parts { add(t, p) }
+    }
+    hw, err := ka.HighWatermarks(ctx, topics)
+    if err != nil { return nil, err }
+    uncommitted := map[string][]int{}
+    for t, parts := range topics {
+        for _, p := range parts {
+            if _, ok := committed[t][p]; !ok { uncommitted[t] = append(uncommitted[t], p) }
+        }
+    }
+    lw, err := ka.LowWatermarks(ctx, uncommitted)
+    if err != nil { return nil, err }
+
+    for t, parts := range topics {
+        for _, p := range parts {
+            pl := PartitionLag{Topic: t, Partition: p, Committed: -1, MemberID: owner[t][p]}
+            if c, ok := committed[t][p]; ok { pl.Committed = c }
+            h, ok := hw[t][p]
+            next, lok := pl.Committed, true
+            if pl.Committed < 0 { next, lok = lw[t][p] }
+            if !ok {
+                pl.Error = "high watermark unavailable"
+            } else if !lok {
+                pl.Error = "low watermark unavailable"
+            } else {
+                pl.HighWatermark = h
+                pl.Lag = h - next
+                if pl.Lag < 0 { pl.Lag = 0 }
+                out.TotalLag += pl.Lag
+            }
+            out.Partitions = append(out.Partitions, pl)
+        }
+    }
+    sort.Slice(out.Partitions, func(i, j int) bool {
+        a, b := out.Partitions[i], out.Partitions[j]
+        if a.Topic != b.Topic { return a.Topic < b.Topic }
+        return a.Partition < b.Partition
+    })
+    return out, nil
+}
+
+// FillRoutineLoadLag 以 Kafka 高水位与作业 Progress 计算每个分区的积压并写回 jobs；
+// 不依赖消费组提交的 offset（Routine Load 默认不回提交）。尚未消费（Offset 为 -1）的分区积压为高水位减低水位。
+// 已计算过或缺少主题/进度的作业跳过，某个分区的水位缺失时该作业的总积压保持为空
+func (ka *KafkaAdmin) FillRoutineLoadLag(ctx context.Context, jobs []RLJob) error {
+    topics, unread := map[string][]int{}, map[string][]int{}
+    for _, j := range jobs {
+        if j.Lag != nil || j.Topic == "" { continue }
+        for _, p := range j.Progress {
+            topics[j.Topic] = append(topics[j.Topic], p.Partition)
+            if p.Offset < 0 { unread[j.Topic] = append(unread[j.Topic], p.Partition) }
+        }
+    }
+    if len(topics) == 0 { return nil }
+    for _, m := range []map[string][]int{topics, unread} {
+        for t, parts := range m {
+            sort.Ints(parts)
+            uniq := parts[:0]
+            for i, p := range parts {
+                if i == 0 || p != parts[i-1] { uniq = append(uniq, p) }
+            }
+            m[t] = uniq
+        }
+    }
+    hw, err := ka.HighWatermarks(ctx, topics)
+    if err != nil { return err }
+    lw, err := ka.LowWatermarks(ctx, unread)
+    if err != nil { return err }
+    for i := range jobs {
+        j := &jobs[i]
+        if j.Lag != nil || j.Topic == "" || len(j.Progress) == 0 { continue }
+        var total int64
+        complete := true
+        for k := range j.Progress {
+            p := &j.Progress[k]
+            h, ok := hw[j.Topic][p.Partition]
+            if !ok {
+                complete = false
+                continue
+            }
+            next := p.Offset + 1
+            if p.Offset < 0 {
+                if next, ok = lw[j.Topic][p.Partition]; !ok {
+                    complete = false
+                    continue
+                }
+            }
+            lag := max(h-next, 0)
+            p.HighWatermark, p.Lag = &h, &lag
+            total += lag
+        }
+        if complete { j.Lag = &total }
+    }
+    return nil
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/segmentio/kafka-go"
+)
+
+func TestGroupMembers(t *testing.T) {
+    g := kafka.DescribeGroupsResponseGroup{Members: []kafka.DescribeGroupsResponseMember{
+        {MemberID: "m-2", ClientID: "sr-be-2", ClientHost: "/10.0.0.2", MemberAssignments: kafka.DescribeGroupsResponseAssignments{
+            Topics: []kafka.GroupMemberTopic{{Topic: "clicks", Partitions: []int{5, 1, 3}}, {Topic: "orders", Partitions: []int{0}}},
+        }},
+        {MemberID: "m-1", ClientID: "sr-be-1", ClientHost: "/10.0.0.1"},
+    }}
+    want := []GroupMember{
+        {MemberID: "m-1", ClientID: "sr-be-1", ClientHost: "/10.0.0.1"},
+        {MemberID: "m-2", ClientID: "sr-be-2", ClientHost: "/10.0.0.2", Assignments: map[string][]int{"clicks": {1, 3, 5}, "orders": {0}}},
+    }
+    if got := groupMembers(g); !reflect.DeepEqual(got, want) {
+        t.Errorf("members = %+v, want %+v", got, want)
+    }
+    if got := groupMembers(kafka.DescribeGroupsResponseGroup{}); got == nil || len(got) != 0 {
+        t.Errorf("empty group = %#v, want empty slice", got)
+    }
+    // 排序不应修改响应中的分区切片
+    if parts := g.Members[0].MemberAssignments.Topics[0].Partitions; !reflect.DeepEqual(parts, []int{5, 1, 3}) {
+        t.Errorf("response partitions modified: %v", parts)
+    }
+}
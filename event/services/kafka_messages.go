@@ -0,0 +1,261 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "sort"
+    "time"
+    "unicode/utf8"
+
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+const (
+    maxBrowseMessages = 500      // 单次浏览的最大条数
+    maxBrowseValue    = 64 << 10 // 单条消息返回的 value 上限，超出部分截断
+    browseFetchBytes  = 1 << 20  // 单次 fetch 的最大字节数
+)
+
+// KafkaMessage 为浏览返回的单条消息。Value 为 JSON 时格式化输出；
+// key/value 不是合法 UTF-8 时以 base64 返回并在 Encoding 中标注；header 值按同样规则显示
+type KafkaMessage struct {
+    Partition int               `json:"partition"`
+    Offset    int64             `json:"offset"`
+    Timestamp time.Time         `json:"timestamp"`
+    Key       string            `json:"key,omitempty"`
+    Value     string            `json:"value"`
+    Headers   map[string]string `json:"headers,omitempty"`
+    Encoding  string            `json:"encoding,omitempty"` // base64
+    Truncated bool              `json:"truncated,omitempty"`
+}
+
+// MessageQuery 为消息浏览条件。Offset 为 nil 时返回最新的 Limit 条（Partition 为 -1 时跨全部分区，按时间倒序）；
+// 否则从 Partition 的 Offset 开始按顺序读取至多 Limit 条，此时必须指定 Partition
+type MessageQuery struct {
+    Partition int
+    Offset    *int64
+    Limit     int
+}
+
+func displayBytes(b []byte) (string, bool) {
+    if !utf8.Valid(b) { return base64.StdEncoding.EncodeToString(b), false }
+    if json.Valid(b) {
+        var buf bytes.Buffer
+        if err := json.Indent(&buf, b, "", "  "); err == nil { return buf.String(), true }
+    }
+    return string(b), true
+}
+
+func toKafkaMessage(m kafka.Message) KafkaMessage {
+    out := KafkaMessage{Partition: m.Partition, Offset: m.Offset, Timestamp: m.Time}
+    val := m.Value
+    if len(val) > maxBrowseValue { val, out.Truncated = val[:maxBrowseValue], true }
+    var keyOK, valOK bool
+    out.Key, keyOK = displayBytes(m.Key)
+    if out.Truncated {
+        // 截断后的 JSON 不再合法，按原文返回
+        out.Value, valOK = string(val), utf8.Valid(val)
+        if !valOK { out.Value = base64.StdEncoding.EncodeToString(val) }
+    } else {
+        out.Value, valOK = displayBytes(val)
+    }
+    if !keyOK || !valOK { out.Encoding = "base64" }
+    if len(m.Headers) > 0 {
+        out.Headers = make(map[string]string, len(m.Headers))
+        for _, h := range m.Headers { out.Headers[h.Key], _ = displayBytes(h.Value) }
+    }
+    return out
+}
+
+// readRange 读取分区内 [from, to) 的消息，至多 limit 条
+func (ka *KafkaAdmin) readRange(conn *kafka.Conn, from, to int64, limit int) ([]KafkaMessage, error) {
+    var out []KafkaMessage
+    next := from
+    for next < to && len(out) < limit {
+        if _, err := conn.Seek(next, kafka.SeekAbsolute|kafka.SeekDontCheck); err != nil { return out, err }
+        batch := conn.ReadBatchWith(kafka.ReadBatchConfig{MinBytes: 1, MaxBytes: browseFetchBytes, MaxWait: time.Second})
+        read := 0
+        for len(out) < limit {
+            m, err := batch.ReadMessage()
+            if err != nil {
+                if !errors.Is(err, io.EOF) {
+                    _ = batch.Close()
+                    return out, err
+                }
+                break
+            }
+            // 压缩批次可能包含起始 offset 之前的消息
+            if m.Offset < next || m.Offset >= to { continue }
+            out = append(out, toKafkaMessage(m))
+            next = m.Offset + 1
+            read++
+        }
+        if err := batch.Close(); err != nil && !errors.Is(err, io.EOF) { return out, err }
+        if read == 0 { break } // 事务标记等导致本批无可见消息时停止，避免空转
+    }
+    return out, nil
+}
+
+// ReadMessages 按 MessageQuery 读取主题消息
+func (ka *KafkaAdmin) ReadMessages(ctx context.Context, topic string, q MessageQuery) ([]KafkaMessage, error) {
+    if q.Limit <= 0 { q.Limit = 20 }
+    if q.Limit > maxBrowseMessages { q.Limit = maxBrowseMessages }
+    parts, err := ka.topicPartitions(ctx, topic)
+    if err != nil { return nil, err }
+    if q.Offset != nil && q.Partition < 0 {
+        return nil, fmt.Errorf("%w: offset requires partition", utils.ErrInvalid)
+    }
+    if q.Partition >= 0 {
+        var sel []kafka.Partition
+        for _, p := range parts {
+            if p.ID == q.Partition { sel = append(sel, p) }
+        }
+        if len(sel) == 0 {
+            return nil, fmt.Errorf("%w: topic %s has no partition %d", utils.ErrInvalid, topic, q.Partition)
+        }
+        parts = sel
+    }
+
+    out := []KafkaMessage{}
+    for _, p := range parts {
+        if !hasLeader(p) { return nil, fmt.Errorf("topic %s partition %d has no leader", topic, p.ID) }
+        conn, err := ka.dialPartition(ctx, p)
+        if err != nil { return nil, err }
+        low, high, err := conn.ReadOffsets()
+        if err != nil {
+            _ = conn.Close()
+            return nil, adminError(err)
+        }
+        from := max(low, high-int64(q.Limit))
+        if q.Offset != nil {
+            if *q.Offset < low || *q.Offset > high {
+                _ = conn.Close()
+                return nil, fmt.Errorf("%w: offset %d out of range [%d, %d] for partition %d", utils.ErrInvalid, *q.Offset, low, high, p.ID)
+            }
+            from = *q.Offset
+        }
+        msgs, err := ka.readRange(conn, from, high, q.Limit)
+        _ = conn.Close()
+        if err != nil { return nil, err }
+        out = append(out, msgs...)
+    }
+    if q.Offset == nil {
+        // 最新模式：跨分区按时间倒序，取前 Limit 条
+        sort.SliceStable(out, func(i, j int) bool {
+            if !out[i].Timestamp.Equal(out[j].Timestamp) { return out[i].Timestamp.After(out[j].Timestamp) }
+            return out[i].Offset > out[j].Offset
+        })
+        if len(out) > q.Limit { out = out[:q.Limit] }
+    }
+    return out, nil
+}
+
+const maxProduceRecords = 1000 // 单次写入的最大条数
+
+// ProduceRecord 为待写入的消息。Value 为 JSON 字符串时写入其内容，其他 JSON 值按紧凑格式写入；
+// Partition 为空时按 key 的 murmur2 哈希选择分区（与 Java 客户端一致），没有 key 时随机
+type ProduceRecord struct {
+    Key       string            `json:"key,omitempty"`
+    Value     json.RawMessage   `json:"value"`
+    Headers   map[string]string `json:"headers,omitempty"`
+    Partition *int              `json:"partition,omitempty"`
+}
+
+// ProduceResult 为单条消息的写入结果，顺序与请求一致
+type ProduceResult struct {
+    Partition int    `json:"partition"`
+    Offset    int64  `json:"offset"`
+    Error     string `json:"error,omitempty"`
+}
+
+func recordValue(raw json.RawMessage) ([]byte, error) {
+    var s string
+    if err := json.Unmarshal(raw, &s); err == nil { return []byte(s), nil }
+    var buf bytes.Buffer
+    if err := json.Compact(&buf, raw); err != nil { return nil, err }
+    return buf.Bytes(), nil
+}
+
+// recordBalancer 优先使用消息指定的分区（通过 WriterData 携带），否则交给 fallback
+type recordBalancer struct{ fallback kafka.Balancer }
+
+func (b recordBalancer) Balance(msg kafka.Message, partitions ...int) int {
+    if ref, ok := msg.WriterData.(produceRef); ok && ref.partition >= 0 { return ref.partition }
+    return b.fallback.Balance(msg, partitions...)
+}
+
+// produceRef 记录消息在请求中的位置与指定分区（-1 表示未指定）
+type produceRef struct{ index, partition int }
+
+// Produce 使用 kafka-go Writer 同步写入消息，返回每条消息的分区与 offset；
+// 部分消息失败时在对应结果中给出原因，全部失败时返回错误
+func (ka *KafkaAdmin) Produce(ctx context.Context, topic string, recs []ProduceRecord) ([]ProduceResult, error) {
+    if len(recs) == 0 { return nil, fmt.Errorf("%w: no records", utils.ErrInvalid) }
+    if len(recs) > maxProduceRecords {
+        return nil, fmt.Errorf("%w: at most %d records per request", utils.ErrInvalid, maxProduceRecords)
+    }
+    parts, err := ka.topicPartitions(ctx, topic)
+    if err != nil { return nil, err }
+    valid := make(map[int]bool, len(parts))
+    for _, p := range parts { valid[p.ID] = true }
+
+    msgs := make([]kafka.Message, len(recs))
+    for i, rec := range recs {
+        if len(rec.Value) == 0 { return nil, fmt.Errorf("%w: record %d has no value", utils.ErrInvalid, i) }
+        val, err := recordValue(rec.Value)
+        if err != nil { return nil, fmt.Errorf("%w: record %d: %v", utils.ErrInvalid, i, err) }
+        ref := produceRef{index: i, partition: -1}
+        if rec.Partition != nil {
+            if !valid[*rec.Partition] {
+                return nil, fmt.Errorf("%w: record %d: topic %s has no partition %d", utils.ErrInvalid, i, topic, *rec.Partition)
+            }
+            ref.partition = *rec.Partition
+        }
+        m := kafka.Message{Value: val, WriterData: ref}
+        if rec.Key != "" { m.Key = []byte(rec.Key) }
+        keys := make([]string, 0, len(rec.Headers))
+        for k := range rec.Headers { keys = append(keys, k) }
+        sort.Strings(keys)
+        for _, k := range keys { m.Headers = append(m.Headers, kafka.Header{Key: k, Value: []byte(rec.Headers[k])}) }
+        msgs[i] = m
+    }
+
+    results := make([]ProduceResult, len(recs))
+    w := &kafka.Writer{
+        Addr:         kafka.TCP(ka.addrs...),
+        Topic:        topic,
+        Balancer:     recordBalancer{fallback: kafka.Murmur2Balancer{}},
+        Transport:    ka.client.Transport,
+        RequiredAcks: kafka.RequireAll,
+        BatchSize:    len(msgs),
+        BatchTimeout: 10 * time.Millisecond,
+        WriteTimeout: adminTimeout,
+        // Completion 在 WriteMessages 返回前按批次调用，各批次写入的是互不重叠的下标
+        Completion: func(done []kafka.Message, err error) {
+            for _, m := range done {
+                ref := m.WriterData.(produceRef)
+                results[ref.index] = ProduceResult{Partition: m.Partition, Offset: m.Offset}
+                if err != nil { results[ref.index].Error = err.Error() }
+            }
+        },
+    }
+    err = w.WriteMessages(ctx, msgs...)
+    if cerr := w.Close(); err == nil { err = cerr }
+    var werrs kafka.WriteErrors
+    switch {
+    case err == nil:
+    case errors.As(err, &werrs) && werrs.Count() < len(msgs):
+        for i, e := range werrs {
+            if e != nil { results[i].Error = e.Error() }
+        }
+    default:
+        return nil, err
+    }
+    return results, nil
+}
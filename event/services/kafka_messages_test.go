@@ -0,0 +1,111 @@
+package services
+
+import (
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "strings"
+    "testing"
+    "time"
+
+    "event/config"
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+func TestDisplayBytes(t *testing.T) {
+    cases := []struct {
+        name string
+        in   []byte
+        want string
+        ok   bool
+    }{
+        {"json indented", []byte(`{"id":1,"tags":["a"]}`), "{\n  \"id\": 1,\n  \"tags\": [\n    \"a\"\n  ]\n}", true},
+        {"plain text", []byte("hello, 世界"), "hello, 世界", true},
+        {"empty", nil, "", true},
+        {"binary", []byte{0xff, 0xfe, 0x00}, base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00}), false},
+    }
+    for _, tc := range cases {
+        got, ok := displayBytes(tc.in)
+        if got != tc.want || ok != tc.ok {
+            t.Errorf("%s: displayBytes = %q, %v, want %q, %v", tc.name, got, ok, tc.want, tc.ok)
+        }
+    }
+}
+
+func TestToKafkaMessage(t *testing.T) {
+    at := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+    m := toKafkaMessage(kafka.Message{
+        Partition: 2, Offset: 41, Time: at,
+        Key:       []byte{0x01, 0xff},
+        Value:     []byte(`{"id":1}`),
+        Headers:   []kafka.Header{{Key: "trace", Value: []byte("abc")}},
+    })
+    if m.Partition != 2 || m.Offset != 41 || !m.Timestamp.Equal(at) || m.Encoding != "base64" || m.Value != "{\n  \"id\": 1\n}" || m.Headers["trace"] != "abc" || m.Truncated {
+        t.Errorf("message = %+v", m)
+    }
+
+    // 超长 JSON 截断后按原文返回，不再尝试格式化
+    big := `{"payload":"` + strings.Repeat("x", maxBrowseValue) + `"}`
+    m = toKafkaMessage(kafka.Message{Value: []byte(big)})
+    if !m.Truncated || len(m.Value) != maxBrowseValue || m.Value != big[:maxBrowseValue] || m.Encoding != "" {
+        t.Errorf("truncated: len %d, truncated %v, encoding %q", len(m.Value), m.Truncated, m.Encoding)
+    }
+    // 截断处切断多字节字符时退回 base64
+    multi := []byte(strings.Repeat("a", maxBrowseValue-1) + "世")
+    m = toKafkaMessage(kafka.Message{Value: multi})
+    if !m.Truncated || m.Encoding != "base64" {
+        t.Errorf("truncated multibyte: truncated %v, encoding %q", m.Truncated, m.Encoding)
+    }
+}
+
+func TestRecordValue(t *testing.T) {
+    cases := []struct {
+        raw  string
+        want string
+        err  bool
+    }{
+        {`"plain text"`, "plain text", false},
+        {`"{\"id\":1}"`, `{"id":1}`, false},
+        {`{ "id": 1, "tags": [ "a" ] }`, `{"id":1,"tags":["a"]}`, false},
+        {`42`, "42", false},
+        {`{"id":`, "", true},
+    }
+    for _, tc := range cases {
+        got, err := recordValue(json.RawMessage(tc.raw))
+        if tc.err != (err != nil) || string(got) != tc.want {
+            t.Errorf("recordValue(%s) = %q, %v, want %q", tc.raw, got, err, tc.want)
+        }
+    }
+}
+
+type fixedBalancer int
+
+func (b fixedBalancer) Balance(kafka.Message, ...int) int { return int(b) }
+
+func TestRecordBalancer(t *testing.T) {
+    b := recordBalancer{fallback: fixedBalancer(7)}
+    parts := []int{0, 1, 2}
+    if got := b.Balance(kafka.Message{WriterData: produceRef{index: 0, partition: 2}}, parts...); got != 2 {
+        t.Errorf("explicit partition = %d, want 2", got)
+    }
+    if got := b.Balance(kafka.Message{WriterData: produceRef{index: 1, partition: -1}}, parts...); got != 7 {
+        t.Errorf("unset partition = %d, want fallback 7", got)
+    }
+    if got := b.Balance(kafka.Message{}, parts...); got != 7 {
+        t.Errorf("no writer data = %d, want fallback 7", got)
+    }
+}
+
+func TestProduceLimits(t *testing.T) {
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}})
+    ctx := context.Background()
+    if _, err := ka.Produce(ctx, "clicks", nil); !errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("no records: err = %v, want ErrInvalid", err)
+    }
+    recs := make([]ProduceRecord, maxProduceRecords+1)
+    if _, err := ka.Produce(ctx, "clicks", recs); !errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("too many records: err = %v, want ErrInvalid", err)
+    }
+}
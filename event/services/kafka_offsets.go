@@ -0,0 +1,174 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+// OffsetTarget 描述消费位置的重置目标，消费组重置与 Routine Load 定位共用
+type OffsetTarget struct {
+    To         string     `json:"to"`                   // earliest / latest / offset / timestamp
+    Offset     *int64     `json:"offset,omitempty"`     // To=offset 时的目标位置，须在 [最早, 高水位] 之间
+    Timestamp  *time.Time `json:"timestamp,omitempty"`  // To=timestamp 时的时间点（RFC3339）
+    Ago        string     `json:"ago,omitempty"`        // To=timestamp 时也可给出相对时长，如 "1h" 表示一小时前
+    Partitions []int      `json:"partitions,omitempty"` // 为空表示全部分区
+}
+
+// PartitionOffset 为单个分区解析出的目标位置；Offset 为下一条要消费的消息
+type PartitionOffset struct {
+    Partition     int    `json:"partition"`
+    Offset        int64  `json:"offset"`
+    Previous      *int64 `json:"previous,omitempty"` // 重置前已提交的位置，未提交过时为空
+    LowWatermark  int64  `json:"low_watermark"`
+    HighWatermark int64  `json:"high_watermark"`
+}
+
+// OffsetReset 为消费组重置结果
+type OffsetReset struct {
+    Group      string            `json:"group"`
+    Topic      string            `json:"topic"`
+    DryRun     bool              `json:"dry_run"`
+    Partitions []PartitionOffset `json:"partitions"`
+}
+
+// normalize 校验重置目标并把 Ago 换算为 Timestamp
+func (t *OffsetTarget) normalize() error {
+    t.To = strings.ToLower(strings.TrimSpace(t.To))
+    switch t.To {
+    case "earliest", "latest":
+    case "offset":
+        if t.Offset == nil || *t.Offset < 0 {
+            return fmt.Errorf("%w: offset must be a non-negative number", utils.ErrInvalid)
+        }
+    case "timestamp":
+        if ago := strings.TrimSpace(t.Ago); ago != "" {
+            d, err := time.ParseDuration(ago)
+            if err != nil || d <= 0 {
+                return fmt.Errorf("%w: invalid ago %q", utils.ErrInvalid, t.Ago)
+            }
+            at := time.Now().Add(-d)
+            t.Timestamp = &at
+        }
+        if t.Timestamp == nil {
+            return fmt.Errorf("%w: timestamp or ago is required", utils.ErrInvalid)
+        }
+    default:
+        return fmt.Errorf("%w: to must be earliest, latest, offset or timestamp", utils.ErrInvalid)
+    }
+    return nil
+}
+
+// ResolveOffsets 将重置目标解析为各分区的具体位置。时间点之后没有消息的分区定位到高水位，
+// 指定的分区不存在时返回 ErrInvalid
+func (ka *KafkaAdmin) ResolveOffsets(ctx context.Context, topic string, t OffsetTarget) ([]PartitionOffset, error) {
+    if !utils.ValidTopicName(topic) {
+        return nil, fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, topic)
+    }
+    if err := t.normalize(); err != nil { return nil, err }
+    parts, err := ka.topicPartitions(ctx, topic)
+    if err != nil { return nil, err }
+    if len(t.Partitions) > 0 {
+        byID := make(map[int]kafka.Partition, len(parts))
+        for _, p := range parts { byID[p.ID] = p }
+        picked := make([]kafka.Partition, 0, len(t.Partitions))
+        seen := map[int]bool{}
+        for _, id := range t.Partitions {
+            p, ok := byID[id]
+            if !ok { return nil, fmt.Errorf("%w: topic %s has no partition %d", utils.ErrInvalid, topic, id) }
+            if seen[id] { continue }
+            seen[id] = true
+            picked = append(picked, p)
+        }
+        sort.Slice(picked, func(i, j int) bool { return picked[i].ID < picked[j].ID })
+        parts = picked
+    }
+
+    out := make([]PartitionOffset, 0, len(parts))
+    for _, p := range parts {
+        if !hasLeader(p) { return nil, fmt.Errorf("partition %d has no leader", p.ID) }
+        conn, err := ka.dialPartition(ctx, p)
+        if err != nil { return nil, fmt.Errorf("partition %d: %w", p.ID, err) }
+        po := PartitionOffset{Partition: p.ID}
+        po.LowWatermark, po.HighWatermark, err = conn.ReadOffsets()
+        if err == nil && t.To == "timestamp" {
+            po.Offset, err = conn.ReadOffset(*t.Timestamp)
+            // 该时间点之后没有消息时 broker 返回 -1
+            if err == nil && po.Offset < 0 { po.Offset = po.HighWatermark }
+        }
+        _ = conn.Close()
+        if err != nil { return nil, fmt.Errorf("partition %d: %w", p.ID, err) }
+        switch t.To {
+        case "earliest":
+            po.Offset = po.LowWatermark
+        case "latest":
+            po.Offset = po.HighWatermark
+        case "offset":
+            if *t.Offset < po.LowWatermark || *t.Offset > po.HighWatermark {
+                return nil, fmt.Errorf("%w: offset %d out of range [%d, %d] on partition %d", utils.ErrInvalid, *t.Offset, po.LowWatermark, po.HighWatermark, p.ID)
+            }
+            po.Offset = *t.Offset
+        }
+        out = append(out, po)
+    }
+    return out, nil
+}
+
+// ResetGroupOffsets 将消费组在指定主题上的已提交位置重置到目标位置。
+// 组内仍有活跃成员时返回 ErrConflict（成员会覆盖提交结果）；dryRun 只返回解析结果不提交
+func (ka *KafkaAdmin) ResetGroupOffsets(ctx context.Context, group, topic string, t OffsetTarget, dryRun bool) (*OffsetReset, error) {
+    if strings.TrimSpace(group) == "" { return nil, fmt.Errorf("%w: empty group id", utils.ErrInvalid) }
+    g, err := ka.describeGroup(ctx, group)
+    if err != nil && !errors.Is(err, utils.ErrNotFound) { return nil, err }
+    if g != nil && len(g.Members) > 0 {
+        return nil, fmt.Errorf("consumer group %s has %d active members, stop the consumers first: %w", group, len(g.Members), utils.ErrConflict)
+    }
+    offsets, err := ka.ResolveOffsets(ctx, topic, t)
+    if err != nil { return nil, err }
+
+    ids := make([]int, len(offsets))
+    for i, po := range offsets { ids[i] = po.Partition }
+    fr, err := ka.client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group, Topics: map[string][]int{topic: ids}})
+    if err != nil { return nil, err }
+    if fr.Error != nil { return nil, fr.Error }
+    prev := map[int]int64{}
+    for _, p := range fr.Topics[topic] {
+        if p.Error == nil && p.CommittedOffset >= 0 { prev[p.Partition] = p.CommittedOffset }
+    }
+    for i := range offsets {
+        if c, ok := prev[offsets[i].Partition]; ok { offsets[i].Previous = &c }
+    }
+    out := &OffsetReset{Group: group, Topic: topic, DryRun: dryRun, Partitions: offsets}
+    if dryRun { return out, nil }
+
+    commits := make([]kafka.OffsetCommit, len(offsets))
+    for i, po := range offsets { commits[i] = kafka.OffsetCommit{Partition: po.Partition, Offset: po.Offset} }
+    // 以 generation -1、空成员 ID 提交，仅对没有活跃成员的组生效
+    cr, err := ka.client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{GroupID: group, GenerationID: -1, Topics: map[string][]kafka.OffsetCommit{topic: commits}})
+    if err != nil { return nil, err }
+    for _, p := range cr.Topics[topic] {
+        if p.Error == nil { continue }
+        if errors.Is(p.Error, kafka.RebalanceInProgress) || errors.Is(p.Error, kafka.UnknownMemberId) || errors.Is(p.Error, kafka.IllegalGeneration) {
+            return nil, fmt.Errorf("consumer group %s became active during reset: %w", group, utils.ErrConflict)
+        }
+        return nil, fmt.Errorf("commit partition %d: %w", p.Partition, p.Error)
+    }
+    return out, nil
+}
+
+// RoutineLoadOffsets 将解析结果转换为 Routine Load 的 kafka_partitions / kafka_offsets
+func RoutineLoadOffsets(offsets []PartitionOffset) ([]int, []string) {
+    parts := make([]int, len(offsets))
+    offs := make([]string, len(offsets))
+    for i, po := range offsets {
+        parts[i] = po.Partition
+        offs[i] = fmt.Sprint(po.Offset)
+    }
+    return parts, offs
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+    "time"
+
+    "event/utils"
+)
+
+func TestOffsetTargetNormalize(t *testing.T) {
+    n := func(v int64) *int64 { return &v }
+    at := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+    cases := []struct {
+        name    string
+        target  OffsetTarget
+        invalid bool
+    }{
+        {"earliest", OffsetTarget{To: " Earliest "}, false},
+        {"latest", OffsetTarget{To: "latest"}, false},
+        {"offset", OffsetTarget{To: "offset", Offset: n(0)}, false},
+        {"offset missing", OffsetTarget{To: "offset"}, true},
+        {"negative offset", OffsetTarget{To: "offset", Offset: n(-1)}, true},
+        {"timestamp", OffsetTarget{To: "timestamp", Timestamp: &at}, false},
+        {"ago", OffsetTarget{To: "timestamp", Ago: "1h"}, false},
+        {"bad ago", OffsetTarget{To: "timestamp", Ago: "yesterday"}, true},
+        {"negative ago", OffsetTarget{To: "timestamp", Ago: "-5m"}, true},
+        {"timestamp missing", OffsetTarget{To: "timestamp"}, true},
+        {"unknown", OffsetTarget{To: "beginning"}, true},
+    }
+    for _, tc := range cases {
+        target := tc.target
+        err := target.normalize()
+        if tc.invalid {
+            if !errors.Is(err, utils.ErrInvalid) { t.Errorf("%s: err = %v, want ErrInvalid", tc.name, err) }
+            continue
+        }
+        if err != nil { t.Errorf("%s: %v", tc.name, err) }
+    }
+
+    // ago 换算为相对当前时间的时间点
+    target := OffsetTarget{To: "timestamp", Ago: "1h", Timestamp: &at}
+    if err := target.normalize(); err != nil { t.Fatal(err) }
+    if d := time.Since(*target.Timestamp); d < time.Hour || d > time.Hour+time.Minute {
+        t.Errorf("ago 1h resolved to %s ago", d)
+    }
+}
+
+func TestRoutineLoadOffsets(t *testing.T) {
+    parts, offs := RoutineLoadOffsets([]PartitionOffset{{Partition: 2, Offset: 0}, {Partition: 0, Offset: 1500}})
+    if !reflect.DeepEqual(parts, []int{2, 0}) || !reflect.DeepEqual(offs, []string{"0", "1500"}) {
+        t.Errorf("parts %v offsets %v", parts, offs)
+    }
+    // 结果可直接用于 kafkaOffsetProps
+    props, err := kafkaOffsetProps(parts, offs)
+    if err != nil || len(props) != 2 {
+        t.Errorf("props = %q, err %v", props, err)
+    }
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+// AddPartitions 通过 CreatePartitions 将主题分区数增加到 count，返回原分区数；
+// Kafka 不支持减少分区，count 不大于当前分区数时返回 ErrInvalid
+func (ka *KafkaAdmin) AddPartitions(ctx context.Context, topic string, count int) (int, error) {
+    if !utils.ValidTopicName(topic) {
+        return 0, fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, topic)
+    }
+    parts, err := ka.topicPartitions(ctx, topic)
+    if err != nil { return 0, err }
+    old := len(parts)
+    if count <= old {
+        return old, fmt.Errorf("%w: topic %s already has %d partitions, count must be greater", utils.ErrInvalid, topic, old)
+    }
+    resp, err := ka.client.CreatePartitions(ctx, &kafka.CreatePartitionsRequest{
+        Topics: []kafka.TopicPartitionsConfig{{Name: topic, Count: int32(count)}},
+    })
+    if err != nil { return old, err }
+    if err := resp.Errors[topic]; err != nil { return old, adminError(err) }
+    return old, nil
+}
+
+// RLPartitionCheck 为消费某主题的 Routine Load 作业的分区覆盖情况。
+// Explicit 表示作业创建时指定了 kafka_partitions，不会自动感知新增分区，需要重建作业；
+// 取不到 CREATE 语句时无法判断，为空
+type RLPartitionCheck struct {
+    Job        string `json:"job"`
+    State      string `json:"state"`
+    Partitions []int  `json:"partitions"`
+    Missing    []int  `json:"missing,omitempty"`
+    PickedUp   bool   `json:"picked_up"`
+    Explicit   *bool  `json:"explicit"`
+    Restarted  bool   `json:"restarted,omitempty"`
+    Error      string `json:"error,omitempty"`
+}
+
+// CurrentPartitions 读取 DataSourceProperties 中作业当前消费的分区，如 "0,1,2"；FE 未给出时为空
+func (d *RLDetails) CurrentPartitions() []int {
+    var out []int
+    for _, s := range strings.Split(d.Kafka["currentKafkaPartitions"], ",") {
+        if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil { out = append(out, n) }
+    }
+    sort.Ints(out)
+    return out
+}
+
+// CheckTopicJobs 检查消费该主题（按 DataSourceProperties 中的 topic 匹配）且未停止的 Routine Load 作业
+// 是否已覆盖主题的全部分区。count 大于 0 时以 0..count-1 作为全部分区（刚扩容后元数据可能尚未更新），
+// 否则读取主题元数据。restart 为 true 时对未覆盖、处于 RUNNING 且未确认显式指定分区的作业
+// 执行暂停与恢复，促使 FE 重新获取分区列表
+func (s *PipelineService) CheckTopicJobs(ctx context.Context, topic string, count int, restart bool) ([]RLPartitionCheck, error) {
+    var ids []int
+    if count > 0 {
+        for i := 0; i < count; i++ { ids = append(ids, i) }
+    } else {
+        parts, err := s.ka.topicPartitions(ctx, topic)
+        if err != nil { return nil, err }
+        for _, p := range parts { ids = append(ids, p.ID) }
+    }
+    jobs, err := s.sr.ListRoutineLoad(ctx)
+    if err != nil { return nil, fmt.Errorf("list routine load: %w", err) }
+    out := []RLPartitionCheck{}
+    for _, j := range jobs {
+        st := strings.ToUpper(strings.TrimSpace(j.State))
+        if st == "STOPPED" || st == "CANCELLED" { continue }
+        d, err := s.sr.GetRoutineLoadDetails(ctx, j.Name)
+        if err != nil {
+            // 详情查询失败时退回列表中的主题，避免漏报
+            if j.Topic == topic { out = append(out, RLPartitionCheck{Job: j.Name, State: st, Partitions: []int{}, Error: err.Error()}) }
+            continue
+        }
+        if d.Kafka["topic"] != topic { continue }
+        c := partitionCheck(j.Name, st, d, ids)
+        if restart && !c.PickedUp && (c.Explicit == nil || !*c.Explicit) && st == "RUNNING" {
+            if err := s.sr.PauseRoutineLoad(ctx, j.Name); err != nil {
+                c.Error = "pause: " + err.Error()
+            } else if err := s.sr.ResumeRoutineLoad(ctx, j.Name); err != nil {
+                c.Error = "resume: " + err.Error()
+            } else {
+                c.Restarted = true
+            }
+        }
+        out = append(out, c)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Job < out[j].Job })
+    return out, nil
+}
+
+// partitionCheck 对比作业当前消费的分区与主题的全部分区 ids
+func partitionCheck(job, state string, d *RLDetails, ids []int) RLPartitionCheck {
+    c := RLPartitionCheck{Job: job, State: state, Partitions: d.CurrentPartitions()}
+    if c.Partitions == nil { c.Partitions = []int{} }
+    if d.CreateSQL != "" {
+        explicit := strings.Contains(d.CreateSQL, "kafka_partitions")
+        c.Explicit = &explicit
+    }
+    have := make(map[int]bool, len(c.Partitions))
+    for _, p := range c.Partitions { have[p] = true }
+    for _, id := range ids {
+        if !have[id] { c.Missing = append(c.Missing, id) }
+    }
+    c.PickedUp = len(c.Missing) == 0
+    return c
+}
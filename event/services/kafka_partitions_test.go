@@ -0,0 +1,58 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "testing"
+
+    "event/config"
+    "event/utils"
+)
+
+func TestCurrentPartitions(t *testing.T) {
+    cases := []struct {
+        kafka map[string]string
+        want  []int
+    }{
+        {map[string]string{"currentKafkaPartitions": "2,0, 1"}, []int{0, 1, 2}},
+        {map[string]string{"currentKafkaPartitions": "0,x,3"}, []int{0, 3}},
+        {map[string]string{"currentKafkaPartitions": ""}, nil},
+        {nil, nil},
+    }
+    for _, tc := range cases {
+        d := RLDetails{Kafka: tc.kafka}
+        if got := d.CurrentPartitions(); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("CurrentPartitions(%v) = %v, want %v", tc.kafka, got, tc.want)
+        }
+    }
+}
+
+func TestPartitionCheck(t *testing.T) {
+    yes, no := true, false
+    cases := []struct {
+        name     string
+        d        RLDetails
+        missing  []int
+        pickedUp bool
+        explicit *bool
+    }{
+        {"covered", RLDetails{Kafka: map[string]string{"currentKafkaPartitions": "0,1,2,3"}, CreateSQL: "CREATE ROUTINE LOAD x FROM KAFKA (\"kafka_topic\" = \"clicks\")"}, nil, true, &no},
+        {"new partitions not picked up", RLDetails{Kafka: map[string]string{"currentKafkaPartitions": "0,1"}}, []int{2, 3}, false, nil},
+        {"explicit partitions", RLDetails{Kafka: map[string]string{"currentKafkaPartitions": "0,1"}, CreateSQL: "... \"kafka_partitions\" = \"0,1\" ..."}, []int{2, 3}, false, &yes},
+        {"no partitions reported", RLDetails{}, []int{0, 1, 2, 3}, false, nil},
+    }
+    for _, tc := range cases {
+        c := partitionCheck("clicks_rl", "RUNNING", &tc.d, []int{0, 1, 2, 3})
+        if !reflect.DeepEqual(c.Missing, tc.missing) || c.PickedUp != tc.pickedUp || !reflect.DeepEqual(c.Explicit, tc.explicit) || c.Partitions == nil {
+            t.Errorf("%s: check = %+v", tc.name, c)
+        }
+    }
+}
+
+func TestAddPartitionsInvalidTopic(t *testing.T) {
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}})
+    if _, err := ka.AddPartitions(context.Background(), "a b", 6); !errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("err = %v, want ErrInvalid", err)
+    }
+}
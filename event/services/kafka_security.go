@@ -0,0 +1,81 @@
+package services
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "fmt"
+    "os"
+    "strings"
+
+    "event/config"
+    "github.com/segmentio/kafka-go/sasl"
+    "github.com/segmentio/kafka-go/sasl/plain"
+    "github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// saslMechanism 按配置构造 SASL 机制，未配置时返回 nil
+func saslMechanism(c config.KafkaSASLConfig) (sasl.Mechanism, error) {
+    switch strings.ToUpper(strings.TrimSpace(c.Mechanism)) {
+    case "":
+        return nil, nil
+    case "PLAIN":
+        return plain.Mechanism{Username: c.Username, Password: c.Password}, nil
+    case "SCRAM-SHA-256":
+        return scram.Mechanism(scram.SHA256, c.Username, c.Password)
+    case "SCRAM-SHA-512":
+        return scram.Mechanism(scram.SHA512, c.Username, c.Password)
+    }
+    return nil, fmt.Errorf("unsupported kafka sasl mechanism %q", c.Mechanism)
+}
+
+// tlsEnabled 判断是否启用 TLS：显式开启或配置了任一证书文件
+func tlsEnabled(c config.KafkaTLSConfig) bool {
+    return c.Enabled || c.CAFile != "" || c.CertFile != ""
+}
+
+// tlsConfig 按配置构造 TLS 配置，未启用时返回 nil
+func tlsConfig(c config.KafkaTLSConfig) (*tls.Config, error) {
+    if !tlsEnabled(c) { return nil, nil }
+    tc := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: c.InsecureSkipVerify}
+    if c.CAFile != "" {
+        pem, err := os.ReadFile(c.CAFile)
+        if err != nil { return nil, fmt.Errorf("read kafka ca file: %w", err) }
+        pool := x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(pem) { return nil, fmt.Errorf("no certificates found in %s", c.CAFile) }
+        tc.RootCAs = pool
+    }
+    if c.CertFile != "" || c.KeyFile != "" {
+        cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+        if err != nil { return nil, fmt.Errorf("load kafka client certificate: %w", err) }
+        tc.Certificates = []tls.Certificate{cert}
+    }
+    return tc, nil
+}
+
+// RoutineLoadSecurityProperties 将 Kafka 的 SASL/TLS 配置转换为 Routine Load 的 FROM KAFKA 数据源属性
+// （property.security.protocol、property.sasl.* 等）；未启用安全配置时返回空
+func RoutineLoadSecurityProperties(c config.KafkaConfig) map[string]string {
+    mech := strings.ToUpper(strings.TrimSpace(c.SASL.Mechanism))
+    useTLS := tlsEnabled(c.TLS)
+    props := map[string]string{}
+    switch {
+    case mech != "" && useTLS:
+        props["property.security.protocol"] = "SASL_SSL"
+    case mech != "":
+        props["property.security.protocol"] = "SASL_PLAINTEXT"
+    case useTLS:
+        props["property.security.protocol"] = "SSL"
+    default:
+        return nil
+    }
+    if mech != "" {
+        props["property.sasl.mechanism"] = mech
+        props["property.sasl.username"] = c.SASL.Username
+        props["property.sasl.password"] = c.SASL.Password
+    }
+    if useTLS {
+        if c.TLS.RoutineLoadCA != "" { props["property.ssl.ca.location"] = c.TLS.RoutineLoadCA }
+        if c.TLS.InsecureSkipVerify { props["property.enable.ssl.certificate.verification"] = "false" }
+    }
+    return props
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+    "context"
+    "path/filepath"
+    "reflect"
+    "strings"
+    "testing"
+
+    "event/config"
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+    "go.uber.org/zap/zaptest/observer"
+)
+
+func TestSASLMechanism(t *testing.T) {
+    cases := []struct {
+        mech string
+        name string
+        err  bool
+    }{
+        {"", "", false},
+        {"plain", "PLAIN", false},
+        {" SCRAM-SHA-256 ", "SCRAM-SHA-256", false},
+        {"scram-sha-512", "SCRAM-SHA-512", false},
+        {"GSSAPI", "", true},
+    }
+    for _, tc := range cases {
+        m, err := saslMechanism(config.KafkaSASLConfig{Mechanism: tc.mech, Username: "u", Password: "p"})
+        if tc.err != (err != nil) {
+            t.Errorf("%q: err = %v", tc.mech, err)
+            continue
+        }
+        name := ""
+        if m != nil { name = m.Name() }
+        if name != tc.name {
+            t.Errorf("%q: mechanism = %q, want %q", tc.mech, name, tc.name)
+        }
+    }
+}
+
+func TestTLSConfig(t *testing.T) {
+    if tc, err := tlsConfig(config.KafkaTLSConfig{}); tc != nil || err != nil {
+        t.Errorf("disabled: %v, %v", tc, err)
+    }
+    tc, err := tlsConfig(config.KafkaTLSConfig{Enabled: true, InsecureSkipVerify: true})
+    if err != nil || tc == nil || !tc.InsecureSkipVerify || tc.RootCAs != nil {
+        t.Errorf("enabled: %+v, %v", tc, err)
+    }
+    // 配置了证书文件即视为启用；文件不存在时报错
+    if _, err := tlsConfig(config.KafkaTLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+        t.Error("missing ca file: want error")
+    }
+    // 安全配置错误时所有连接返回同一错误，不会以明文连接
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}, SASL: config.KafkaSASLConfig{Mechanism: "GSSAPI"}}})
+    if _, err := ka.dial(context.Background(), "127.0.0.1:1"); err == nil || !strings.Contains(err.Error(), "unsupported kafka sasl mechanism") {
+        t.Errorf("dial with bad sasl: err = %v", err)
+    }
+}
+
+func TestRoutineLoadSecurityProperties(t *testing.T) {
+    sasl := config.KafkaSASLConfig{Mechanism: "scram-sha-512", Username: "sr", Password: "secret"}
+    cases := []struct {
+        name string
+        cfg  config.KafkaConfig
+        want map[string]string
+    }{
+        {"plaintext", config.KafkaConfig{}, nil},
+        {"sasl", config.KafkaConfig{SASL: sasl}, map[string]string{
+            "property.security.protocol": "SASL_PLAINTEXT", "property.sasl.mechanism": "SCRAM-SHA-512",
+            "property.sasl.username": "sr", "property.sasl.password": "secret",
+        }},
+        {"tls", config.KafkaConfig{TLS: config.KafkaTLSConfig{Enabled: true, RoutineLoadCA: "FILE:kafka_ca.pem", InsecureSkipVerify: true}}, map[string]string{
+            "property.security.protocol": "SSL", "property.ssl.ca.location": "FILE:kafka_ca.pem",
+            "property.enable.ssl.certificate.verification": "false",
+        }},
+        {"sasl over tls", config.KafkaConfig{SASL: sasl, TLS: config.KafkaTLSConfig{CAFile: "/etc/kafka/ca.pem"}}, map[string]string{
+            "property.security.protocol": "SASL_SSL", "property.sasl.mechanism": "SCRAM-SHA-512",
+            "property.sasl.username": "sr", "property.sasl.password": "secret",
+        }},
+    }
+    for _, tc := range cases {
+        if got := RoutineLoadSecurityProperties(tc.cfg); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%s: properties = %v, want %v", tc.name, got, tc.want)
+        }
+    }
+}
+
+func TestCreateRoutineLoadMasksPassword(t *testing.T) {
+    core, logs := observer.New(zapcore.DebugLevel)
+    cfg := config.Config{
+        StarRocks: config.StarRocksConfig{FEHost: "127.0.0.1", FEPort: 1, User: "root", Database: "eventdb"},
+        Kafka:     config.KafkaConfig{SASL: config.KafkaSASLConfig{Mechanism: "PLAIN", Username: "sr", Password: `pa"ss`}},
+    }
+    c, err := NewStarRocksClient(cfg, zap.New(core))
+    if err != nil { t.Fatal(err) }
+    defer c.Close()
+    req := RLCreateRequest{Name: "clicks_rl", Table: "clicks", Kafka: KafkaSource{BrokerList: "kafka:9092", Topic: "clicks", GroupID: "sr-clicks"}}
+    if err := c.CreateRoutineLoad(context.Background(), req); err == nil {
+        t.Fatal("unreachable StarRocks: want error")
+    }
+    entries := logs.FilterMessage("sr.create_rl").All()
+    if len(entries) != 1 {
+        t.Fatalf("log entries = %d, want 1", len(entries))
+    }
+    sql := entries[0].ContextMap()["sql"].(string)
+    if strings.Contains(sql, `pa\"ss`) {
+        t.Errorf("password leaked into log: %s", sql)
+    }
+    if got := sqlQuote(`pa"ss\`); got != `"pa\"ss\\"` {
+        t.Errorf("sqlQuote = %s", got)
+    }
+    for _, want := range []string{`"property.sasl.password" = "******"`, `"property.security.protocol" = "SASL_PLAINTEXT"`, `"property.sasl.username" = "sr"`} {
+        if !strings.Contains(sql, want) { t.Errorf("sql missing %s:\n%s", want, sql) }
+    }
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "event/api/models"
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+const maxTopicConfigChanges = 1000 // 配置修改记录保留条数
+
+// TopicConfigEntry 为主题的单个配置项；Source 为配置生效来源，如 DYNAMIC_TOPIC_CONFIG 表示主题级覆盖
+type TopicConfigEntry struct {
+    Name      string `json:"name"`
+    Value     string `json:"value"`
+    Default   bool   `json:"default"`
+    ReadOnly  bool   `json:"read_only,omitempty"`
+    Sensitive bool   `json:"sensitive,omitempty"`
+    Source    string `json:"source"`
+}
+
+// configSources 对应 DescribeConfigs 响应中的 ConfigSource 取值
+var configSources = map[int8]string{
+    1: "DYNAMIC_TOPIC_CONFIG",
+    2: "DYNAMIC_BROKER_CONFIG",
+    3: "DYNAMIC_DEFAULT_BROKER_CONFIG",
+    4: "STATIC_BROKER_CONFIG",
+    5: "DEFAULT_CONFIG",
+    6: "DYNAMIC_BROKER_LOGGER_CONFIG",
+}
+
+// TopicConfigs 通过 DescribeConfigs 返回主题的全部配置（按名称排序），敏感配置不返回取值
+func (ka *KafkaAdmin) TopicConfigs(ctx context.Context, topic string) ([]TopicConfigEntry, error) {
+    if !utils.ValidTopicName(topic) {
+        return nil, fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, topic)
+    }
+    resp, err := ka.client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{
+        Resources: []kafka.DescribeConfigRequestResource{{ResourceType: kafka.ResourceTypeTopic, ResourceName: topic}},
+    })
+    if err != nil { return nil, err }
+    out := []TopicConfigEntry{}
+    for _, res := range resp.Resources {
+        if res.Error != nil { return nil, adminError(res.Error) }
+        for _, e := range res.ConfigEntries {
+            ce := TopicConfigEntry{Name: e.ConfigName, Value: e.ConfigValue, Default: e.IsDefault, ReadOnly: e.ReadOnly, Sensitive: e.IsSensitive}
+            ce.Source = configSources[e.ConfigSource]
+            if ce.Source == "" { ce.Source = "UNKNOWN" }
+            // v1 以上的响应不再填写 IsDefault，按来源判断
+            if e.ConfigSource != 0 { ce.Default = e.ConfigSource != 1 }
+            if ce.Sensitive { ce.Value = "" }
+            out = append(out, ce)
+        }
+    }
+    // 主题不存在时部分 broker 版本返回空配置而非错误码
+    if len(out) == 0 { return nil, fmt.Errorf("topic %s: %w", topic, utils.ErrNotFound) }
+    sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+    return out, nil
+}
+
+// AlterTopicConfigs 通过 IncrementalAlterConfigs 修改主题配置，只影响请求中出现的配置项：
+// 值为 nil 表示删除主题级覆盖、恢复为默认值。取值先经 ValidateTopicConfigs 校验，
+// 未知或只读的配置项返回 ErrInvalid。返回实际发生变化的配置项（不含时间与操作人）
+func (ka *KafkaAdmin) AlterTopicConfigs(ctx context.Context, topic string, changes map[string]*string) ([]models.TopicConfigChange, error) {
+    if len(changes) == 0 { return nil, fmt.Errorf("%w: no configs to alter", utils.ErrInvalid) }
+    set := map[string]string{}
+    for k, v := range changes {
+        if v != nil { set[k] = strings.TrimSpace(*v) }
+    }
+    if err := ValidateTopicConfigs(set); err != nil { return nil, err }
+    for k, v := range changes {
+        if v == nil && !topicConfigKeyRe.MatchString(k) {
+            return nil, fmt.Errorf("%w: invalid config name %q", utils.ErrInvalid, k)
+        }
+    }
+    current, err := ka.TopicConfigs(ctx, topic)
+    if err != nil { return nil, err }
+    diff, configs, err := topicConfigDiff(topic, current, changes)
+    if err != nil { return nil, err }
+    if len(configs) == 0 { return diff, nil }
+    resp, err := ka.client.IncrementalAlterConfigs(ctx, &kafka.IncrementalAlterConfigsRequest{
+        Resources: []kafka.IncrementalAlterConfigsRequestResource{{ResourceType: kafka.ResourceTypeTopic, ResourceName: topic, Configs: configs}},
+    })
+    if err != nil { return nil, err }
+    for _, res := range resp.Resources {
+        if res.Error != nil { return nil, adminError(res.Error) }
+    }
+    return diff, nil
+}
+
+// topicConfigDiff 对比主题当前配置与请求，返回实际会变化的配置项及需下发的操作：
+// 取值与现有主题级覆盖相同、或要删除的配置项本就没有覆盖时跳过
+func topicConfigDiff(topic string, current []TopicConfigEntry, changes map[string]*string) ([]models.TopicConfigChange, []kafka.IncrementalAlterConfigsRequestConfig, error) {
+    byName := make(map[string]TopicConfigEntry, len(current))
+    for _, e := range current { byName[e.Name] = e }
+    names := make([]string, 0, len(changes))
+    for k := range changes { names = append(names, k) }
+    sort.Strings(names)
+    var diff []models.TopicConfigChange
+    var configs []kafka.IncrementalAlterConfigsRequestConfig
+    for _, k := range names {
+        e, ok := byName[k]
+        if !ok { return nil, nil, fmt.Errorf("%w: unknown topic config %q", utils.ErrInvalid, k) }
+        if e.ReadOnly { return nil, nil, fmt.Errorf("%w: topic config %q is read-only", utils.ErrInvalid, k) }
+        if changes[k] == nil {
+            // 没有主题级覆盖时无需删除
+            if e.Source != "DYNAMIC_TOPIC_CONFIG" { continue }
+            configs = append(configs, kafka.IncrementalAlterConfigsRequestConfig{Name: k, ConfigOperation: kafka.ConfigOperationDelete})
+            diff = append(diff, models.TopicConfigChange{Topic: topic, Config: k, Old: e.Value, Reset: true})
+            continue
+        }
+        v := strings.TrimSpace(*changes[k])
+        if v == e.Value && e.Source == "DYNAMIC_TOPIC_CONFIG" { continue }
+        configs = append(configs, kafka.IncrementalAlterConfigsRequestConfig{Name: k, Value: v, ConfigOperation: kafka.ConfigOperationSet})
+        diff = append(diff, models.TopicConfigChange{Topic: topic, Config: k, Old: e.Value, New: v})
+    }
+    return diff, configs, nil
+}
+
+// TopicConfigHistory 在 Store 中记录主题配置的修改历史
+type TopicConfigHistory struct {
+    store *Store
+}
+
+func NewTopicConfigHistory(store *Store) *TopicConfigHistory { return &TopicConfigHistory{store: store} }
+
+// Record 追加修改记录，操作人取自 context（见 WithOperator）
+func (h *TopicConfigHistory) Record(ctx context.Context, changes []models.TopicConfigChange) error {
+    if len(changes) == 0 { return nil }
+    now := time.Now().UTC()
+    author := operatorFrom(ctx)
+    return h.store.Update(func(d *storeData) error {
+        for _, c := range changes {
+            c.Author, c.At = author, now
+            d.TopicConfigChanges = append(d.TopicConfigChanges, c)
+        }
+        if n := len(d.TopicConfigChanges); n > maxTopicConfigChanges {
+            d.TopicConfigChanges = append([]models.TopicConfigChange(nil), d.TopicConfigChanges[n-maxTopicConfigChanges:]...)
+        }
+        return nil
+    })
+}
+
+// List 返回主题最近的修改记录（新的在前）
+func (h *TopicConfigHistory) List(topic string, limit int) []models.TopicConfigChange {
+    if limit <= 0 { limit = 50 }
+    out := []models.TopicConfigChange{}
+    _ = h.store.View(func(d *storeData) error {
+        for i := len(d.TopicConfigChanges) - 1; i >= 0 && len(out) < limit; i-- {
+            if d.TopicConfigChanges[i].Topic == topic { out = append(out, d.TopicConfigChanges[i]) }
+        }
+        return nil
+    })
+    return out
+}
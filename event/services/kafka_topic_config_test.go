@@ -0,0 +1,93 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "testing"
+
+    "event/api/models"
+    "event/config"
+    "event/utils"
+    "github.com/segmentio/kafka-go"
+)
+
+func TestTopicConfigDiff(t *testing.T) {
+    current := []TopicConfigEntry{
+        {Name: "retention.ms", Value: "86400000", Source: "DYNAMIC_TOPIC_CONFIG"},
+        {Name: "cleanup.policy", Value: "delete", Default: true, Source: "DEFAULT_CONFIG"},
+        {Name: "max.message.bytes", Value: "1048588", Source: "STATIC_BROKER_CONFIG"},
+        {Name: "message.format.version", Value: "3.0", ReadOnly: true},
+    }
+    s := func(v string) *string { return &v }
+    cases := []struct {
+        name    string
+        changes map[string]*string
+        diff    []models.TopicConfigChange
+        ops     []kafka.IncrementalAlterConfigsRequestConfig
+        invalid bool
+    }{
+        {"set new override", map[string]*string{"cleanup.policy": s(" compact ")},
+            []models.TopicConfigChange{{Topic: "clicks", Config: "cleanup.policy", Old: "delete", New: "compact"}},
+            []kafka.IncrementalAlterConfigsRequestConfig{{Name: "cleanup.policy", Value: "compact", ConfigOperation: kafka.ConfigOperationSet}}, false},
+        {"same override unchanged", map[string]*string{"retention.ms": s("86400000")}, nil, nil, false},
+        // 与 broker 级取值相同但尚无主题级覆盖：仍需写入覆盖
+        {"pin broker value", map[string]*string{"max.message.bytes": s("1048588")},
+            []models.TopicConfigChange{{Topic: "clicks", Config: "max.message.bytes", Old: "1048588", New: "1048588"}},
+            []kafka.IncrementalAlterConfigsRequestConfig{{Name: "max.message.bytes", Value: "1048588", ConfigOperation: kafka.ConfigOperationSet}}, false},
+        {"reset override", map[string]*string{"retention.ms": nil, "cleanup.policy": nil},
+            []models.TopicConfigChange{{Topic: "clicks", Config: "retention.ms", Old: "86400000", Reset: true}},
+            []kafka.IncrementalAlterConfigsRequestConfig{{Name: "retention.ms", ConfigOperation: kafka.ConfigOperationDelete}}, false},
+        {"unknown config", map[string]*string{"retention.hours": s("1")}, nil, nil, true},
+        {"read only", map[string]*string{"message.format.version": s("2.8")}, nil, nil, true},
+    }
+    for _, tc := range cases {
+        diff, ops, err := topicConfigDiff("clicks", current, tc.changes)
+        if tc.invalid {
+            if !errors.Is(err, utils.ErrInvalid) { t.Errorf("%s: err = %v, want ErrInvalid", tc.name, err) }
+            continue
+        }
+        if err != nil { t.Fatalf("%s: %v", tc.name, err) }
+        if !reflect.DeepEqual(diff, tc.diff) || !reflect.DeepEqual(ops, tc.ops) {
+            t.Errorf("%s: diff %+v ops %+v, want %+v %+v", tc.name, diff, ops, tc.diff, tc.ops)
+        }
+    }
+}
+
+func TestAlterTopicConfigsValidation(t *testing.T) {
+    ka := NewKafkaAdmin(config.Config{Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}})
+    bad := "forever"
+    for name, changes := range map[string]map[string]*string{
+        "empty":         {},
+        "bad value":     {"retention.ms": &bad},
+        "bad reset key": {"Retention.MS": nil},
+    } {
+        if _, err := ka.AlterTopicConfigs(context.Background(), "clicks", changes); !errors.Is(err, utils.ErrInvalid) {
+            t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+        }
+    }
+}
+
+func TestTopicConfigHistory(t *testing.T) {
+    store, err := NewStore(config.Config{Storage: config.StorageConfig{DataDir: t.TempDir()}})
+    if err != nil { t.Fatal(err) }
+    h := NewTopicConfigHistory(store)
+    ctx := WithOperator(context.Background(), "alice")
+    if err := h.Record(ctx, []models.TopicConfigChange{
+        {Topic: "clicks", Config: "retention.ms", Old: "1", New: "2"},
+        {Topic: "orders", Config: "retention.ms", Old: "1", New: "3"},
+    }); err != nil { t.Fatal(err) }
+    if err := h.Record(ctx, []models.TopicConfigChange{{Topic: "clicks", Config: "cleanup.policy", Old: "delete", New: "compact"}}); err != nil {
+        t.Fatal(err)
+    }
+    got := h.List("clicks", 0)
+    if len(got) != 2 || got[0].Config != "cleanup.policy" || got[1].Config != "retention.ms" || got[0].Author != "alice" || got[0].At.IsZero() {
+        t.Errorf("history = %+v", got)
+    }
+    if got := h.List("clicks", 1); len(got) != 1 {
+        t.Errorf("limit 1: %d entries", len(got))
+    }
+    if got := h.List("missing", 0); got == nil || len(got) != 0 {
+        t.Errorf("unknown topic = %#v, want empty slice", got)
+    }
+}
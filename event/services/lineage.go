@@ -0,0 +1,205 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+
+    "event/config"
+    "event/utils"
+)
+
+// LineageNode 为血缘图中的一个节点，ID 形如 topic:page_views、job:page_views_rl、table:page_views、mv:mv_pv_per_minute
+type LineageNode struct {
+    ID       string `json:"id"`
+    Type     string `json:"type"` // topic/job/table/mv
+    Name     string `json:"name"`
+    State    string `json:"state,omitempty"`
+    Pipeline string `json:"pipeline,omitempty"`
+}
+
+type LineageEdge struct {
+    From string `json:"from"`
+    To   string `json:"to"`
+}
+
+// LineageGraph 描述 Kafka 主题 → Routine Load → 表 → 物化视图 的依赖关系
+type LineageGraph struct {
+    Nodes    []LineageNode `json:"nodes"`
+    Edges    []LineageEdge `json:"edges"`
+    Warnings []string      `json:"warnings,omitempty"`
+}
+
+// LineageService 由 Routine Load 列表、information_schema 与物化视图元数据构建血缘图
+type LineageService struct {
+    pipelines *PipelineService
+    sr        *StarRocksClient
+}
+
+func NewLineageService(cfg config.Config, store *Store, sr *StarRocksClient) *LineageService {
+    return &LineageService{pipelines: NewPipelineService(cfg, store, sr), sr: sr}
+}
+
+func lineageID(typ, name string) string { return typ + ":" + name }
+
+// lineageTypeOrder 决定节点输出顺序，便于前端按列布局
+var lineageTypeOrder = map[string]int{"topic": 0, "job": 1, "table": 2, "mv": 3}
+
+type lineageBuilder struct {
+    nodes map[string]*LineageNode
+    edges map[LineageEdge]bool
+}
+
+func (b *lineageBuilder) node(typ, name string) *LineageNode {
+    id := lineageID(typ, name)
+    if n, ok := b.nodes[id]; ok { return n }
+    n := &LineageNode{ID: id, Type: typ, Name: name}
+    b.nodes[id] = n
+    return n
+}
+
+func (b *lineageBuilder) edge(from, to *LineageNode) { b.edges[LineageEdge{From: from.ID, To: to.ID}] = true }
+
+func (b *lineageBuilder) graph() *LineageGraph {
+    g := &LineageGraph{Nodes: make([]LineageNode, 0, len(b.nodes)), Edges: make([]LineageEdge, 0, len(b.edges))}
+    for _, n := range b.nodes { g.Nodes = append(g.Nodes, *n) }
+    sort.Slice(g.Nodes, func(i, j int) bool {
+        a, c := g.Nodes[i], g.Nodes[j]
+        if lineageTypeOrder[a.Type] != lineageTypeOrder[c.Type] { return lineageTypeOrder[a.Type] < lineageTypeOrder[c.Type] }
+        return a.Name < c.Name
+    })
+    for e := range b.edges { g.Edges = append(g.Edges, e) }
+    sort.Slice(g.Edges, func(i, j int) bool {
+        if g.Edges[i].From != g.Edges[j].From { return g.Edges[i].From < g.Edges[j].From }
+        return g.Edges[i].To < g.Edges[j].To
+    })
+    return g
+}
+
+// mvSourceRe 匹配物化视图定义中 FROM / JOIN 之后的表名（可带库名与反引号）
+var mvSourceRe = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+((?:`?\\w+`?\\.)?`?\\w+`?)")
+
+// mvBaseTables 从物化视图定义中提取引用的表；known 非空时只保留库中存在的表或物化视图，
+// 以排除 EXTRACT(x FROM col) 这类被误匹配的列名
+func mvBaseTables(def string, known map[string]bool) []string {
+    seen := map[string]bool{}
+    var out []string
+    for _, m := range mvSourceRe.FindAllStringSubmatch(def, -1) {
+        ref := strings.ReplaceAll(m[1], "`", "")
+        if i := strings.LastIndex(ref, "."); i >= 0 { ref = ref[i+1:] }
+        if seen[ref] || (len(known) > 0 && !known[ref]) { continue }
+        seen[ref] = true
+        out = append(out, ref)
+    }
+    return out
+}
+
+// Build 构建完整血缘图。作业列表获取失败时返回错误；表与物化视图元数据获取失败时记录告警并返回不完整的图
+func (s *LineageService) Build(ctx context.Context) (*LineageGraph, error) {
+    jobs, err := s.sr.ListRoutineLoad(ctx)
+    if err != nil { return nil, fmt.Errorf("list routine load: %w", err) }
+    pipes, err := s.pipelines.ListWithStates(JobStates(jobs))
+    if err != nil { return nil, err }
+    var warnings []string
+    tables, err := s.sr.ListTables(ctx)
+    if err != nil { warnings = append(warnings, fmt.Sprintf("list tables: %v", err)) }
+    mvs, err := s.sr.ListMaterializedViews(ctx)
+    if err != nil { warnings = append(warnings, fmt.Sprintf("list materialized views: %v", err)) }
+
+    b := &lineageBuilder{nodes: map[string]*LineageNode{}, edges: map[LineageEdge]bool{}}
+    byJob := make(map[string]int, len(pipes))
+    for i, p := range pipes { byJob[p.JobName] = i }
+
+    for _, j := range jobs {
+        jn := b.node("job", j.Name)
+        jn.State = j.State
+        topic := j.Topic
+        if i, ok := byJob[j.Name]; ok {
+            jn.Pipeline = pipes[i].Name
+            if topic == "" { topic = pipes[i].SourceTopic }
+        }
+        if topic != "" { b.edge(b.node("topic", topic), jn) }
+        if j.Table != "" { b.edge(jn, b.node("table", j.Table)) }
+    }
+    // 作业已丢失的管道按定义补齐节点，便于看出断开的位置
+    for _, p := range pipes {
+        if p.Status != "MISSING" { continue }
+        jn := b.node("job", p.JobName)
+        jn.State, jn.Pipeline = p.Status, p.Name
+        b.edge(b.node("topic", p.SourceTopic), jn)
+        b.edge(jn, b.node("table", p.TargetTable))
+    }
+
+    known := make(map[string]bool, len(tables)+len(mvs))
+    for _, t := range tables { known[t.Name] = true }
+    isMV := make(map[string]bool, len(mvs))
+    for _, mv := range mvs { known[mv.Name], isMV[mv.Name] = true, true }
+    for _, mv := range mvs {
+        mn := b.node("mv", mv.Name)
+        mn.State = mv.Active
+        for _, base := range mvBaseTables(mv.Definition, known) {
+            if base == mv.Name { continue }
+            typ := "table"
+            if isMV[base] { typ = "mv" }
+            b.edge(b.node(typ, base), mn)
+        }
+    }
+    // 表元数据可用时，标记作业指向但库中不存在的表
+    if tables != nil {
+        for _, n := range b.nodes {
+            if n.Type == "table" && !known[n.Name] { n.State = "MISSING" }
+        }
+    }
+    g := b.graph()
+    g.Warnings = warnings
+    return g, nil
+}
+
+// Around 返回包含指定节点全部上游与下游的子图，用于评估暂停作业或修改表的影响范围
+func (g *LineageGraph) Around(id string) (*LineageGraph, error) {
+    found := false
+    for _, n := range g.Nodes {
+        if n.ID == id { found = true; break }
+    }
+    if !found { return nil, fmt.Errorf("lineage node %s: %w", id, utils.ErrNotFound) }
+    down := map[string][]string{}
+    up := map[string][]string{}
+    for _, e := range g.Edges {
+        down[e.From] = append(down[e.From], e.To)
+        up[e.To] = append(up[e.To], e.From)
+    }
+    keep := map[string]bool{id: true}
+    walk := func(adj map[string][]string) {
+        stack := []string{id}
+        for len(stack) > 0 {
+            cur := stack[len(stack)-1]
+            stack = stack[:len(stack)-1]
+            for _, next := range adj[cur] {
+                if keep[next] { continue }
+                keep[next] = true
+                stack = append(stack, next)
+            }
+        }
+    }
+    walk(down)
+    walk(up)
+    out := &LineageGraph{Nodes: []LineageNode{}, Edges: []LineageEdge{}, Warnings: g.Warnings}
+    for _, n := range g.Nodes {
+        if keep[n.ID] { out.Nodes = append(out.Nodes, n) }
+    }
+    for _, e := range g.Edges {
+        if keep[e.From] && keep[e.To] { out.Edges = append(out.Edges, e) }
+    }
+    return out, nil
+}
+
+// ForPipeline 返回单条管道的血缘：以其 Routine Load 作业为中心的上下游
+func (s *LineageService) ForPipeline(ctx context.Context, name string) (*LineageGraph, error) {
+    p, err := s.pipelines.Get(ctx, name)
+    if err != nil { return nil, err }
+    g, err := s.Build(ctx)
+    if err != nil { return nil, err }
+    return g.Around(lineageID("job", p.JobName))
+}
\ No newline at end of file
@@ -0,0 +1,84 @@
+package services
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "event/utils"
+)
+
+func TestMVBaseTables(t *testing.T) {
+    cases := []struct {
+        name  string
+        def   string
+        known map[string]bool
+        want  []string
+    }{
+        {"single table", "SELECT page, count(*) FROM page_views GROUP BY page", nil, []string{"page_views"}},
+        {"qualified and quoted join", "select * from `eventdb`.`orders` o join eventdb.users u on o.uid = u.id join orders x on 1=1", nil, []string{"orders", "users"}},
+        {"extract filtered by known", "SELECT EXTRACT(hour FROM event_time) h FROM page_views", map[string]bool{"page_views": true}, []string{"page_views"}},
+        {"extract without metadata", "SELECT EXTRACT(hour FROM event_time) h FROM page_views", nil, []string{"event_time", "page_views"}},
+    }
+    for _, tc := range cases {
+        if got := mvBaseTables(tc.def, tc.known); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%s: tables = %v, want %v", tc.name, got, tc.want)
+        }
+    }
+}
+
+// testLineage 构造 topic → job → table → mv 链，外加一条不相关的管道
+func testLineage() *LineageGraph {
+    b := &lineageBuilder{nodes: map[string]*LineageNode{}, edges: map[LineageEdge]bool{}}
+    job := b.node("job", "pv_rl")
+    b.edge(b.node("topic", "page_views"), job)
+    b.edge(job, b.node("table", "page_views"))
+    b.edge(b.node("table", "page_views"), b.node("mv", "mv_pv_minute"))
+    b.edge(b.node("mv", "mv_pv_minute"), b.node("mv", "mv_pv_hour"))
+    other := b.node("job", "orders_rl")
+    b.edge(b.node("topic", "orders"), other)
+    b.edge(other, b.node("table", "orders"))
+    return b.graph()
+}
+
+func TestLineageGraphOrder(t *testing.T) {
+    g := testLineage()
+    var ids []string
+    for _, n := range g.Nodes { ids = append(ids, n.ID) }
+    want := []string{"topic:orders", "topic:page_views", "job:orders_rl", "job:pv_rl", "table:orders", "table:page_views", "mv:mv_pv_hour", "mv:mv_pv_minute"}
+    if !reflect.DeepEqual(ids, want) {
+        t.Errorf("nodes = %v, want %v", ids, want)
+    }
+    if len(g.Edges) != 6 || g.Edges[0] != (LineageEdge{From: "job:orders_rl", To: "table:orders"}) {
+        t.Errorf("edges = %v", g.Edges)
+    }
+}
+
+func TestLineageAround(t *testing.T) {
+    g := testLineage()
+    cases := []struct {
+        id    string
+        want  []string
+        edges int
+    }{
+        {"job:pv_rl", []string{"topic:page_views", "job:pv_rl", "table:page_views", "mv:mv_pv_hour", "mv:mv_pv_minute"}, 4},
+        {"mv:mv_pv_minute", []string{"topic:page_views", "job:pv_rl", "table:page_views", "mv:mv_pv_hour", "mv:mv_pv_minute"}, 4},
+        {"table:orders", []string{"topic:orders", "job:orders_rl", "table:orders"}, 2},
+        {"topic:page_views", []string{"topic:page_views", "job:pv_rl", "table:page_views", "mv:mv_pv_hour", "mv:mv_pv_minute"}, 4},
+    }
+    for _, tc := range cases {
+        sub, err := g.Around(tc.id)
+        if err != nil { t.Fatal(err) }
+        var ids []string
+        for _, n := range sub.Nodes { ids = append(ids, n.ID) }
+        if !reflect.DeepEqual(ids, tc.want) {
+            t.Errorf("%s: nodes = %v, want %v", tc.id, ids, tc.want)
+        }
+        if len(sub.Edges) != tc.edges {
+            t.Errorf("%s: edges = %v, want %d", tc.id, sub.Edges, tc.edges)
+        }
+    }
+    if _, err := g.Around("job:missing"); !errors.Is(err, utils.ErrNotFound) {
+        t.Errorf("unknown node: err = %v, want ErrNotFound", err)
+    }
+}
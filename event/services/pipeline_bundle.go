@@ -0,0 +1,151 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "event/api/models"
+    "event/utils"
+    "gopkg.in/yaml.v3"
+)
+
+// bundleFormatVersion 为导出包格式版本，格式不兼容变更时递增
+const bundleFormatVersion = 1
+
+// Bundle 是可在环境间迁移的管道导出包
+type Bundle struct {
+    FormatVersion int           `json:"format_version" yaml:"format_version"`
+    ExportedAt    time.Time     `json:"exported_at" yaml:"exported_at"`
+    SourceEnv     string        `json:"source_env" yaml:"source_env"`
+    Pipelines     []BundleEntry `json:"pipelines" yaml:"pipelines"`
+    Warnings      []string      `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// BundleEntry 包含单条管道的定义，以及源环境中的建表语句、Routine Load 创建语句与主题设置
+// （分区、副本数与主题级覆盖的配置，如 retention.ms、cleanup.policy）；
+// routine_load_sql 仅作对照，导入时按 definition 重建作业以便替换为目标环境的 broker
+type BundleEntry struct {
+    Definition     models.Pipeline `json:"definition" yaml:"definition"`
+    Topic          TopicSpec       `json:"topic" yaml:"topic"`
+    TableDDL       string          `json:"table_ddl" yaml:"table_ddl"`
+    RoutineLoadSQL string          `json:"routine_load_sql,omitempty" yaml:"routine_load_sql,omitempty"`
+}
+
+// ImportOptions 控制导入时的环境差异替换
+type ImportOptions struct {
+    BrokerList string // 非空时覆盖所有作业的 kafka_broker_list
+}
+
+type ImportEntryResult struct {
+    Pipeline string      `json:"pipeline"`
+    Action   string      `json:"action"` // applied/skipped/failed
+    Error    string      `json:"error,omitempty"`
+    Steps    []ApplyStep `json:"steps,omitempty"`
+}
+
+// Export 导出全部管道；单条管道的 DDL/主题信息获取失败时记录告警并继续
+func (s *PipelineService) Export(ctx context.Context) (*Bundle, error) {
+    pipes, err := s.ListWithStates(nil)
+    if err != nil { return nil, err }
+    b := &Bundle{
+        FormatVersion: bundleFormatVersion,
+        ExportedAt:    time.Now().UTC(),
+        SourceEnv:     s.cfg.Server.Env,
+        Pipelines:     make([]BundleEntry, 0, len(pipes)),
+    }
+    for _, p := range pipes {
+        p.Status = ""
+        e := BundleEntry{Definition: p, Topic: TopicSpec{Name: p.SourceTopic}}
+        if spec, err := s.ka.ReadTopicSpec(ctx, p.SourceTopic); err == nil {
+            e.Topic = *spec
+            if cfgs, err := s.ka.TopicConfigs(ctx, p.SourceTopic); err == nil {
+                e.Topic.Configs = topicOverrides(cfgs)
+            } else {
+                b.Warnings = append(b.Warnings, fmt.Sprintf("%s: read topic configs %s: %v", p.Name, p.SourceTopic, err))
+            }
+        } else {
+            b.Warnings = append(b.Warnings, fmt.Sprintf("%s: read topic %s: %v", p.Name, p.SourceTopic, err))
+        }
+        if ddl, err := s.sr.ShowCreateTable(ctx, p.TargetTable); err == nil {
+            e.TableDDL = ddl
+        } else {
+            b.Warnings = append(b.Warnings, fmt.Sprintf("%s: show create table %s: %v", p.Name, p.TargetTable, err))
+        }
+        if d, err := s.sr.GetRoutineLoadDetails(ctx, p.JobName); err == nil {
+            e.RoutineLoadSQL = d.CreateSQL
+        } else {
+            b.Warnings = append(b.Warnings, fmt.Sprintf("%s: routine load %s: %v", p.Name, p.JobName, err))
+        }
+        b.Pipelines = append(b.Pipelines, e)
+    }
+    return b, nil
+}
+
+// topicOverrides 返回主题级覆盖的配置项；默认值、只读与敏感配置不导出
+func topicOverrides(cfgs []TopicConfigEntry) map[string]string {
+    out := map[string]string{}
+    for _, c := range cfgs {
+        if c.Default || c.ReadOnly || c.Sensitive { continue }
+        out[c.Name] = c.Value
+    }
+    if len(out) == 0 { return nil }
+    return out
+}
+
+// ParseBundle 解析 YAML 或 JSON 格式的导出包
+func ParseBundle(data []byte) (*Bundle, error) {
+    var b Bundle
+    if err := yaml.Unmarshal(data, &b); err != nil {
+        return nil, fmt.Errorf("%w: parse bundle: %v", utils.ErrInvalid, err)
+    }
+    if b.FormatVersion != bundleFormatVersion {
+        return nil, fmt.Errorf("%w: unsupported bundle format_version %d", utils.ErrInvalid, b.FormatVersion)
+    }
+    return &b, nil
+}
+
+// Import 逐条回放导出包：每条管道按 主题 → 表 → Routine Load → 登记 的顺序应用，
+// 单条失败只回滚该条；目标环境已存在的同名管道跳过
+func (s *PipelineService) Import(ctx context.Context, b Bundle, opts ImportOptions) []ImportEntryResult {
+    out := make([]ImportEntryResult, 0, len(b.Pipelines))
+    for _, e := range b.Pipelines {
+        def := e.Definition
+        r := ImportEntryResult{Pipeline: def.Name}
+        if strings.TrimSpace(e.TableDDL) == "" {
+            r.Action, r.Error = "failed", "bundle entry has no table_ddl"
+            out = append(out, r)
+            continue
+        }
+        if opts.BrokerList != "" { def.BrokerList = opts.BrokerList }
+        topic := e.Topic
+        topic.Name = def.SourceTopic
+        spec := PipelineSpec{
+            Name:         def.Name,
+            Owner:        def.Owner,
+            Team:         def.Team,
+            Description:  def.Description,
+            Labels:       def.Labels,
+            SLA:          def.SLA,
+            DesiredState: def.DesiredState,
+            Topic:        topic,
+            Table:        TableSpec{Name: def.TargetTable, DDL: e.TableDDL},
+            RoutineLoad:  s.RoutineLoadRequest(def),
+        }
+        res, err := s.Apply(ctx, spec)
+        switch {
+        case errors.Is(err, utils.ErrConflict):
+            r.Action, r.Error = "skipped", err.Error()
+        case err != nil:
+            r.Action, r.Error = "failed", err.Error()
+        case !res.OK:
+            r.Action, r.Steps = "failed", res.Steps
+        default:
+            r.Action, r.Steps = "applied", res.Steps
+        }
+        out = append(out, r)
+    }
+    return out
+}
\ No newline at end of file
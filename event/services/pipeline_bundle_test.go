@@ -0,0 +1,68 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "testing"
+
+    "event/api/models"
+    "event/utils"
+)
+
+func TestParseBundle(t *testing.T) {
+    cases := []struct {
+        name    string
+        data    string
+        entries int
+        invalid bool
+    }{
+        {"yaml", "format_version: 1\nsource_env: prod\npipelines:\n  - definition: {name: clicks, source_topic: clicks, target_table: clicks}\n    table_ddl: CREATE TABLE clicks (id BIGINT)\n", 1, false},
+        {"json", `{"format_version": 1, "pipelines": [{"definition": {"name": "a"}}, {"definition": {"name": "b"}}]}`, 2, false},
+        {"missing version", "pipelines: []\n", 0, true},
+        {"future version", `{"format_version": 2, "pipelines": []}`, 0, true},
+        {"malformed", "format_version: [1\n", 0, true},
+    }
+    for _, tc := range cases {
+        b, err := ParseBundle([]byte(tc.data))
+        if tc.invalid {
+            if !errors.Is(err, utils.ErrInvalid) { t.Errorf("%s: err = %v, want ErrInvalid", tc.name, err) }
+            continue
+        }
+        if err != nil || len(b.Pipelines) != tc.entries {
+            t.Errorf("%s: bundle = %+v, err %v", tc.name, b, err)
+        }
+    }
+}
+
+func TestTopicOverrides(t *testing.T) {
+    cfgs := []TopicConfigEntry{
+        {Name: "retention.ms", Value: "86400000"},
+        {Name: "cleanup.policy", Value: "delete", Default: true},
+        {Name: "message.format.version", Value: "3.0", ReadOnly: true},
+        {Name: "sasl.jaas.config", Value: "secret", Sensitive: true},
+        {Name: "max.message.bytes", Value: "2097152"},
+    }
+    want := map[string]string{"retention.ms": "86400000", "max.message.bytes": "2097152"}
+    if got := topicOverrides(cfgs); !reflect.DeepEqual(got, want) {
+        t.Errorf("overrides = %v, want %v", got, want)
+    }
+    if got := topicOverrides(cfgs[1:4]); got != nil {
+        t.Errorf("only defaults: overrides = %v, want nil", got)
+    }
+}
+
+func TestImportSkipsAndFailsWithoutProvisioning(t *testing.T) {
+    s := newTestService(t, models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks"})
+    b := Bundle{FormatVersion: bundleFormatVersion, Pipelines: []BundleEntry{
+        {Definition: models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", JobName: "clicks_rl"}, TableDDL: "CREATE TABLE clicks (id BIGINT)"},
+        {Definition: models.Pipeline{Name: "views", SourceTopic: "views", TargetTable: "views", JobName: "views_rl"}},
+    }}
+    got := s.Import(context.Background(), b, ImportOptions{BrokerList: "kafka-b:9092"})
+    if len(got) != 2 || got[0].Action != "skipped" || got[1].Action != "failed" || got[1].Error != "bundle entry has no table_ddl" {
+        t.Fatalf("import = %+v", got)
+    }
+    if pipes, _ := s.ListWithStates(nil); len(pipes) != 1 {
+        t.Errorf("pipelines after import = %d, want 1", len(pipes))
+    }
+}
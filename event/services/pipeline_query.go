@@ -0,0 +1,102 @@
+package services
+
+import (
+    "fmt"
+    "strings"
+
+    "event/api/models"
+    "event/utils"
+)
+
+// selectorReq 是选择器中的单个条件
+type selectorReq struct {
+    key   string
+    op    string // =/!=/exists/!exists
+    value string
+}
+
+// Selector 为标签选择器，形如 team=growth,tier!=bronze,critical,!deprecated，各条件之间为与关系
+type Selector []selectorReq
+
+// ParseSelector 解析标签选择器；空串表示不过滤
+func ParseSelector(s string) (Selector, error) {
+    var out Selector
+    for _, part := range strings.Split(s, ",") {
+        part = strings.TrimSpace(part)
+        if part == "" { continue }
+        var req selectorReq
+        switch {
+        case strings.Contains(part, "!="):
+            k, v, _ := strings.Cut(part, "!=")
+            req = selectorReq{key: strings.TrimSpace(k), op: "!=", value: strings.TrimSpace(v)}
+        case strings.Contains(part, "="):
+            k, v, _ := strings.Cut(part, "=")
+            req = selectorReq{key: strings.TrimSpace(k), op: "=", value: strings.TrimPrefix(strings.TrimSpace(v), "=")}
+        case strings.HasPrefix(part, "!"):
+            req = selectorReq{key: strings.TrimSpace(part[1:]), op: "!exists"}
+        default:
+            req = selectorReq{key: part, op: "exists"}
+        }
+        if !utils.ValidLabel(req.key, req.value) {
+            return nil, fmt.Errorf("%w: invalid selector %q", utils.ErrInvalid, part)
+        }
+        out = append(out, req)
+    }
+    return out, nil
+}
+
+// Matches 判断标签集合是否满足全部条件
+func (sel Selector) Matches(labels map[string]string) bool {
+    for _, req := range sel {
+        v, ok := labels[req.key]
+        switch req.op {
+        case "=":
+            if !ok || v != req.value { return false }
+        case "!=":
+            if ok && v == req.value { return false }
+        case "exists":
+            if !ok { return false }
+        case "!exists":
+            if ok { return false }
+        }
+    }
+    return true
+}
+
+// pipelineLabels 返回用于选择器匹配的标签：显式标签优先，team 与 owner 字段作为同名隐式标签
+func pipelineLabels(p models.Pipeline) map[string]string {
+    out := make(map[string]string, len(p.Labels)+2)
+    if p.Team != "" { out["team"] = p.Team }
+    if p.Owner != "" { out["owner"] = p.Owner }
+    for k, v := range p.Labels { out[k] = v }
+    return out
+}
+
+// matchesQuery 对名称、描述、负责人、团队、主题、表、作业名与标签做不区分大小写的子串匹配；
+// 查询串按空白拆分后每个词都须命中
+func matchesQuery(p models.Pipeline, q string) bool {
+    fields := []string{p.Name, p.Description, p.Owner, p.Team, p.SourceTopic, p.TargetTable, p.JobName}
+    for k, v := range p.Labels { fields = append(fields, k+"="+v) }
+    text := strings.ToLower(strings.Join(fields, "\n"))
+    for _, word := range strings.Fields(strings.ToLower(q)) {
+        if !strings.Contains(text, word) { return false }
+    }
+    return true
+}
+
+// PipelineFilter 为管道列表的过滤条件
+type PipelineFilter struct {
+    Selector Selector
+    Query    string
+}
+
+// FilterPipelines 按选择器与全文查询过滤管道，保持原有顺序
+func FilterPipelines(pipes []models.Pipeline, f PipelineFilter) []models.Pipeline {
+    out := make([]models.Pipeline, 0, len(pipes))
+    for _, p := range pipes {
+        if len(f.Selector) > 0 && !f.Selector.Matches(pipelineLabels(p)) { continue }
+        if f.Query != "" && !matchesQuery(p, f.Query) { continue }
+        out = append(out, p)
+    }
+    return out
+}
\ No newline at end of file
@@ -0,0 +1,75 @@
+package services
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "event/api/models"
+    "event/utils"
+)
+
+func TestParseSelector(t *testing.T) {
+    cases := []struct {
+        in      string
+        want    Selector
+        invalid bool
+    }{
+        {"", nil, false},
+        {" team = growth , tier!=bronze,critical,!deprecated,", Selector{
+            {key: "team", op: "=", value: "growth"},
+            {key: "tier", op: "!=", value: "bronze"},
+            {key: "critical", op: "exists"},
+            {key: "deprecated", op: "!exists"},
+        }, false},
+        {"team==growth", Selector{{key: "team", op: "=", value: "growth"}}, false},
+        {"team=a b", nil, true},
+        {"=growth", nil, true},
+        {"!", nil, true},
+    }
+    for _, tc := range cases {
+        got, err := ParseSelector(tc.in)
+        if tc.invalid {
+            if !errors.Is(err, utils.ErrInvalid) { t.Errorf("%q: err = %v, want ErrInvalid", tc.in, err) }
+            continue
+        }
+        if err != nil || !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%q: selector = %+v, err %v, want %+v", tc.in, got, err, tc.want)
+        }
+    }
+}
+
+func TestFilterPipelines(t *testing.T) {
+    pipes := []models.Pipeline{
+        {Name: "clicks", Team: "growth", Owner: "alice", SourceTopic: "web_clicks", Labels: map[string]string{"tier": "gold", "critical": "true"}},
+        {Name: "orders", Team: "payments", Owner: "bob", Description: "Order events from checkout", Labels: map[string]string{"tier": "bronze"}},
+        {Name: "legacy", Team: "growth", Labels: map[string]string{"deprecated": "true", "team": "archive"}},
+    }
+    cases := []struct {
+        selector string
+        query    string
+        want     []string
+    }{
+        {"", "", []string{"clicks", "orders", "legacy"}},
+        {"team=growth", "", []string{"clicks"}},
+        {"team=archive", "", []string{"legacy"}},
+        {"tier!=bronze", "", []string{"clicks", "legacy"}},
+        {"owner", "", []string{"clicks", "orders"}},
+        {"!deprecated,tier", "", []string{"clicks", "orders"}},
+        {"", "CHECKOUT order", []string{"orders"}},
+        {"", "tier=gold", []string{"clicks"}},
+        {"", "web alice", []string{"clicks"}},
+        {"team=payments", "clicks", []string{}},
+    }
+    for _, tc := range cases {
+        sel, err := ParseSelector(tc.selector)
+        if err != nil { t.Fatal(err) }
+        got := []string{}
+        for _, p := range FilterPipelines(pipes, PipelineFilter{Selector: sel, Query: tc.query}) {
+            got = append(got, p.Name)
+        }
+        if !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("selector %q query %q: got %v, want %v", tc.selector, tc.query, got, tc.want)
+        }
+    }
+}
@@ -1,3 +1,242 @@
 package services
 
-// 预留：管道编排与收敛逻辑
\ No newline at end of file
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "event/utils"
+)
+
+// PipelineService 管理管道定义（持久化于 Store），并结合 Routine Load 状态给出实时视图
+type PipelineService struct {
+    cfg   config.Config
+    store *Store
+    sr    *StarRocksClient
+    ka    *KafkaAdmin
+}
+
+func NewPipelineService(cfg config.Config, store *Store, sr *StarRocksClient) *PipelineService {
+    return &PipelineService{cfg: cfg, store: store, sr: sr, ka: NewKafkaAdmin(cfg)}
+}
+
+// JobStates 将作业列表转换为 作业名 → 状态 的映射
+func JobStates(jobs []RLJob) map[string]string {
+    m := make(map[string]string, len(jobs))
+    for _, j := range jobs { m[j.Name] = strings.ToUpper(strings.TrimSpace(j.State)) }
+    return m
+}
+
+// List 返回全部管道，Status 取自对应 Routine Load 的实时状态
+func (s *PipelineService) List(ctx context.Context) ([]models.Pipeline, error) {
+    var states map[string]string
+    if jobs, err := s.sr.ListRoutineLoad(ctx); err == nil {
+        states = JobStates(jobs)
+    }
+    return s.ListWithStates(states)
+}
+
+// ListWithStates 使用调用方已获取的作业状态填充 Status；states 为 nil 表示状态未知
+func (s *PipelineService) ListWithStates(states map[string]string) ([]models.Pipeline, error) {
+    var out []models.Pipeline
+    err := s.store.View(func(d *storeData) error {
+        out = make([]models.Pipeline, 0, len(d.Pipelines))
+        for _, p := range d.Pipelines { out = append(out, p) }
+        return nil
+    })
+    if err != nil { return nil, err }
+    sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+    for i := range out { out[i].Status = pipelineStatus(out[i], states) }
+    return out, nil
+}
+
+func pipelineStatus(p models.Pipeline, states map[string]string) string {
+    if states == nil { return "UNKNOWN" }
+    st, ok := states[p.JobName]
+    if !ok { return "MISSING" }
+    if st == "" { return "UNKNOWN" }
+    return st
+}
+
+// Get 返回单个管道定义
+func (s *PipelineService) Get(ctx context.Context, name string) (*models.Pipeline, error) {
+    var p models.Pipeline
+    var found bool
+    _ = s.store.View(func(d *storeData) error {
+        p, found = d.Pipelines[name]
+        return nil
+    })
+    if !found { return nil, fmt.Errorf("pipeline %s: %w", name, utils.ErrNotFound) }
+    var states map[string]string
+    if jobs, err := s.sr.ListRoutineLoad(ctx); err == nil {
+        states = JobStates(jobs)
+    }
+    p.Status = pipelineStatus(p, states)
+    return &p, nil
+}
+
+// normalizePipeline 规范化并校验管道定义
+func normalizePipeline(p *models.Pipeline) error {
+    p.Name = strings.TrimSpace(p.Name)
+    p.SourceTopic = strings.TrimSpace(p.SourceTopic)
+    p.TargetTable = strings.TrimSpace(p.TargetTable)
+    p.JobName = strings.TrimSpace(p.JobName)
+    p.Owner = strings.TrimSpace(p.Owner)
+    p.Team = strings.TrimSpace(p.Team)
+    p.Description = strings.TrimSpace(p.Description)
+    p.BrokerList = strings.TrimSpace(p.BrokerList)
+    p.GroupID = strings.TrimSpace(p.GroupID)
+    if p.JobName == "" { p.JobName = p.Name + "_rl" }
+    p.DesiredState = strings.ToUpper(strings.TrimSpace(p.DesiredState))
+    if p.DesiredState == "" { p.DesiredState = "RUNNING" }
+    switch p.DesiredState {
+    case "RUNNING", "PAUSED", "STOPPED":
+    default:
+        return fmt.Errorf("%w: desired_state must be RUNNING, PAUSED or STOPPED", utils.ErrInvalid)
+    }
+    if !utils.ValidIdentifier(p.Name) {
+        return fmt.Errorf("%w: invalid name %q", utils.ErrInvalid, p.Name)
+    }
+    if !utils.ValidTopicName(p.SourceTopic) {
+        return fmt.Errorf("%w: invalid source_topic %q", utils.ErrInvalid, p.SourceTopic)
+    }
+    if !utils.ValidIdentifier(p.TargetTable) {
+        return fmt.Errorf("%w: invalid target_table %q", utils.ErrInvalid, p.TargetTable)
+    }
+    if !utils.ValidIdentifier(p.JobName) {
+        return fmt.Errorf("%w: invalid job_name %q", utils.ErrInvalid, p.JobName)
+    }
+    for k, v := range p.Labels {
+        if !utils.ValidLabel(k, v) {
+            return fmt.Errorf("%w: invalid label %q=%q", utils.ErrInvalid, k, v)
+        }
+    }
+    if p.SLA.FreshnessSec < 0 || p.SLA.MaxLagMessages < 0 {
+        return fmt.Errorf("%w: sla thresholds must not be negative", utils.ErrInvalid)
+    }
+    // Status 为运行期字段，不落盘
+    p.Status = ""
+    return nil
+}
+
+// RoutineLoadRequest 由管道定义还原 CREATE ROUTINE LOAD 请求，未填写的 broker 与消费组使用默认值
+func (s *PipelineService) RoutineLoadRequest(p models.Pipeline) RLCreateRequest {
+    brokers := p.BrokerList
+    if brokers == "" { brokers = strings.Join(s.cfg.Kafka.Brokers, ",") }
+    group := p.GroupID
+    if group == "" { group = defaultGroupID(p.SourceTopic) }
+    return RLCreateRequest{
+        Name:       p.JobName,
+        Table:      p.TargetTable,
+        Kafka:      KafkaSource{BrokerList: brokers, Topic: p.SourceTopic, GroupID: group},
+        Columns:    p.Columns,
+        Set:        p.Set,
+        Properties: p.Properties,
+    }
+}
+
+// newPipelineConflict 检查新管道的名称或作业名是否已被占用，占用时返回 ErrConflict
+func newPipelineConflict(d *storeData, name, job string) error {
+    if _, ok := d.Pipelines[name]; ok {
+        return fmt.Errorf("pipeline %s: %w", name, utils.ErrConflict)
+    }
+    for _, other := range d.Pipelines {
+        if other.JobName == job {
+            return fmt.Errorf("job %s already used by pipeline %s: %w", job, other.Name, utils.ErrConflict)
+        }
+    }
+    return nil
+}
+
+// Create 新建管道定义，名称或作业名重复时返回 ErrConflict
+func (s *PipelineService) Create(ctx context.Context, p models.Pipeline) (*models.Pipeline, error) {
+    if err := normalizePipeline(&p); err != nil { return nil, err }
+    now := time.Now().UTC()
+    p.CreatedAt, p.UpdatedAt = now, now
+    err := s.store.Update(func(d *storeData) error {
+        if err := newPipelineConflict(d, p.Name, p.JobName); err != nil { return err }
+        d.Pipelines[p.Name] = p
+        appendVersion(ctx, d, p, "create", "", nil)
+        return nil
+    })
+    if err != nil { return nil, err }
+    return &p, nil
+}
+
+// Update 整体替换管道定义（名称不可修改），保留创建时间并记录新版本
+func (s *PipelineService) Update(ctx context.Context, name string, p models.Pipeline) (*models.Pipeline, error) {
+    p.Name = name
+    if err := normalizePipeline(&p); err != nil { return nil, err }
+    err := s.store.Update(func(d *storeData) error {
+        old, ok := d.Pipelines[name]
+        if !ok { return fmt.Errorf("pipeline %s: %w", name, utils.ErrNotFound) }
+        for _, other := range d.Pipelines {
+            if other.Name != name && other.JobName == p.JobName {
+                return fmt.Errorf("job %s already used by pipeline %s: %w", p.JobName, other.Name, utils.ErrConflict)
+            }
+        }
+        p.CreatedAt = old.CreatedAt
+        p.UpdatedAt = time.Now().UTC()
+        d.Pipelines[name] = p
+        appendVersion(ctx, d, p, "update", "", old.Properties)
+        return nil
+    })
+    if err != nil { return nil, err }
+    return &p, nil
+}
+
+// Delete 删除管道定义（不会停止对应的 Routine Load）
+func (s *PipelineService) Delete(ctx context.Context, name string) error {
+    return s.store.Update(func(d *storeData) error {
+        if _, ok := d.Pipelines[name]; !ok {
+            return fmt.Errorf("pipeline %s: %w", name, utils.ErrNotFound)
+        }
+        delete(d.Pipelines, name)
+        // 计划窗口随管道一起删除，运行记录保留
+        for id, sc := range d.Schedules {
+            if sc.Pipeline == name { delete(d.Schedules, id) }
+        }
+        delete(d.SchedulePauses, name)
+        return nil
+    })
+}
+
+// SetDesiredStateByJob 在通过 API 手动暂停/恢复/停止作业后同步管道的期望状态，
+// 避免收敛循环把有意暂停的作业恢复；作业不属于任何管道时不做处理
+func (s *PipelineService) SetDesiredStateByJob(jobName, state string) error {
+    return s.store.Update(func(d *storeData) error {
+        for name, p := range d.Pipelines {
+            if p.JobName != jobName || p.DesiredState == state { continue }
+            p.DesiredState = state
+            p.UpdatedAt = time.Now().UTC()
+            d.Pipelines[name] = p
+        }
+        return nil
+    })
+}
+
+// TopicConsumers 返回以指定主题为数据源的管道，以及仍在消费该主题（未停止）的 Routine Load 作业，
+// 用于删除主题前检查影响范围
+func (s *PipelineService) TopicConsumers(ctx context.Context, topic string) ([]string, []string, error) {
+    var pipes []string
+    _ = s.store.View(func(d *storeData) error {
+        for _, p := range d.Pipelines {
+            if p.SourceTopic == topic { pipes = append(pipes, p.Name) }
+        }
+        return nil
+    })
+    sort.Strings(pipes)
+    rls, err := s.sr.ListRoutineLoad(ctx)
+    if err != nil { return pipes, nil, fmt.Errorf("list routine load: %w", err) }
+    var jobs []string
+    for _, j := range rls {
+        st := strings.ToUpper(strings.TrimSpace(j.State))
+        if j.Topic == topic && st != "STOPPED" && st != "CANCELLED" { jobs = append(jobs, j.Name) }
+    }
+    sort.Strings(jobs)
+    return pipes, jobs, nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "event/api/models"
+    "event/config"
+    "event/utils"
+)
+
+func TestNormalizePipeline(t *testing.T) {
+    cases := []struct {
+        name    string
+        p       models.Pipeline
+        job     string
+        desired string
+        err     bool
+    }{
+        {"defaults", models.Pipeline{Name: " clicks ", SourceTopic: "clicks", TargetTable: "clicks"}, "clicks_rl", "RUNNING", false},
+        {"explicit", models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", JobName: "c_rl", DesiredState: "paused"}, "c_rl", "PAUSED", false},
+        {"bad desired state", models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", DesiredState: "gone"}, "", "", true},
+        {"bad name", models.Pipeline{Name: "a b", SourceTopic: "clicks", TargetTable: "clicks"}, "", "", true},
+        {"bad topic", models.Pipeline{Name: "clicks", SourceTopic: "a b", TargetTable: "clicks"}, "", "", true},
+        {"bad table", models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "a-b"}, "", "", true},
+        {"bad label", models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", Labels: map[string]string{"a b": "x"}}, "", "", true},
+        {"negative sla", models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", SLA: models.PipelineSLA{FreshnessSec: -1}}, "", "", true},
+    }
+    for _, tc := range cases {
+        p := tc.p
+        err := normalizePipeline(&p)
+        if tc.err {
+            if !errors.Is(err, utils.ErrInvalid) { t.Errorf("%s: err = %v, want ErrInvalid", tc.name, err) }
+            continue
+        }
+        if err != nil || p.JobName != tc.job || p.DesiredState != tc.desired || p.Name != "clicks" {
+            t.Errorf("%s: got %+v, err %v", tc.name, p, err)
+        }
+    }
+}
+
+func TestPipelineCRUD(t *testing.T) {
+    ctx := context.Background()
+    s := newTestService(t, models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks"})
+
+    if _, err := s.Create(ctx, models.Pipeline{Name: "clicks", SourceTopic: "x", TargetTable: "x"}); !errors.Is(err, utils.ErrConflict) {
+        t.Errorf("duplicate name: err = %v, want ErrConflict", err)
+    }
+    if _, err := s.Create(ctx, models.Pipeline{Name: "views", SourceTopic: "views", TargetTable: "views", JobName: "clicks_rl"}); !errors.Is(err, utils.ErrConflict) {
+        t.Errorf("duplicate job: err = %v, want ErrConflict", err)
+    }
+    if _, err := s.Create(ctx, models.Pipeline{Name: "views", SourceTopic: "views", TargetTable: "views"}); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := s.Update(ctx, "views", models.Pipeline{SourceTopic: "views", TargetTable: "views", JobName: "clicks_rl"}); !errors.Is(err, utils.ErrConflict) {
+        t.Errorf("update to a used job: err = %v, want ErrConflict", err)
+    }
+    if _, err := s.Update(ctx, "missing", models.Pipeline{SourceTopic: "x", TargetTable: "x"}); !errors.Is(err, utils.ErrNotFound) {
+        t.Errorf("update missing: err = %v, want ErrNotFound", err)
+    }
+    up, err := s.Update(ctx, "views", models.Pipeline{SourceTopic: "views", TargetTable: "views_v2", Owner: "bob"})
+    if err != nil || up.TargetTable != "views_v2" || up.CreatedAt.IsZero() {
+        t.Fatalf("update: %+v, err %v", up, err)
+    }
+
+    pipes, err := s.ListWithStates(map[string]string{"clicks_rl": "PAUSED", "views_rl": ""})
+    if err != nil || len(pipes) != 2 || pipes[0].Name != "clicks" || pipes[0].Status != "PAUSED" || pipes[1].Status != "UNKNOWN" {
+        t.Fatalf("list = %+v, err %v", pipes, err)
+    }
+    if pipes, _ := s.ListWithStates(map[string]string{}); pipes[0].Status != "MISSING" {
+        t.Errorf("status without job = %s, want MISSING", pipes[0].Status)
+    }
+    if pipes, _ := s.ListWithStates(nil); pipes[0].Status != "UNKNOWN" {
+        t.Errorf("status with unknown states = %s, want UNKNOWN", pipes[0].Status)
+    }
+
+    if err := s.SetDesiredStateByJob("clicks_rl", "PAUSED"); err != nil { t.Fatal(err) }
+    if err := s.Delete(ctx, "views"); err != nil { t.Fatal(err) }
+    if err := s.Delete(ctx, "views"); !errors.Is(err, utils.ErrNotFound) {
+        t.Errorf("delete twice: err = %v, want ErrNotFound", err)
+    }
+
+    // 重新打开同一目录，定义已落盘
+    store, err := NewStore(config.Config{Storage: config.StorageConfig{DataDir: s.cfg.Storage.DataDir}})
+    if err != nil { t.Fatal(err) }
+    reopened, err := NewPipelineService(s.cfg, store, nil).ListWithStates(nil)
+    if err != nil || len(reopened) != 1 || reopened[0].Name != "clicks" || reopened[0].DesiredState != "PAUSED" {
+        t.Errorf("reopened = %+v, err %v", reopened, err)
+    }
+}
+
+func TestRoutineLoadRequestDefaults(t *testing.T) {
+    s := newTestService(t)
+    rl := s.RoutineLoadRequest(models.Pipeline{Name: "clicks", SourceTopic: "web_clicks", TargetTable: "clicks", JobName: "clicks_rl"})
+    if rl.Kafka.BrokerList != "127.0.0.1:1" || rl.Kafka.GroupID != defaultGroupID("web_clicks") || rl.Kafka.GroupID != "sr-web_clicks" {
+        t.Errorf("defaults = %+v", rl.Kafka)
+    }
+    rl = s.RoutineLoadRequest(models.Pipeline{SourceTopic: "web_clicks", BrokerList: "k:9092", GroupID: "g1"})
+    if rl.Kafka.BrokerList != "k:9092" || rl.Kafka.GroupID != "g1" {
+        t.Errorf("explicit = %+v", rl.Kafka)
+    }
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+
+    "event/api/models"
+    "event/utils"
+    "gopkg.in/yaml.v3"
+)
+
+// PipelineSpec 是声明式的管道描述：一次提交即可依次创建主题、建表并创建 Routine Load
+type PipelineSpec struct {
+    Name         string             `json:"name" yaml:"name"`
+    Owner        string             `json:"owner,omitempty" yaml:"owner,omitempty"`
+    Team         string             `json:"team,omitempty" yaml:"team,omitempty"`
+    Description  string             `json:"description,omitempty" yaml:"description,omitempty"`
+    Labels       map[string]string  `json:"labels,omitempty" yaml:"labels,omitempty"`
+    SLA          models.PipelineSLA `json:"sla" yaml:"sla"`
+    DesiredState string             `json:"desired_state,omitempty" yaml:"desired_state,omitempty"` // 缺省 RUNNING；PAUSED/STOPPED 时作业创建后立即暂停
+    Topic        TopicSpec          `json:"topic" yaml:"topic"`
+    Table        TableSpec          `json:"table" yaml:"table"`
+    RoutineLoad  RLCreateRequest    `json:"routine_load" yaml:"routine_load"`
+}
+
+// ParsePipelineSpec 解析 YAML 或 JSON 格式的管道描述（JSON 是 YAML 的子集）
+func ParsePipelineSpec(b []byte) (*PipelineSpec, error) {
+    var spec PipelineSpec
+    if err := yaml.Unmarshal(b, &spec); err != nil {
+        return nil, fmt.Errorf("%w: parse spec: %v", utils.ErrInvalid, err)
+    }
+    return &spec, nil
+}
+
+// ApplyStep 记录声明式创建过程中每一步的执行结果
+type ApplyStep struct {
+    Step   string `json:"step"`   // topic/table/routine_load/pause/register
+    Target string `json:"target"`
+    Action string `json:"action"` // created/exists/failed/rolled_back/rollback_failed
+    Error  string `json:"error,omitempty"`
+}
+
+type ApplyResult struct {
+    OK       bool             `json:"ok"`
+    Pipeline *models.Pipeline `json:"pipeline,omitempty"`
+    Steps    []ApplyStep      `json:"steps"`
+}
+
+// normalize 补全默认值：作业名、目标表、主题、消费组与 broker 列表均可从其他字段推导
+func (spec *PipelineSpec) normalize(defaultBrokers []string) error {
+    spec.Name = strings.TrimSpace(spec.Name)
+    if !utils.ValidIdentifier(spec.Name) {
+        return fmt.Errorf("%w: invalid name %q", utils.ErrInvalid, spec.Name)
+    }
+    rl := &spec.RoutineLoad
+    spec.DesiredState = strings.ToUpper(strings.TrimSpace(spec.DesiredState))
+    switch spec.DesiredState {
+    case "", "RUNNING", "PAUSED", "STOPPED":
+    default:
+        return fmt.Errorf("%w: desired_state must be RUNNING, PAUSED or STOPPED", utils.ErrInvalid)
+    }
+    if strings.TrimSpace(rl.Name) == "" { rl.Name = spec.Name + "_rl" }
+    if spec.Table.Name == "" { spec.Table.Name = rl.Table }
+    if rl.Table == "" { rl.Table = spec.Table.Name }
+    if spec.Topic.Name == "" { spec.Topic.Name = rl.Kafka.Topic }
+    if rl.Kafka.Topic == "" { rl.Kafka.Topic = spec.Topic.Name }
+    if rl.Kafka.GroupID == "" { rl.Kafka.GroupID = defaultGroupID(rl.Kafka.Topic) }
+    if rl.Kafka.BrokerList == "" { rl.Kafka.BrokerList = strings.Join(defaultBrokers, ",") }
+    if rl.Table != spec.Table.Name {
+        return fmt.Errorf("%w: routine_load.table %s differs from table.name %s", utils.ErrInvalid, rl.Table, spec.Table.Name)
+    }
+    if rl.Kafka.Topic != spec.Topic.Name {
+        return fmt.Errorf("%w: routine_load.kafka.topic %s differs from topic.name %s", utils.ErrInvalid, rl.Kafka.Topic, spec.Topic.Name)
+    }
+    if !utils.ValidIdentifier(rl.Name) {
+        return fmt.Errorf("%w: invalid routine_load.name %q", utils.ErrInvalid, rl.Name)
+    }
+    if !utils.ValidIdentifier(spec.Table.Name) {
+        return fmt.Errorf("%w: invalid table name %q", utils.ErrInvalid, spec.Table.Name)
+    }
+    if !utils.ValidTopicName(spec.Topic.Name) {
+        return fmt.Errorf("%w: invalid topic name %q", utils.ErrInvalid, spec.Topic.Name)
+    }
+    // 结构化建表时提前校验 DDL，避免创建主题后才发现表定义有误
+    if _, err := spec.Table.BuildDDL(); err != nil { return err }
+    return nil
+}
+
+// pipelineFromSpec 由描述生成需持久化的管道定义
+func pipelineFromSpec(spec PipelineSpec) models.Pipeline {
+    rl := spec.RoutineLoad
+    return models.Pipeline{
+        Name:         spec.Name,
+        Owner:        spec.Owner,
+        Team:         spec.Team,
+        Description:  spec.Description,
+        Labels:       spec.Labels,
+        SLA:          spec.SLA,
+        SourceTopic:  rl.Kafka.Topic,
+        TargetTable:  rl.Table,
+        Columns:      rl.Columns,
+        Set:          rl.Set,
+        JobName:      rl.Name,
+        BrokerList:   rl.Kafka.BrokerList,
+        GroupID:      rl.Kafka.GroupID,
+        Properties:   rl.Properties,
+        DesiredState: spec.DesiredState,
+    }
+}
+
+// Apply 按 主题 → 表 → Routine Load → 登记管道 的顺序执行描述；
+// 任一步失败时按逆序回滚本次新建的资源，已存在的主题与表不会被删除
+func (s *PipelineService) Apply(ctx context.Context, spec PipelineSpec) (*ApplyResult, error) {
+    if err := spec.normalize(s.cfg.Kafka.Brokers); err != nil { return nil, err }
+    // 名称与作业名冲突须在创建任何资源之前发现，否则已建的主题、表与作业会无人登记
+    err := s.store.View(func(d *storeData) error { return newPipelineConflict(d, spec.Name, spec.RoutineLoad.Name) })
+    if err != nil { return nil, err }
+
+    res := &ApplyResult{}
+    var undo []func(context.Context) (ApplyStep, error)
+    fail := func(step ApplyStep, err error) (*ApplyResult, error) {
+        step.Action, step.Error = "failed", err.Error()
+        res.Steps = append(res.Steps, step)
+        // 回滚使用独立的 context，避免请求取消导致回滚中断
+        rbCtx := context.WithoutCancel(ctx)
+        for i := len(undo) - 1; i >= 0; i-- {
+            st, rbErr := undo[i](rbCtx)
+            st.Action = "rolled_back"
+            if rbErr != nil { st.Action, st.Error = "rollback_failed", rbErr.Error() }
+            res.Steps = append(res.Steps, st)
+        }
+        return res, nil
+    }
+
+    // 1) Kafka 主题
+    topicStep := ApplyStep{Step: "topic", Target: spec.Topic.Name}
+    if err := s.ka.CreateTopic(ctx, spec.Topic); err != nil {
+        if !errors.Is(err, utils.ErrConflict) { return fail(topicStep, err) }
+        topicStep.Action = "exists"
+    } else {
+        topicStep.Action = "created"
+        undo = append(undo, func(ctx context.Context) (ApplyStep, error) {
+            return topicStep, s.ka.DeleteTopic(ctx, spec.Topic.Name)
+        })
+    }
+    res.Steps = append(res.Steps, topicStep)
+
+    // 2) StarRocks 表
+    tableStep := ApplyStep{Step: "table", Target: spec.Table.Name}
+    exists, err := s.sr.TableExists(ctx, spec.Table.Name)
+    if err != nil { return fail(tableStep, err) }
+    if exists {
+        tableStep.Action = "exists"
+    } else {
+        if err := s.sr.CreateTable(ctx, spec.Table); err != nil { return fail(tableStep, err) }
+        tableStep.Action = "created"
+        undo = append(undo, func(ctx context.Context) (ApplyStep, error) {
+            return tableStep, s.sr.DropTable(ctx, spec.Table.Name)
+        })
+    }
+    res.Steps = append(res.Steps, tableStep)
+
+    // 3) Routine Load
+    rlStep := ApplyStep{Step: "routine_load", Target: spec.RoutineLoad.Name}
+    if err := s.sr.CreateRoutineLoad(ctx, spec.RoutineLoad); err != nil { return fail(rlStep, err) }
+    rlStep.Action = "created"
+    undo = append(undo, func(ctx context.Context) (ApplyStep, error) {
+        return rlStep, s.sr.StopRoutineLoad(ctx, spec.RoutineLoad.Name)
+    })
+    res.Steps = append(res.Steps, rlStep)
+
+    // 期望状态不是 RUNNING 时不让新作业开始消费；STOPPED 的作业无法恢复，因此只暂停
+    if spec.DesiredState == "PAUSED" || spec.DesiredState == "STOPPED" {
+        pauseStep := ApplyStep{Step: "pause", Target: spec.RoutineLoad.Name}
+        if err := s.sr.PauseRoutineLoad(ctx, spec.RoutineLoad.Name); err != nil { return fail(pauseStep, err) }
+        pauseStep.Action = "paused"
+        res.Steps = append(res.Steps, pauseStep)
+    }
+
+    // 4) 登记管道定义
+    regStep := ApplyStep{Step: "register", Target: spec.Name}
+    p, err := s.Create(ctx, pipelineFromSpec(spec))
+    if err != nil { return fail(regStep, err) }
+    regStep.Action = "created"
+    res.Steps = append(res.Steps, regStep)
+
+    res.OK = true
+    res.Pipeline = p
+    return res, nil
+}
\ No newline at end of file
@@ -0,0 +1,71 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "event/api/models"
+    "event/config"
+    "event/utils"
+)
+
+// newTestService 返回使用临时目录存储的 PipelineService；Kafka 指向不可达地址，StarRocks 为空，
+// 只适用于不访问外部系统的路径
+func newTestService(t *testing.T, pipes ...models.Pipeline) *PipelineService {
+    t.Helper()
+    cfg := config.Config{Storage: config.StorageConfig{DataDir: t.TempDir()}, Kafka: config.KafkaConfig{Brokers: []string{"127.0.0.1:1"}}}
+    store, err := NewStore(cfg)
+    if err != nil { t.Fatal(err) }
+    s := NewPipelineService(cfg, store, nil)
+    for _, p := range pipes {
+        if _, err := s.Create(context.Background(), p); err != nil { t.Fatalf("create %s: %v", p.Name, err) }
+    }
+    return s
+}
+
+func TestApplyConflictBeforeProvisioning(t *testing.T) {
+    s := newTestService(t, models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", JobName: "shared_rl"})
+    table := TableSpec{Columns: []ColumnSpec{{Name: "id", Type: "BIGINT"}}}
+    cases := []struct {
+        name string
+        spec PipelineSpec
+    }{
+        {"same name", PipelineSpec{Name: "clicks", Topic: TopicSpec{Name: "other"}, Table: withName(table, "other")}},
+        {"same job name", PipelineSpec{Name: "views", Topic: TopicSpec{Name: "views"}, Table: withName(table, "views"),
+            RoutineLoad: RLCreateRequest{Name: "shared_rl"}}},
+    }
+    for _, tc := range cases {
+        // StarRocks 客户端为空、Kafka 不可达：冲突须在创建任何资源之前返回
+        res, err := s.Apply(context.Background(), tc.spec)
+        if !errors.Is(err, utils.ErrConflict) {
+            t.Errorf("%s: err = %v, result %+v, want ErrConflict", tc.name, err, res)
+        }
+    }
+}
+
+func withName(t TableSpec, name string) TableSpec {
+    t.Name = name
+    return t
+}
+
+func TestBuildDDLRaw(t *testing.T) {
+    cases := []struct {
+        name    string
+        ddl     string
+        invalid bool
+    }{
+        {"plain", "CREATE TABLE page_views (id BIGINT) DUPLICATE KEY(id)", false},
+        {"if not exists with semicolon", "create table if not exists `page_views` (id BIGINT);", false},
+        {"other table", "CREATE TABLE orders (id BIGINT)", true},
+        {"qualified", "CREATE TABLE eventdb.page_views (id BIGINT)", true},
+        {"not create table", "DROP TABLE page_views", true},
+        {"view", "CREATE VIEW page_views AS SELECT 1", true},
+    }
+    for _, tc := range cases {
+        _, err := TableSpec{Name: "page_views", DDL: tc.ddl}.BuildDDL()
+        if tc.invalid != errors.Is(err, utils.ErrInvalid) || (!tc.invalid && err != nil) {
+            t.Errorf("%s: err = %v, invalid = %v", tc.name, err, tc.invalid)
+        }
+    }
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "event/api/models"
+    "event/utils"
+)
+
+type operatorKey struct{}
+
+// WithOperator 在 context 中记录本次变更的操作人，用于版本记录
+func WithOperator(ctx context.Context, user string) context.Context {
+    return context.WithValue(ctx, operatorKey{}, user)
+}
+
+func operatorFrom(ctx context.Context) string {
+    if u, ok := ctx.Value(operatorKey{}).(string); ok && strings.TrimSpace(u) != "" { return u }
+    return "anonymous"
+}
+
+func copyProps(m map[string]string) map[string]string {
+    out := make(map[string]string, len(m))
+    for k, v := range m { out[k] = v }
+    return out
+}
+
+// appendVersion 在同一事务内为管道追加一个版本，返回新版本号
+func appendVersion(ctx context.Context, d *storeData, p models.Pipeline, source, comment string, oldProps map[string]string) int {
+    hist := d.Versions[p.Name]
+    next := 1
+    if n := len(hist); n > 0 { next = hist[n-1].Version + 1 }
+    p.Status = ""
+    d.Versions[p.Name] = append(hist, models.PipelineVersion{
+        Pipeline:      p.Name,
+        Version:       next,
+        Source:        source,
+        Author:        operatorFrom(ctx),
+        CreatedAt:     time.Now().UTC(),
+        Comment:       comment,
+        OldProperties: copyProps(oldProps),
+        NewProperties: copyProps(p.Properties),
+        Definition:    p,
+    })
+    return next
+}
+
+// RecordJobProperties 在通过作业接口修改属性后，同步管道定义并记录新版本；changed 须为实际写入的属性
+// （AlterOutcome.Applied）。作业不属于任何管道或没有属性被写入时返回 0。before 为修改前的作业详情，用于补齐定义中缺失的旧值
+func (s *PipelineService) RecordJobProperties(ctx context.Context, jobName string, before *RLDetails, changed map[string]string) (int, error) {
+    version := 0
+    if len(changed) == 0 { return 0, nil }
+    err := s.store.Update(func(d *storeData) error {
+        for name, p := range d.Pipelines {
+            if p.JobName != jobName { continue }
+            oldProps := copyProps(p.Properties)
+            for k := range changed {
+                if _, ok := oldProps[k]; ok || before == nil { continue }
+                if v, ok := before.ActualProperty(k); ok { oldProps[k] = v }
+            }
+            newProps := copyProps(oldProps)
+            for k, v := range changed { newProps[k] = strings.TrimSpace(v) }
+            p.Properties = newProps
+            p.UpdatedAt = time.Now().UTC()
+            d.Pipelines[name] = p
+            version = appendVersion(ctx, d, p, "job_properties", "", oldProps)
+            return nil
+        }
+        return nil
+    })
+    return version, err
+}
+
+// ListVersions 返回管道的全部版本（按版本号升序）；版本记录引入之前创建的管道没有版本，返回空列表
+func (s *PipelineService) ListVersions(ctx context.Context, name string) ([]models.PipelineVersion, error) {
+    out := []models.PipelineVersion{}
+    var exists bool
+    _ = s.store.View(func(d *storeData) error {
+        _, exists = d.Pipelines[name]
+        out = append(out, d.Versions[name]...)
+        return nil
+    })
+    if !exists && len(out) == 0 { return nil, fmt.Errorf("pipeline %s: %w", name, utils.ErrNotFound) }
+    return out, nil
+}
+
+func (s *PipelineService) getVersion(name string, version int) (*models.PipelineVersion, error) {
+    var v *models.PipelineVersion
+    _ = s.store.View(func(d *storeData) error {
+        for _, pv := range d.Versions[name] {
+            if pv.Version == version {
+                pv := pv
+                v = &pv
+                break
+            }
+        }
+        return nil
+    })
+    if v == nil { return nil, fmt.Errorf("pipeline %s version %d: %w", name, version, utils.ErrNotFound) }
+    return v, nil
+}
+
+// FieldChange 描述两个版本间单个字段或属性的差异
+type FieldChange struct {
+    Key    string `json:"key"`
+    Change string `json:"change"` // added/removed/changed
+    From   string `json:"from,omitempty"`
+    To     string `json:"to,omitempty"`
+}
+
+type VersionDiff struct {
+    Pipeline   string        `json:"pipeline"`
+    From       int           `json:"from"`
+    To         int           `json:"to"`
+    Properties []FieldChange `json:"properties"`
+    Definition []FieldChange `json:"definition"`
+}
+
+// definitionFields 将管道定义中除属性外的字段展开为可比较的键值
+func definitionFields(p models.Pipeline) map[string]string {
+    setPairs := make([]string, 0, len(p.Set))
+    for k, v := range p.Set { setPairs = append(setPairs, k+" = "+v) }
+    sort.Strings(setPairs)
+    labelPairs := make([]string, 0, len(p.Labels))
+    for k, v := range p.Labels { labelPairs = append(labelPairs, k+"="+v) }
+    sort.Strings(labelPairs)
+    return map[string]string{
+        "source_topic":  p.SourceTopic,
+        "target_table":  p.TargetTable,
+        "job_name":      p.JobName,
+        "owner":         p.Owner,
+        "team":          p.Team,
+        "description":   p.Description,
+        "labels":        strings.Join(labelPairs, ","),
+        "sla":           fmt.Sprintf("freshness_sec=%d,max_lag_messages=%d", p.SLA.FreshnessSec, p.SLA.MaxLagMessages),
+        "broker_list":   p.BrokerList,
+        "group_id":      p.GroupID,
+        "desired_state": p.DesiredState,
+        "columns":       strings.Join(p.Columns, ", "),
+        "set":           strings.Join(setPairs, "; "),
+    }
+}
+
+func diffMaps(a, b map[string]string) []FieldChange {
+    keys := map[string]bool{}
+    for k := range a { keys[k] = true }
+    for k := range b { keys[k] = true }
+    sorted := make([]string, 0, len(keys))
+    for k := range keys { sorted = append(sorted, k) }
+    sort.Strings(sorted)
+    out := []FieldChange{}
+    for _, k := range sorted {
+        av, aok := a[k]
+        bv, bok := b[k]
+        switch {
+        case aok && !bok:
+            if av != "" { out = append(out, FieldChange{Key: k, Change: "removed", From: av}) }
+        case !aok && bok:
+            if bv != "" { out = append(out, FieldChange{Key: k, Change: "added", To: bv}) }
+        case av != bv:
+            out = append(out, FieldChange{Key: k, Change: "changed", From: av, To: bv})
+        }
+    }
+    return out
+}
+
+// DiffVersions 比较两个版本的定义快照
+func (s *PipelineService) DiffVersions(ctx context.Context, name string, from, to int) (*VersionDiff, error) {
+    a, err := s.getVersion(name, from)
+    if err != nil { return nil, err }
+    b, err := s.getVersion(name, to)
+    if err != nil { return nil, err }
+    return &VersionDiff{
+        Pipeline:   name,
+        From:       from,
+        To:         to,
+        Properties: diffMaps(a.NewProperties, b.NewProperties),
+        Definition: diffMaps(definitionFields(a.Definition), definitionFields(b.Definition)),
+    }, nil
+}
+
+type RollbackResult struct {
+    Pipeline *models.Pipeline  `json:"pipeline"`
+    Version  int               `json:"version"`
+    Altered  map[string]string `json:"altered"`
+    Paused   bool              `json:"paused"`
+    Resumed  bool              `json:"resumed"`
+    Warnings []string          `json:"warnings,omitempty"`
+}
+
+// rollbackProperties 返回回滚到 target 后应有的属性。目标版本中没有、之后才加入的属性取其后第一个修改它的版本的旧值
+// （修改作业属性时会从作业补齐旧值）；找不到旧值的属性保留当前值并给出警告，不从定义中丢弃
+func rollbackProperties(hist []models.PipelineVersion, target models.PipelineVersion, cur map[string]string) (map[string]string, []string) {
+    out := copyProps(target.NewProperties)
+    var warnings []string
+    keys := make([]string, 0, len(cur))
+    for k := range cur { keys = append(keys, k) }
+    sort.Strings(keys)
+    for _, k := range keys {
+        if _, ok := out[k]; ok { continue }
+        restored := false
+        for _, v := range hist {
+            if v.Version <= target.Version { continue }
+            ov, had := v.OldProperties[k]
+            nv, has := v.NewProperties[k]
+            if had == has && ov == nv { continue }
+            if had { out[k], restored = ov, true }
+            break
+        }
+        if !restored {
+            out[k] = cur[k]
+            warnings = append(warnings, fmt.Sprintf("property %s was added after v%d and its earlier value is unknown; kept %s", k, target.Version, cur[k]))
+        }
+    }
+    return out, warnings
+}
+
+// Rollback 将管道恢复到指定版本：先通过 暂停 → ALTER → 恢复 重新应用该版本的属性（含之后才加入的属性的旧值），
+// 再以该版本快照替换定义并记录一个 rollback 版本。主题、表等无法 ALTER 的差异由收敛循环标记为漂移
+func (s *PipelineService) Rollback(ctx context.Context, name string, version int) (*RollbackResult, error) {
+    target, err := s.getVersion(name, version)
+    if err != nil { return nil, err }
+    cur, err := s.Get(ctx, name)
+    if err != nil { return nil, err }
+    var hist []models.PipelineVersion
+    _ = s.store.View(func(d *storeData) error {
+        hist = append(hist, d.Versions[name]...)
+        return nil
+    })
+
+    res := &RollbackResult{Altered: map[string]string{}}
+    props, warnings := rollbackProperties(hist, *target, cur.Properties)
+    for k, v := range props {
+        if cur.Properties[k] != v { res.Altered[k] = v }
+    }
+    if len(res.Altered) > 0 && cur.Status != "MISSING" {
+        out, err := s.sr.AlterWithPause(ctx, cur.JobName, res.Altered)
+        res.Paused, res.Resumed = out.Paused, out.Resumed
+        if err == nil {
+            // 只报告实际写入的属性，不可 ALTER 的属性另行提示
+            for k := range res.Altered {
+                if _, ok := out.Applied[k]; !ok { res.Warnings = append(res.Warnings, "property "+k+" cannot be altered in place") }
+            }
+            res.Altered = out.Applied
+        }
+        if out.PauseErr != nil { res.Warnings = append(res.Warnings, "pause: "+out.PauseErr.Error()) }
+        if out.ResumeErr != nil { res.Warnings = append(res.Warnings, "resume: "+out.ResumeErr.Error()) }
+        if err != nil {
+            if out.ResumeErr != nil { return nil, fmt.Errorf("alter %s: %w (resume failed, job left paused: %v)", cur.JobName, err, out.ResumeErr) }
+            return nil, fmt.Errorf("alter %s: %w", cur.JobName, err)
+        }
+    }
+    res.Warnings = append(res.Warnings, warnings...)
+    sort.Strings(res.Warnings)
+    restored := target.Definition
+    restored.Properties = props
+    if restored.SourceTopic != cur.SourceTopic || restored.TargetTable != cur.TargetTable || restored.JobName != cur.JobName {
+        res.Warnings = append(res.Warnings, "topic/table/job changes cannot be altered in place; reconciler will report drift")
+    }
+
+    err = s.store.Update(func(d *storeData) error {
+        old, ok := d.Pipelines[name]
+        if !ok { return fmt.Errorf("pipeline %s: %w", name, utils.ErrNotFound) }
+        restored.Name = name
+        restored.Status = ""
+        restored.CreatedAt = old.CreatedAt
+        restored.UpdatedAt = time.Now().UTC()
+        // 回滚不改变运行意图，保留当前的期望状态
+        restored.DesiredState = old.DesiredState
+        d.Pipelines[name] = restored
+        res.Version = appendVersion(ctx, d, restored, "rollback", fmt.Sprintf("rollback to v%d", version), old.Properties)
+        return nil
+    })
+    if err != nil { return nil, err }
+    restored.Status = cur.Status
+    res.Pipeline = &restored
+    return res, nil
+}
\ No newline at end of file
@@ -0,0 +1,119 @@
+package services
+
+import (
+    "context"
+    "reflect"
+    "testing"
+
+    "event/api/models"
+)
+
+func TestRollbackProperties(t *testing.T) {
+    ver := func(n int, oldProps, newProps map[string]string) models.PipelineVersion {
+        return models.PipelineVersion{Version: n, OldProperties: oldProps, NewProperties: newProps}
+    }
+    // v1 创建时没有 max_batch_rows；v2 通过作业接口设置（旧值由作业补齐）；v3 再次修改
+    hist := []models.PipelineVersion{
+        ver(1, map[string]string{}, map[string]string{"format": "json"}),
+        ver(2, map[string]string{"format": "json", "max_batch_rows": "200000"}, map[string]string{"format": "json", "max_batch_rows": "900000"}),
+        ver(3, map[string]string{"format": "json", "max_batch_rows": "900000"}, map[string]string{"format": "json", "max_batch_rows": "5000000"}),
+    }
+    cases := []struct {
+        name     string
+        hist     []models.PipelineVersion
+        target   int
+        cur      map[string]string
+        want     map[string]string
+        warnings int
+    }{
+        {"restore value from the version that added the key", hist, 1,
+            map[string]string{"format": "json", "max_batch_rows": "5000000"},
+            map[string]string{"format": "json", "max_batch_rows": "200000"}, 0},
+        {"key present in target", hist, 2,
+            map[string]string{"format": "json", "max_batch_rows": "5000000"},
+            map[string]string{"format": "json", "max_batch_rows": "900000"}, 0},
+        {"no earlier value", []models.PipelineVersion{
+            ver(1, map[string]string{}, map[string]string{"format": "json"}),
+            ver(2, map[string]string{"format": "json"}, map[string]string{"format": "json", "strict_mode": "true"}),
+        }, 1,
+            map[string]string{"format": "json", "strict_mode": "true"},
+            map[string]string{"format": "json", "strict_mode": "true"}, 1},
+        {"key never recorded in versions", hist, 1,
+            map[string]string{"format": "json", "max_batch_rows": "5000000", "timezone": "UTC"},
+            map[string]string{"format": "json", "max_batch_rows": "200000", "timezone": "UTC"}, 1},
+        {"target value restored even if removed later", []models.PipelineVersion{
+            ver(1, map[string]string{}, map[string]string{"format": "json", "strict_mode": "true"}),
+            ver(2, map[string]string{"format": "json", "strict_mode": "true"}, map[string]string{"format": "json"}),
+        }, 1,
+            map[string]string{"format": "json"},
+            map[string]string{"format": "json", "strict_mode": "true"}, 0},
+    }
+    for _, tc := range cases {
+        var target models.PipelineVersion
+        for _, v := range tc.hist {
+            if v.Version == tc.target { target = v }
+        }
+        got, warnings := rollbackProperties(tc.hist, target, tc.cur)
+        if !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%s: properties = %v, want %v", tc.name, got, tc.want)
+        }
+        if len(warnings) != tc.warnings {
+            t.Errorf("%s: warnings = %q, want %d", tc.name, warnings, tc.warnings)
+        }
+    }
+}
+
+func TestRecordJobPropertiesAndDiff(t *testing.T) {
+    ctx := WithOperator(context.Background(), "alice")
+    s := newTestService(t, models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", Properties: map[string]string{"format": "json"}})
+
+    if v, err := s.RecordJobProperties(ctx, "clicks_rl", nil, nil); err != nil || v != 0 {
+        t.Fatalf("no changes: version %d, err %v, want 0", v, err)
+    }
+    if v, err := s.RecordJobProperties(ctx, "other_rl", nil, map[string]string{"max_batch_rows": "1"}); err != nil || v != 0 {
+        t.Fatalf("unknown job: version %d, err %v, want 0", v, err)
+    }
+    before := &RLDetails{Properties: map[string]string{"maxBatchRows": "200000", "format": "json"}}
+    v, err := s.RecordJobProperties(ctx, "clicks_rl", before, map[string]string{"max_batch_rows": " 900000 "})
+    if err != nil || v != 2 {
+        t.Fatalf("record: version %d, err %v, want 2", v, err)
+    }
+
+    vs, err := s.ListVersions(ctx, "clicks")
+    if err != nil || len(vs) != 2 {
+        t.Fatalf("versions = %+v, err %v", vs, err)
+    }
+    if vs[1].Author != "alice" || vs[1].Source != "job_properties" {
+        t.Errorf("v2 author/source = %s/%s", vs[1].Author, vs[1].Source)
+    }
+    if want := map[string]string{"format": "json", "max_batch_rows": "200000"}; !reflect.DeepEqual(vs[1].OldProperties, want) {
+        t.Errorf("v2 old properties = %v, want %v (missing old value filled from the job)", vs[1].OldProperties, want)
+    }
+
+    d, err := s.DiffVersions(ctx, "clicks", 1, 2)
+    if err != nil { t.Fatal(err) }
+    if want := []FieldChange{{Key: "max_batch_rows", Change: "added", To: "900000"}}; !reflect.DeepEqual(d.Properties, want) {
+        t.Errorf("diff properties = %+v, want %+v", d.Properties, want)
+    }
+    if len(d.Definition) != 0 {
+        t.Errorf("diff definition = %+v, want none", d.Definition)
+    }
+    if _, err := s.DiffVersions(ctx, "clicks", 1, 3); err == nil {
+        t.Error("diff with unknown version: want error")
+    }
+}
+
+func TestDiffMaps(t *testing.T) {
+    got := diffMaps(
+        map[string]string{"a": "1", "b": "2", "c": "3", "e": ""},
+        map[string]string{"a": "1", "b": "20", "d": "4", "f": ""},
+    )
+    want := []FieldChange{
+        {Key: "b", Change: "changed", From: "2", To: "20"},
+        {Key: "c", Change: "removed", From: "3"},
+        {Key: "d", Change: "added", To: "4"},
+    }
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("diffMaps = %+v, want %+v", got, want)
+    }
+}
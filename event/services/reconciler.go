@@ -0,0 +1,258 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "go.uber.org/zap"
+)
+
+// ReconcileAction 描述针对单个管道的一项收敛动作
+type ReconcileAction struct {
+    Pipeline string `json:"pipeline"`
+    Job      string `json:"job"`
+    Action   string `json:"action"` // recreate/resume/drift/topic_missing
+    Detail   string `json:"detail,omitempty"`
+    Applied  bool   `json:"applied"`
+    Error    string `json:"error,omitempty"`
+}
+
+type ReconcileReport struct {
+    StartedAt  time.Time         `json:"started_at"`
+    FinishedAt time.Time         `json:"finished_at"`
+    DryRun     bool              `json:"dry_run"`
+    Pipelines  int               `json:"pipelines"`
+    Actions    []ReconcileAction `json:"actions"`
+    Error      string            `json:"error,omitempty"`
+}
+
+const maxReconcileBackoff = time.Hour // 连续重建/恢复失败时的最长重试间隔
+
+// reconcileBackoff 记录管道连续的重建/恢复尝试：作业反复被取消或操作反复失败时按指数拉长重试间隔，
+// 作业达到期望状态后清除
+type reconcileBackoff struct {
+    attempts int
+    next     time.Time
+}
+
+// Reconciler 周期性比对管道定义与实际的 Routine Load / Kafka 主题，使实际状态向期望状态收敛：
+// 重建丢失的作业、恢复被误暂停的作业，并标记属性或主题漂移（漂移仅告警，不自动修改）
+type Reconciler struct {
+    cfg       config.Config
+    logger    *zap.Logger
+    pipelines *PipelineService
+    sr        *StarRocksClient
+    ka        *KafkaAdmin
+    scheduler *Scheduler
+
+    runMu   sync.Mutex // 保证同一时刻只有一轮收敛
+    backoff map[string]*reconcileBackoff // 仅在持有 runMu 时访问
+    mu      sync.RWMutex
+    last    *ReconcileReport
+}
+
+func NewReconciler(cfg config.Config, logger *zap.Logger, store *Store, sr *StarRocksClient, scheduler *Scheduler) *Reconciler {
+    return &Reconciler{
+        cfg:       cfg,
+        logger:    logger,
+        pipelines: NewPipelineService(cfg, store, sr),
+        sr:        sr,
+        ka:        NewKafkaAdmin(cfg),
+        scheduler: scheduler,
+        backoff:   map[string]*reconcileBackoff{},
+    }
+}
+
+// interval 返回收敛间隔，最短 10 秒
+func (r *Reconciler) interval() time.Duration {
+    return max(time.Duration(r.cfg.Reconcile.IntervalSec)*time.Second, 10*time.Second)
+}
+
+// Run 按配置的间隔执行收敛，直到 ctx 结束
+func (r *Reconciler) Run(ctx context.Context) {
+    if !r.cfg.Reconcile.Enabled { return }
+    ticker := time.NewTicker(r.interval())
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            rep := r.Reconcile(ctx, r.cfg.Reconcile.DryRun)
+            if rep.Error != "" {
+                r.logger.Sugar().Warnw("reconcile.failed", "err", rep.Error)
+                continue
+            }
+            for _, a := range rep.Actions {
+                r.logger.Sugar().Infow("reconcile.action",
+                    "pipeline", a.Pipeline, "job", a.Job, "action", a.Action,
+                    "detail", a.Detail, "applied", a.Applied, "err", a.Error,
+                )
+            }
+        }
+    }
+}
+
+// LastReport 返回最近一轮收敛的结果（可能为 nil）
+func (r *Reconciler) LastReport() *ReconcileReport {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.last
+}
+
+// Reconcile 执行一轮收敛；dryRun 时只生成计划动作
+func (r *Reconciler) Reconcile(ctx context.Context, dryRun bool) *ReconcileReport {
+    r.runMu.Lock()
+    defer r.runMu.Unlock()
+    rep := &ReconcileReport{StartedAt: time.Now().UTC(), DryRun: dryRun, Actions: []ReconcileAction{}}
+    defer func() {
+        rep.FinishedAt = time.Now().UTC()
+        r.mu.Lock()
+        r.last = rep
+        r.mu.Unlock()
+    }()
+
+    // 作业或主题列表获取失败时整轮放弃，避免把"查询失败"误判为"作业丢失"
+    jobs, err := r.sr.ListRoutineLoad(ctx)
+    if err != nil {
+        rep.Error = fmt.Sprintf("list routine load: %v", err)
+        return rep
+    }
+    topics, err := r.ka.ListTopics(ctx)
+    if err != nil {
+        rep.Error = fmt.Sprintf("list topics: %v", err)
+        return rep
+    }
+    states := JobStates(jobs)
+    topicSet := make(map[string]bool, len(topics))
+    for _, t := range topics { topicSet[t.Name] = true }
+
+    pipes, err := r.pipelines.ListWithStates(states)
+    if err != nil {
+        rep.Error = fmt.Sprintf("list pipelines: %v", err)
+        return rep
+    }
+    rep.Pipelines = len(pipes)
+    seen := make(map[string]bool, len(pipes))
+    for _, p := range pipes {
+        seen[p.Name] = true
+        rep.Actions = append(rep.Actions, r.reconcileOne(ctx, p, topicSet, dryRun)...)
+    }
+    for name := range r.backoff {
+        if !seen[name] { delete(r.backoff, name) }
+    }
+    return rep
+}
+
+func (r *Reconciler) reconcileOne(ctx context.Context, p models.Pipeline, topics map[string]bool, dryRun bool) []ReconcileAction {
+    var out []ReconcileAction
+    now := time.Now()
+    act := func(action, detail string, apply func() error) {
+        a := ReconcileAction{Pipeline: p.Name, Job: p.JobName, Action: action, Detail: detail}
+        if apply != nil && !dryRun {
+            if b := r.backoff[p.Name]; b != nil && now.Before(b.next) {
+                a.Detail += fmt.Sprintf("; backing off after %d attempts until %s", b.attempts, b.next.UTC().Format(time.RFC3339))
+            } else {
+                r.attempted(p.Name, now)
+                if err := apply(); err != nil {
+                    a.Error = err.Error()
+                } else {
+                    a.Applied = true
+                }
+            }
+        }
+        out = append(out, a)
+    }
+
+    // 期望为 STOPPED 的管道由用户主动停止，不做任何收敛
+    if p.DesiredState == "STOPPED" { return out }
+    // 计划维护窗口内视同期望暂停，避免把计划暂停的作业恢复
+    if p.DesiredState == "RUNNING" && r.scheduler != nil && r.scheduler.InWindow(p.Name, now) {
+        p.DesiredState = "PAUSED"
+    }
+    if p.Status == p.DesiredState { delete(r.backoff, p.Name) }
+
+    if !topics[p.SourceTopic] {
+        // 主题不存在时无法（重）建作业，仅标记
+        act("topic_missing", fmt.Sprintf("source topic %s not found", p.SourceTopic), nil)
+        return out
+    }
+
+    switch p.Status {
+    case "MISSING", "STOPPED", "CANCELLED":
+        // 已停止或被取消的作业可能是有意为之，只在期望状态为 RUNNING 时重建；丢失的作业总是重建
+        if p.Status != "MISSING" && p.DesiredState != "RUNNING" {
+            act("recreate", fmt.Sprintf("job state %s; not recreated while desired state is %s", p.Status, p.DesiredState), nil)
+            return out
+        }
+        req := r.pipelines.RoutineLoadRequest(p)
+        act("recreate", fmt.Sprintf("job state %s", p.Status), func() error {
+            if err := r.sr.CreateRoutineLoad(ctx, req); err != nil { return err }
+            if p.DesiredState == "PAUSED" { return r.sr.PauseRoutineLoad(ctx, p.JobName) }
+            return nil
+        })
+        return out
+    case "PAUSED":
+        if p.DesiredState != "PAUSED" {
+            act("resume", "job paused but desired state is RUNNING", func() error {
+                return r.sr.ResumeRoutineLoad(ctx, p.JobName)
+            })
+        }
+    }
+
+    // 漂移检测：主题、目标表与属性
+    d, err := r.sr.GetRoutineLoadDetails(ctx, p.JobName)
+    if err != nil { return out }
+    for _, drift := range detectDrift(p, d) {
+        act("drift", drift, nil)
+    }
+    return out
+}
+
+// attempted 记录一次重建/恢复尝试，并按 收敛间隔 × 2^(次数-1) 推迟下一次尝试
+func (r *Reconciler) attempted(name string, now time.Time) {
+    b := r.backoff[name]
+    if b == nil {
+        b = &reconcileBackoff{}
+        r.backoff[name] = b
+    }
+    b.attempts++
+    delay := maxReconcileBackoff
+    if b.attempts <= 16 { delay = min(r.interval()<<(b.attempts-1), maxReconcileBackoff) }
+    b.next = now.Add(delay)
+}
+
+// detectDrift 比较管道定义与作业当前配置，返回差异描述
+func detectDrift(p models.Pipeline, d *RLDetails) []string {
+    var out []string
+    if t, ok := d.Kafka["topic"]; ok && t != "" && t != p.SourceTopic {
+        out = append(out, fmt.Sprintf("topic: want %s, got %s", p.SourceTopic, t))
+    }
+    if d.Table != "" && d.Table != p.TargetTable {
+        out = append(out, fmt.Sprintf("table: want %s, got %s", p.TargetTable, d.Table))
+    }
+    keys := make([]string, 0, len(p.Properties))
+    for k := range p.Properties { keys = append(keys, k) }
+    sort.Strings(keys)
+    for _, k := range keys {
+        want := normalizeRLProperty(k, p.Properties[k])
+        got, ok := d.ActualProperty(k)
+        if !ok { continue }
+        if !samePropertyValue(want, got) {
+            out = append(out, fmt.Sprintf("property %s: want %s, got %s", k, want, got))
+        }
+    }
+    return out
+}
+
+// samePropertyValue 忽略空白与大小写比较属性值（jsonpaths 在 FE 中可能被重新格式化）
+func samePropertyValue(a, b string) bool {
+    strip := func(s string) string { return strings.Join(strings.Fields(s), "") }
+    return strings.EqualFold(strip(a), strip(b))
+}
\ No newline at end of file
@@ -0,0 +1,126 @@
+package services
+
+import (
+    "context"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "go.uber.org/zap"
+)
+
+// newTestReconciler 返回不连接外部系统的 Reconciler：未配置 broker，重建作业时在拼装请求阶段即失败
+func newTestReconciler(t *testing.T) *Reconciler {
+    t.Helper()
+    cfg := config.Config{Storage: config.StorageConfig{DataDir: t.TempDir()}, Reconcile: config.ReconcileConfig{IntervalSec: 60}}
+    store, err := NewStore(cfg)
+    if err != nil { t.Fatal(err) }
+    return NewReconciler(cfg, zap.NewNop(), store, &StarRocksClient{cfg: cfg}, nil)
+}
+
+func TestReconcileOneStoppedJobs(t *testing.T) {
+    r := newTestReconciler(t)
+    topics := map[string]bool{"clicks": true}
+    pipe := func(status, desired string) models.Pipeline {
+        return models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", JobName: "clicks_rl", Status: status, DesiredState: desired}
+    }
+    cases := []struct {
+        name    string
+        p       models.Pipeline
+        topics  map[string]bool
+        actions []string
+        applied bool // 是否尝试执行（未配置 broker，尝试必然失败）
+    }{
+        {"desired stopped", pipe("STOPPED", "STOPPED"), topics, nil, false},
+        {"stopped but desired paused", pipe("STOPPED", "PAUSED"), topics, []string{"recreate"}, false},
+        {"cancelled but desired paused", pipe("CANCELLED", "PAUSED"), topics, []string{"recreate"}, false},
+        {"stopped and desired running", pipe("STOPPED", "RUNNING"), topics, []string{"recreate"}, true},
+        {"missing and desired paused", pipe("MISSING", "PAUSED"), topics, []string{"recreate"}, true},
+        {"topic missing", pipe("MISSING", "RUNNING"), map[string]bool{}, []string{"topic_missing"}, false},
+    }
+    for _, tc := range cases {
+        r.backoff = map[string]*reconcileBackoff{}
+        acts := r.reconcileOne(context.Background(), tc.p, tc.topics, false)
+        var names []string
+        for _, a := range acts { names = append(names, a.Action) }
+        if !reflect.DeepEqual(names, tc.actions) {
+            t.Errorf("%s: actions = %v, want %v", tc.name, names, tc.actions)
+            continue
+        }
+        tried := len(acts) > 0 && acts[0].Error != ""
+        if tried != tc.applied {
+            t.Errorf("%s: attempted = %v (%+v), want %v", tc.name, tried, acts, tc.applied)
+        }
+        if _, ok := r.backoff[tc.p.Name]; ok != tc.applied {
+            t.Errorf("%s: backoff recorded = %v, want %v", tc.name, ok, tc.applied)
+        }
+    }
+}
+
+func TestReconcileOneBacksOff(t *testing.T) {
+    r := newTestReconciler(t)
+    p := models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks", JobName: "clicks_rl", Status: "CANCELLED", DesiredState: "RUNNING"}
+    topics := map[string]bool{"clicks": true}
+
+    first := r.reconcileOne(context.Background(), p, topics, false)
+    if len(first) != 1 || first[0].Error == "" {
+        t.Fatalf("first pass = %+v, want a failed recreate", first)
+    }
+    second := r.reconcileOne(context.Background(), p, topics, false)
+    if len(second) != 1 || second[0].Error != "" || !strings.Contains(second[0].Detail, "backing off after 1 attempts") {
+        t.Fatalf("second pass = %+v, want recreate skipped by backoff", second)
+    }
+    // dry run 只报告计划，不受退避影响也不计数
+    dry := r.reconcileOne(context.Background(), p, topics, true)
+    if len(dry) != 1 || strings.Contains(dry[0].Detail, "backing off") || r.backoff[p.Name].attempts != 1 {
+        t.Fatalf("dry run = %+v, attempts %d", dry, r.backoff[p.Name].attempts)
+    }
+    r.backoff[p.Name].next = time.Now().Add(-time.Second)
+    third := r.reconcileOne(context.Background(), p, topics, false)
+    if len(third) != 1 || third[0].Error == "" || r.backoff[p.Name].attempts != 2 {
+        t.Fatalf("third pass = %+v, attempts %d, want a second attempt", third, r.backoff[p.Name].attempts)
+    }
+}
+
+func TestReconcileBackoffDelay(t *testing.T) {
+    r := newTestReconciler(t)
+    now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+    want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour}
+    for i, w := range want {
+        r.attempted("clicks", now)
+        if got := r.backoff["clicks"].next.Sub(now); got != w {
+            t.Errorf("attempt %d: delay = %s, want %s", i+1, got, w)
+        }
+    }
+    for i := 0; i < 100; i++ { r.attempted("clicks", now) }
+    if got := r.backoff["clicks"].next.Sub(now); got != time.Hour {
+        t.Errorf("after many attempts: delay = %s, want 1h", got)
+    }
+}
+
+func TestDetectDrift(t *testing.T) {
+    p := models.Pipeline{SourceTopic: "clicks", TargetTable: "clicks", Properties: map[string]string{
+        "max_batch_rows": "300000", "desired_concurrent_number": "3", "jsonpaths": `["$.id", "$.ts"]`, "format": "json",
+    }}
+    cases := []struct {
+        name string
+        d    RLDetails
+        want []string
+    }{
+        {"in sync", RLDetails{Table: "clicks", Kafka: map[string]string{"topic": "clicks"},
+            Properties: map[string]string{"maxBatchRows": "300000", "desireTaskConcurrentNum": "3", "jsonpaths": `["$.id","$.ts"]`}}, nil},
+        {"topic and table", RLDetails{Table: "views", Kafka: map[string]string{"topic": "views"}},
+            []string{"topic: want clicks, got views", "table: want clicks, got views"}},
+        {"property aliases", RLDetails{Table: "clicks", Properties: map[string]string{"maxBatchRows": "250000", "desireTaskConcurrentNum": "1"}},
+            []string{"property desired_concurrent_number: want 3, got 1", "property max_batch_rows: want 300000, got 250000"}},
+        {"unknown properties ignored", RLDetails{Properties: map[string]string{}}, nil},
+    }
+    for _, tc := range cases {
+        if got := detectDrift(p, &tc.d); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%s: drift = %q, want %q", tc.name, got, tc.want)
+        }
+    }
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "event/utils"
+    "go.uber.org/zap"
+)
+
+const (
+    maxScheduleRuns  = 1000        // 运行记录保留条数
+    maxWindowMinutes = 7 * 24 * 60 // 单个窗口最长一周
+)
+
+// ScheduleView 为计划窗口及其实时信息
+type ScheduleView struct {
+    models.PipelineSchedule
+    Active    bool       `json:"active"`
+    NextStart *time.Time `json:"next_start,omitempty"`
+}
+
+// Scheduler 按管道的计划窗口暂停与恢复 Routine Load，并记录每次执行。
+// 是否处于窗口内由 cron 与时长直接计算（无状态），收敛循环据此在窗口内不恢复作业；
+// 由计划暂停的管道记录在 Store 中，服务在窗口内重启后仍会在窗口结束时恢复
+type Scheduler struct {
+    cfg       config.Config
+    logger    *zap.Logger
+    store     *Store
+    pipelines *PipelineService
+    sr        *StarRocksClient
+
+    active map[string]models.PipelineSchedule // 上一轮处于窗口内的计划，仅由 Run 所在的 goroutine 访问
+}
+
+func NewScheduler(cfg config.Config, logger *zap.Logger, store *Store, sr *StarRocksClient) *Scheduler {
+    return &Scheduler{
+        cfg:       cfg,
+        logger:    logger,
+        store:     store,
+        pipelines: NewPipelineService(cfg, store, sr),
+        sr:        sr,
+        active:    map[string]models.PipelineSchedule{},
+    }
+}
+
+func newScheduleID() string {
+    b := make([]byte, 6)
+    _, _ = rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+func compileSchedule(sc models.PipelineSchedule) (*utils.CronExpr, *time.Location, error) {
+    expr, err := utils.ParseCron(sc.Cron)
+    if err != nil { return nil, nil, err }
+    loc := time.Local
+    if sc.Timezone != "" {
+        if loc, err = time.LoadLocation(sc.Timezone); err != nil {
+            return nil, nil, fmt.Errorf("%w: timezone %q: %v", utils.ErrInvalid, sc.Timezone, err)
+        }
+    }
+    return expr, loc, nil
+}
+
+// windowAt 判断 now 是否落在计划窗口内：过去 DurationMin 分钟内存在命中 cron 的分钟即为窗口内
+func windowAt(sc models.PipelineSchedule, now time.Time) bool {
+    if !sc.Enabled { return false }
+    expr, loc, err := compileSchedule(sc)
+    if err != nil { return false }
+    t := now.In(loc).Truncate(time.Minute)
+    for i := 0; i < sc.DurationMin; i++ {
+        if expr.Match(t.Add(-time.Duration(i) * time.Minute)) { return true }
+    }
+    return false
+}
+
+// InWindow 判断管道当前是否处于任一计划窗口内
+func (s *Scheduler) InWindow(pipeline string, now time.Time) bool {
+    in := false
+    _ = s.store.View(func(d *storeData) error {
+        for _, sc := range d.Schedules {
+            if sc.Pipeline == pipeline && windowAt(sc, now) { in = true; break }
+        }
+        return nil
+    })
+    return in
+}
+
+// Add 为管道新增计划窗口
+func (s *Scheduler) Add(ctx context.Context, pipeline string, sc models.PipelineSchedule) (*models.PipelineSchedule, error) {
+    sc.Cron = strings.TrimSpace(sc.Cron)
+    sc.Timezone = strings.TrimSpace(sc.Timezone)
+    if _, _, err := compileSchedule(sc); err != nil { return nil, err }
+    if sc.DurationMin < 1 || sc.DurationMin > maxWindowMinutes {
+        return nil, fmt.Errorf("%w: duration_min must be between 1 and %d", utils.ErrInvalid, maxWindowMinutes)
+    }
+    sc.ID = newScheduleID()
+    sc.Pipeline = pipeline
+    sc.Author = operatorFrom(ctx)
+    sc.CreatedAt = time.Now().UTC()
+    err := s.store.Update(func(d *storeData) error {
+        if _, ok := d.Pipelines[pipeline]; !ok {
+            return fmt.Errorf("pipeline %s: %w", pipeline, utils.ErrNotFound)
+        }
+        d.Schedules[sc.ID] = sc
+        return nil
+    })
+    if err != nil { return nil, err }
+    return &sc, nil
+}
+
+// SetEnabled 启用或停用计划窗口
+func (s *Scheduler) SetEnabled(ctx context.Context, pipeline, id string, enabled bool) (*models.PipelineSchedule, error) {
+    var out models.PipelineSchedule
+    err := s.store.Update(func(d *storeData) error {
+        sc, ok := d.Schedules[id]
+        if !ok || sc.Pipeline != pipeline {
+            return fmt.Errorf("schedule %s of pipeline %s: %w", id, pipeline, utils.ErrNotFound)
+        }
+        sc.Enabled = enabled
+        d.Schedules[id] = sc
+        out = sc
+        return nil
+    })
+    if err != nil { return nil, err }
+    return &out, nil
+}
+
+// Delete 删除计划窗口；窗口进行中时由下一轮调度恢复作业
+func (s *Scheduler) Delete(ctx context.Context, pipeline, id string) error {
+    return s.store.Update(func(d *storeData) error {
+        sc, ok := d.Schedules[id]
+        if !ok || sc.Pipeline != pipeline {
+            return fmt.Errorf("schedule %s of pipeline %s: %w", id, pipeline, utils.ErrNotFound)
+        }
+        delete(d.Schedules, id)
+        return nil
+    })
+}
+
+// List 返回计划窗口（pipeline 为空时返回全部），附带是否进行中与下次开始时间
+func (s *Scheduler) List(pipeline string) []ScheduleView {
+    now := time.Now()
+    out := []ScheduleView{}
+    _ = s.store.View(func(d *storeData) error {
+        for _, sc := range d.Schedules {
+            if pipeline != "" && sc.Pipeline != pipeline { continue }
+            v := ScheduleView{PipelineSchedule: sc, Active: windowAt(sc, now)}
+            if expr, loc, err := compileSchedule(sc); err == nil && sc.Enabled {
+                if next := expr.Next(now.In(loc)); !next.IsZero() { v.NextStart = &next }
+            }
+            out = append(out, v)
+        }
+        return nil
+    })
+    sort.Slice(out, func(i, j int) bool {
+        if out[i].Pipeline != out[j].Pipeline { return out[i].Pipeline < out[j].Pipeline }
+        return out[i].CreatedAt.Before(out[j].CreatedAt)
+    })
+    return out
+}
+
+// Runs 返回最近的执行记录（新的在前），pipeline 为空时不过滤
+func (s *Scheduler) Runs(pipeline string, limit int) []models.ScheduleRun {
+    if limit <= 0 { limit = 100 }
+    out := []models.ScheduleRun{}
+    _ = s.store.View(func(d *storeData) error {
+        for i := len(d.ScheduleRuns) - 1; i >= 0 && len(out) < limit; i-- {
+            if pipeline != "" && d.ScheduleRuns[i].Pipeline != pipeline { continue }
+            out = append(out, d.ScheduleRuns[i])
+        }
+        return nil
+    })
+    return out
+}
+
+func (s *Scheduler) record(run models.ScheduleRun) {
+    run.At = time.Now().UTC()
+    s.logger.Sugar().Infow("schedule.run",
+        "schedule", run.ScheduleID, "pipeline", run.Pipeline, "job", run.Job,
+        "action", run.Action, "result", run.Result, "detail", run.Detail,
+    )
+    err := s.store.Update(func(d *storeData) error {
+        d.ScheduleRuns = append(d.ScheduleRuns, run)
+        if n := len(d.ScheduleRuns); n > maxScheduleRuns {
+            d.ScheduleRuns = append([]models.ScheduleRun(nil), d.ScheduleRuns[n-maxScheduleRuns:]...)
+        }
+        return nil
+    })
+    if err != nil { s.logger.Sugar().Warnw("schedule.record.failed", "err", err) }
+}
+
+// Run 每 30 秒检查一次窗口的进入与结束，直到 ctx 结束
+func (s *Scheduler) Run(ctx context.Context) {
+    ticker := time.NewTicker(30 * time.Second)
+    defer ticker.Stop()
+    s.tick(ctx, time.Now())
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            s.tick(ctx, now)
+        }
+    }
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+    var schedules []models.PipelineSchedule
+    _ = s.store.View(func(d *storeData) error {
+        for _, sc := range d.Schedules { schedules = append(schedules, sc) }
+        return nil
+    })
+    sort.Slice(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+    in := map[string]bool{}
+    open := map[string]bool{} // 管道 → 是否仍有窗口进行中（重叠窗口全部结束后才恢复）
+    for _, sc := range schedules {
+        if windowAt(sc, now) { in[sc.ID], open[sc.Pipeline] = true, true }
+    }
+    for _, sc := range schedules {
+        if in[sc.ID] && !s.isActive(sc.ID) {
+            s.active[sc.ID] = sc
+            s.enter(ctx, sc)
+        }
+    }
+    for id := range s.active {
+        if !in[id] { delete(s.active, id) }
+    }
+    // 由计划暂停的管道在其全部窗口结束、计划被停用或删除后恢复
+    pauses := s.pauses()
+    pipelines := make([]string, 0, len(pauses))
+    for name := range pauses { pipelines = append(pipelines, name) }
+    sort.Strings(pipelines)
+    for _, name := range pipelines {
+        if !open[name] { s.exit(ctx, name, pauses[name]) }
+    }
+}
+
+// pauses 返回由计划窗口暂停、需在窗口结束时恢复的管道及对应的计划 ID
+func (s *Scheduler) pauses() map[string]string {
+    out := map[string]string{}
+    _ = s.store.View(func(d *storeData) error {
+        for name, id := range d.SchedulePauses { out[name] = id }
+        return nil
+    })
+    return out
+}
+
+// setPausedBy 记录（scheduleID 为空时清除）管道由哪个计划暂停
+func (s *Scheduler) setPausedBy(pipeline, scheduleID string) {
+    err := s.store.Update(func(d *storeData) error {
+        if scheduleID == "" {
+            delete(d.SchedulePauses, pipeline)
+        } else {
+            d.SchedulePauses[pipeline] = scheduleID
+        }
+        return nil
+    })
+    if err != nil { s.logger.Sugar().Warnw("schedule.pause_marker.failed", "pipeline", pipeline, "err", err) }
+}
+
+func (s *Scheduler) isActive(id string) bool {
+    _, ok := s.active[id]
+    return ok
+}
+
+// enter 在窗口开始时暂停作业；期望状态不是 RUNNING 或作业未在运行时跳过
+func (s *Scheduler) enter(ctx context.Context, sc models.PipelineSchedule) {
+    run := models.ScheduleRun{ScheduleID: sc.ID, Pipeline: sc.Pipeline, Action: "pause"}
+    p, err := s.pipelines.Get(ctx, sc.Pipeline)
+    if err != nil {
+        run.Result, run.Detail = "failed", err.Error()
+        s.record(run)
+        return
+    }
+    run.Job = p.JobName
+    if _, ok := s.pauses()[p.Name]; ok { return }
+    switch {
+    case p.DesiredState != "RUNNING":
+        run.Result, run.Detail = "skipped", "desired state is "+p.DesiredState
+    case p.Status != "RUNNING" && p.Status != "NEED_SCHEDULE":
+        run.Result, run.Detail = "skipped", "job state is "+p.Status
+    default:
+        if err := s.sr.PauseRoutineLoad(ctx, p.JobName); err != nil {
+            run.Result, run.Detail = "failed", err.Error()
+        } else {
+            run.Result = "ok"
+            s.setPausedBy(p.Name, sc.ID)
+        }
+    }
+    if sc.Comment != "" && run.Detail == "" { run.Detail = sc.Comment }
+    s.record(run)
+}
+
+// exit 在管道的全部窗口结束后恢复由计划暂停的作业；期间被手动改为暂停或停止的不恢复
+func (s *Scheduler) exit(ctx context.Context, pipeline, scheduleID string) {
+    s.setPausedBy(pipeline, "")
+    run := models.ScheduleRun{ScheduleID: scheduleID, Pipeline: pipeline, Action: "resume"}
+    p, err := s.pipelines.Get(ctx, pipeline)
+    if err != nil {
+        run.Result, run.Detail = "failed", err.Error()
+        s.record(run)
+        return
+    }
+    run.Job = p.JobName
+    switch {
+    case p.DesiredState != "RUNNING":
+        run.Result, run.Detail = "skipped", "desired state is "+p.DesiredState
+    case p.Status != "PAUSED":
+        run.Result, run.Detail = "skipped", "job state is "+p.Status
+    default:
+        if err := s.sr.ResumeRoutineLoad(ctx, p.JobName); err != nil {
+            run.Result, run.Detail = "failed", err.Error()
+        } else {
+            run.Result = "ok"
+        }
+    }
+    s.record(run)
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package services
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "event/api/models"
+    "event/config"
+    "go.uber.org/zap"
+)
+
+func TestWindowAt(t *testing.T) {
+    shanghai, err := time.LoadLocation("Asia/Shanghai")
+    if err != nil {
+        t.Skipf("tzdata unavailable: %v", err)
+    }
+    sc := func(cron string, dur int, tz string, enabled bool) models.PipelineSchedule {
+        return models.PipelineSchedule{Cron: cron, DurationMin: dur, Timezone: tz, Enabled: enabled}
+    }
+    cases := []struct {
+        name string
+        sc   models.PipelineSchedule
+        now  time.Time
+        want bool
+    }{
+        {"start minute", sc("0 2 * * *", 30, "UTC", true), time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC), true},
+        {"inside", sc("0 2 * * *", 30, "UTC", true), time.Date(2024, 3, 4, 2, 29, 59, 0, time.UTC), true},
+        {"end is exclusive", sc("0 2 * * *", 30, "UTC", true), time.Date(2024, 3, 4, 2, 30, 0, 0, time.UTC), false},
+        {"before start", sc("0 2 * * *", 30, "UTC", true), time.Date(2024, 3, 4, 1, 59, 0, 0, time.UTC), false},
+        {"disabled", sc("0 2 * * *", 30, "UTC", false), time.Date(2024, 3, 4, 2, 10, 0, 0, time.UTC), false},
+        {"crosses midnight", sc("50 23 * * *", 20, "UTC", true), time.Date(2024, 3, 5, 0, 5, 0, 0, time.UTC), true},
+        {"weekday only", sc("0 2 * * 1-5", 60, "UTC", true), time.Date(2024, 3, 9, 2, 10, 0, 0, time.UTC), false},
+        // 02:00 上海时间即 UTC 前一日 18:00
+        {"timezone", sc("0 2 * * *", 30, "Asia/Shanghai", true), time.Date(2024, 3, 3, 18, 15, 0, 0, time.UTC), true},
+        {"timezone other day", sc("0 2 * * *", 30, "Asia/Shanghai", true), time.Date(2024, 3, 4, 2, 15, 0, 0, time.UTC), false},
+        {"now in other zone", sc("0 2 * * *", 30, "UTC", true), time.Date(2024, 3, 4, 10, 15, 0, 0, shanghai), true},
+        {"invalid cron", sc("0 2 * *", 30, "UTC", true), time.Date(2024, 3, 4, 2, 10, 0, 0, time.UTC), false},
+        {"invalid timezone", sc("0 2 * * *", 30, "Mars/Olympus", true), time.Date(2024, 3, 4, 2, 10, 0, 0, time.UTC), false},
+    }
+    for _, tc := range cases {
+        if got := windowAt(tc.sc, tc.now); got != tc.want {
+            t.Errorf("%s: windowAt = %v, want %v", tc.name, got, tc.want)
+        }
+    }
+}
+
+func TestSchedulerResumesAfterRestart(t *testing.T) {
+    // StarRocks 指向不可达地址：作业状态未知，不会真正暂停或恢复
+    cfg := config.Config{
+        Storage:   config.StorageConfig{DataDir: t.TempDir()},
+        StarRocks: config.StarRocksConfig{FEHost: "127.0.0.1", FEPort: 1, User: "root", Database: "eventdb"},
+    }
+    store, err := NewStore(cfg)
+    if err != nil { t.Fatal(err) }
+    sr, err := NewStarRocksClient(cfg, zap.NewNop())
+    if err != nil { t.Fatal(err) }
+    defer sr.Close()
+    ctx := context.Background()
+    if _, err := NewPipelineService(cfg, store, sr).Create(ctx, models.Pipeline{Name: "clicks", SourceTopic: "clicks", TargetTable: "clicks"}); err != nil {
+        t.Fatal(err)
+    }
+    sc, err := NewScheduler(cfg, zap.NewNop(), store, sr).Add(ctx, "clicks", models.PipelineSchedule{Cron: "0 2 * * *", DurationMin: 30, Timezone: "UTC", Enabled: true})
+    if err != nil { t.Fatal(err) }
+    // 上一个进程在窗口开始时暂停了作业
+    if err := store.Update(func(d *storeData) error { d.SchedulePauses["clicks"] = sc.ID; return nil }); err != nil { t.Fatal(err) }
+
+    restarted := NewScheduler(cfg, zap.NewNop(), store, sr)
+    restarted.tick(ctx, time.Date(2024, 3, 4, 2, 10, 0, 0, time.UTC))
+    if _, ok := restarted.pauses()["clicks"]; !ok || len(restarted.Runs("", 0)) != 0 {
+        t.Fatalf("inside window: pauses %v, runs %+v, want marker kept and no runs", restarted.pauses(), restarted.Runs("", 0))
+    }
+    restarted.tick(ctx, time.Date(2024, 3, 4, 2, 31, 0, 0, time.UTC))
+    runs := restarted.Runs("", 0)
+    if len(runs) != 1 || runs[0].Action != "resume" || runs[0].ScheduleID != sc.ID || runs[0].Job != "clicks_rl" {
+        t.Fatalf("after window: runs = %+v, want one resume attempt", runs)
+    }
+    if len(restarted.pauses()) != 0 {
+        t.Errorf("after window: pauses = %v, want none", restarted.pauses())
+    }
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+
+    "event/utils"
+)
+
+// 推断出的字段类型
+const (
+    FieldString      = "string"
+    FieldInteger     = "integer"
+    FieldFloat       = "float"
+    FieldBoolean     = "boolean"
+    FieldArray       = "array"
+    FieldObject      = "object"       // 超过展开深度的嵌套对象，整体作为 JSON 列
+    FieldTimestampMs = "timestamp_ms" // 毫秒时间戳，如 ts_ms
+    FieldTimestampS  = "timestamp_s"  // 秒级时间戳
+    FieldDatetime    = "datetime"     // 可解析为时间的字符串
+)
+
+const maxSchemaDepth = 3 // 嵌套对象按 a_b_c 展开的最大层数
+
+// InferredField 为从样本中推断出的单个字段；Path 为 jsonpath，Column 为建议的列名
+type InferredField struct {
+    Path       string `json:"path"`
+    Column     string `json:"column"`
+    Type       string `json:"type"`
+    ColumnType string `json:"column_type"`
+    Presence   int    `json:"presence"` // 出现该字段（非 null）的样本数
+    Nullable   bool   `json:"nullable"`
+    Example    string `json:"example,omitempty"`
+}
+
+// SchemaSuggestion 为主题样本的推断结果，以及据此建议的建表语句与 Routine Load 参数
+type SchemaSuggestion struct {
+    Topic       string          `json:"topic"`
+    Sampled     int             `json:"sampled"`
+    Skipped     int             `json:"skipped"` // 非 JSON 对象的消息数
+    Fields      []InferredField `json:"fields"`
+    Table       TableSpec       `json:"table"`
+    DDL         string          `json:"ddl"`
+    Jsonpaths   string          `json:"jsonpaths"`
+    RoutineLoad RLCreateRequest `json:"routine_load"`
+    Warnings    []string        `json:"warnings,omitempty"`
+}
+
+// orderedObject 保留 JSON 对象的键顺序，使建议的列顺序与消息中的字段顺序一致
+type orderedObject struct {
+    keys []string
+    vals map[string]any
+}
+
+func decodeOrdered(dec *json.Decoder) (any, error) {
+    tok, err := dec.Token()
+    if err != nil { return nil, err }
+    switch t := tok.(type) {
+    case json.Delim:
+        switch t {
+        case '{':
+            obj := &orderedObject{vals: map[string]any{}}
+            for dec.More() {
+                kt, err := dec.Token()
+                if err != nil { return nil, err }
+                k := kt.(string)
+                v, err := decodeOrdered(dec)
+                if err != nil { return nil, err }
+                if _, dup := obj.vals[k]; !dup { obj.keys = append(obj.keys, k) }
+                obj.vals[k] = v
+            }
+            _, err := dec.Token()
+            return obj, err
+        case '[':
+            var arr []any
+            for dec.More() {
+                v, err := decodeOrdered(dec)
+                if err != nil { return nil, err }
+                arr = append(arr, v)
+            }
+            _, err := dec.Token()
+            return arr, err
+        }
+    }
+    return tok, nil
+}
+
+// fieldStat 汇总同一路径在各样本中的取值
+type fieldStat struct {
+    path, name string
+    types      map[string]int
+    present    int
+    maxLen     int
+    example    string
+}
+
+var (
+    jsonKeyRe    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+    timeNameRe   = regexp.MustCompile(`(?i)((^|_)(ts|time|timestamp|at|date)(_ms|_millis|_s|_sec)?|_ms|millis)$`)
+    datetimeFmts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04:05.000"}
+)
+
+// classify 返回单个取值的类型；数值按字段名与取值范围识别时间戳
+func classify(name string, v any) string {
+    switch t := v.(type) {
+    case bool:
+        return FieldBoolean
+    case json.Number:
+        if n, err := t.Int64(); err == nil {
+            if timeNameRe.MatchString(name) {
+                switch {
+                case n >= 1e12 && n < 1e13:
+                    return FieldTimestampMs
+                case n >= 1e9 && n < 1e10:
+                    return FieldTimestampS
+                }
+            }
+            return FieldInteger
+        }
+        return FieldFloat
+    case string:
+        for _, f := range datetimeFmts {
+            if _, err := time.Parse(f, t); err == nil { return FieldDatetime }
+        }
+        return FieldString
+    case []any:
+        return FieldArray
+    case *orderedObject:
+        return FieldObject
+    }
+    return ""
+}
+
+// mergeType 合并多个样本中观察到的类型：整数与浮点合并为浮点，时间戳与整数合并为整数，其余冲突退化为字符串
+func mergeType(types map[string]int) string {
+    if len(types) == 1 {
+        for t := range types { return t }
+    }
+    numeric := true
+    hasFloat := false
+    for t := range types {
+        switch t {
+        case FieldFloat:
+            hasFloat = true
+        case FieldInteger, FieldTimestampMs, FieldTimestampS:
+        default:
+            numeric = false
+        }
+    }
+    switch {
+    case numeric && hasFloat:
+        return FieldFloat
+    case numeric:
+        return FieldInteger
+    }
+    return FieldString
+}
+
+func varcharFor(maxLen int) string {
+    for _, n := range []int{64, 256, 1024} {
+        if maxLen*2 <= n { return fmt.Sprintf("VARCHAR(%d)", n) }
+    }
+    return "VARCHAR(65533)"
+}
+
+func columnTypeFor(typ string, maxLen int) string {
+    switch typ {
+    case FieldInteger, FieldTimestampMs, FieldTimestampS:
+        return "BIGINT"
+    case FieldFloat:
+        return "DOUBLE"
+    case FieldBoolean:
+        return "BOOLEAN"
+    case FieldDatetime:
+        return "DATETIME"
+    case FieldArray, FieldObject:
+        return "JSON"
+    }
+    return varcharFor(maxLen)
+}
+
+// sortKeyType 判断列类型能否作为排序键：浮点、布尔与 JSON 列不能
+func sortKeyType(colType string) bool {
+    switch colType {
+    case "DOUBLE", "FLOAT", "BOOLEAN", "JSON":
+        return false
+    }
+    return true
+}
+
+var nonIdentRe = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// columnName 将 jsonpath 片段转换为列名，如 device.os → device_os
+func columnName(parts []string) string {
+    name := nonIdentRe.ReplaceAllString(strings.ToLower(strings.Join(parts, "_")), "_")
+    if name == "" || (name[0] >= '0' && name[0] <= '9') { name = "f_" + name }
+    if len(name) > 64 { name = name[:64] }
+    return name
+}
+
+type schemaCollector struct {
+    order    []string
+    stats    map[string]*fieldStat
+    warnings map[string]bool
+}
+
+func (c *schemaCollector) walk(obj *orderedObject, prefix []string) {
+    for _, k := range obj.keys {
+        if !jsonKeyRe.MatchString(k) {
+            c.warnings[fmt.Sprintf("field %q skipped: key is not a plain identifier", strings.Join(append(prefix, k), "."))] = true
+            continue
+        }
+        parts := append(append([]string(nil), prefix...), k)
+        v := obj.vals[k]
+        if nested, ok := v.(*orderedObject); ok && len(parts) < maxSchemaDepth {
+            c.walk(nested, parts)
+            continue
+        }
+        path := "$." + strings.Join(parts, ".")
+        st, ok := c.stats[path]
+        if !ok {
+            st = &fieldStat{path: path, name: columnName(parts), types: map[string]int{}}
+            c.stats[path] = st
+            c.order = append(c.order, path)
+        }
+        if v == nil { continue }
+        st.present++
+        st.types[classify(k, v)]++
+        if s, ok := v.(string); ok {
+            st.maxLen = max(st.maxLen, len(s))
+            if st.example == "" { st.example = s }
+        } else if st.example == "" {
+            if b, err := json.Marshal(unorder(v)); err == nil { st.example = string(b) }
+        }
+    }
+}
+
+// unorder 将 orderedObject 转为普通 map，便于序列化示例值
+func unorder(v any) any {
+    switch t := v.(type) {
+    case *orderedObject:
+        m := make(map[string]any, len(t.vals))
+        for k, x := range t.vals { m[k] = unorder(x) }
+        return m
+    case []any:
+        out := make([]any, len(t))
+        for i, x := range t { out[i] = unorder(x) }
+        return out
+    }
+    return v
+}
+
+// InferSchema 从主题最新的 samples 条消息推断字段，并给出建表语句、jsonpaths 与 columns 建议。
+// 约定与 init 脚本一致：首个 *_id 字段与 event_time 作为排序键，毫秒时间戳通过 SET 映射为 event_time
+func (ka *KafkaAdmin) InferSchema(ctx context.Context, topic, table string, samples int) (*SchemaSuggestion, error) {
+    if samples <= 0 { samples = 100 }
+    if table == "" { table = columnName([]string{topic}) }
+    if !utils.ValidIdentifier(table) {
+        return nil, fmt.Errorf("%w: invalid table name %q", utils.ErrInvalid, table)
+    }
+    msgs, err := ka.ReadMessages(ctx, topic, MessageQuery{Partition: -1, Limit: samples})
+    if err != nil { return nil, err }
+    return suggestSchema(topic, table, strings.Join(ka.addrs, ","), msgs)
+}
+
+// suggestSchema 根据已读取的消息生成推断结果
+func suggestSchema(topic, table, brokers string, msgs []KafkaMessage) (*SchemaSuggestion, error) {
+    var err error
+    out := &SchemaSuggestion{Topic: topic, Fields: []InferredField{}}
+    c := &schemaCollector{stats: map[string]*fieldStat{}, warnings: map[string]bool{}}
+    for _, m := range msgs {
+        if m.Encoding != "" || m.Truncated {
+            out.Skipped++
+            continue
+        }
+        dec := json.NewDecoder(bytes.NewReader([]byte(m.Value)))
+        dec.UseNumber()
+        v, derr := decodeOrdered(dec)
+        obj, ok := v.(*orderedObject)
+        if derr != nil || !ok {
+            out.Skipped++
+            continue
+        }
+        out.Sampled++
+        c.walk(obj, nil)
+    }
+    if out.Sampled == 0 {
+        return nil, fmt.Errorf("%w: no JSON object messages in the latest %d of topic %s", utils.ErrInvalid, len(msgs), topic)
+    }
+
+    used := map[string]bool{"event_time": false}
+    var idCol, timeCol, timeExpr string
+    for _, path := range c.order {
+        st := c.stats[path]
+        if st.present == 0 {
+            c.warnings[fmt.Sprintf("field %s skipped: always null", path)] = true
+            continue
+        }
+        name := st.name
+        for i := 2; used[name]; i++ { name = fmt.Sprintf("%s_%d", st.name, i) }
+        used[name] = true
+        typ := mergeType(st.types)
+        f := InferredField{
+            Path: path, Column: name, Type: typ, ColumnType: columnTypeFor(typ, st.maxLen),
+            Presence: st.present, Nullable: st.present < out.Sampled, Example: st.example,
+        }
+        out.Fields = append(out.Fields, f)
+        if idCol == "" && typ != FieldArray && typ != FieldObject && (name == "id" || strings.HasSuffix(name, "_id")) { idCol = name }
+        if timeCol == "" {
+            switch typ {
+            case FieldTimestampMs:
+                timeCol, timeExpr = name, fmt.Sprintf("FROM_UNIXTIME(%s / 1000)", name)
+            case FieldTimestampS:
+                timeCol, timeExpr = name, fmt.Sprintf("FROM_UNIXTIME(%s)", name)
+            case FieldDatetime:
+                timeCol = name
+            }
+        }
+    }
+
+    // 表结构：排序键（id 字段、事件时间）列在前，其余按字段出现顺序
+    var cols []ColumnSpec
+    var keys []string
+    colType := map[string]string{}
+    for _, f := range out.Fields { colType[f.Column] = f.ColumnType }
+    if idCol != "" {
+        cols = append(cols, ColumnSpec{Name: idCol, Type: colType[idCol]})
+        keys = append(keys, idCol)
+    }
+    eventTime := ""
+    switch {
+    case timeExpr != "":
+        eventTime = "event_time"
+        if used["event_time"] { eventTime = "event_time_derived" }
+        cols = append(cols, ColumnSpec{Name: eventTime, Type: "DATETIME"})
+    case timeCol != "":
+        eventTime = timeCol
+        cols = append(cols, ColumnSpec{Name: timeCol, Type: "DATETIME"})
+    }
+    if eventTime != "" { keys = append(keys, eventTime) }
+    paths := make([]string, 0, len(out.Fields))
+    rlCols := make([]string, 0, len(out.Fields))
+    for _, f := range out.Fields {
+        paths = append(paths, f.Path)
+        rlCols = append(rlCols, f.Column)
+        if f.Column == idCol || (timeExpr == "" && f.Column == timeCol) { continue }
+        cols = append(cols, ColumnSpec{Name: f.Column, Type: f.ColumnType})
+    }
+    // 两者都没有时以第一个可作排序键的列为键，并移到最前：DUPLICATE KEY 须为表的前缀列
+    if len(keys) == 0 {
+        for i, col := range cols {
+            if !sortKeyType(col.Type) { continue }
+            keys = []string{col.Name}
+            cols = append([]ColumnSpec{col}, append(cols[:i:i], cols[i+1:]...)...)
+            break
+        }
+        if len(keys) == 0 { c.warnings["no column usable as duplicate key; set table.duplicate_key manually"] = true }
+    }
+    if idCol == "" { c.warnings["no *_id field found; review the duplicate key"] = true }
+    if eventTime == "" { c.warnings["no timestamp field found; table has no event_time column"] = true }
+
+    dist := ""
+    if len(keys) > 0 { dist = keys[0] }
+    out.Table = TableSpec{Name: table, Columns: cols, DuplicateKey: keys, DistributedBy: dist, Buckets: 8}
+    if out.DDL, err = out.Table.BuildDDL(); err != nil {
+        c.warnings[fmt.Sprintf("ddl: %v", err)] = true
+    }
+    jp, _ := json.Marshal(paths)
+    out.Jsonpaths = string(jp)
+    out.RoutineLoad = RLCreateRequest{
+        Name:    table + "_rl",
+        Table:   table,
+        Kafka:   KafkaSource{BrokerList: brokers, Topic: topic, GroupID: defaultGroupID(topic)},
+        Columns: rlCols,
+        Properties: map[string]string{
+            "format":      "json",
+            "jsonpaths":   out.Jsonpaths,
+            "strict_mode": "false",
+        },
+    }
+    if timeExpr != "" { out.RoutineLoad.Set = map[string]string{eventTime: timeExpr} }
+    for w := range c.warnings { out.Warnings = append(out.Warnings, w) }
+    sort.Strings(out.Warnings)
+    return out, nil
+}
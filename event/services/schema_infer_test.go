@@ -0,0 +1,129 @@
+package services
+
+import (
+    "errors"
+    "reflect"
+    "strings"
+    "testing"
+
+    "event/utils"
+)
+
+func jsonMessages(values ...string) []KafkaMessage {
+    out := make([]KafkaMessage, len(values))
+    for i, v := range values { out[i] = KafkaMessage{Partition: 0, Offset: int64(i), Value: v} }
+    return out
+}
+
+func TestSuggestSchema(t *testing.T) {
+    type col struct{ name, typ string }
+    cases := []struct {
+        name     string
+        msgs     []KafkaMessage
+        cols     []col
+        keys     []string
+        set      map[string]string
+        paths    string
+        sampled  int
+        skipped  int
+        warnings []string
+    }{
+        {
+            name: "clickstream",
+            msgs: jsonMessages(
+                `{"user_id": 1, "page": "/home", "ts_ms": 1700000000000, "device": {"os": "ios"}}`,
+                `{"user_id": 2, "page": "/cart", "ts_ms": 1700000001000, "device": {"os": "android"}, "price": 9.5}`,
+            ),
+            cols:    []col{{"user_id", "BIGINT"}, {"event_time", "DATETIME"}, {"page", "VARCHAR(64)"}, {"ts_ms", "BIGINT"}, {"device_os", "VARCHAR(64)"}, {"price", "DOUBLE"}},
+            keys:    []string{"user_id", "event_time"},
+            set:     map[string]string{"event_time": "FROM_UNIXTIME(ts_ms / 1000)"},
+            paths:   `["$.user_id","$.page","$.ts_ms","$.device.os","$.price"]`,
+            sampled: 2,
+        },
+        {
+            name: "datetime string and skipped messages",
+            msgs: append(jsonMessages(
+                `{"order_id": "A1", "created_at": "2024-03-04 12:00:00", "items": [1, 2]}`,
+                `[1, 2, 3]`,
+                `not json`,
+            ), KafkaMessage{Value: "AAEC", Encoding: "base64"}),
+            cols:    []col{{"order_id", "VARCHAR(64)"}, {"created_at", "DATETIME"}, {"items", "JSON"}},
+            keys:    []string{"order_id", "created_at"},
+            paths:   `["$.order_id","$.created_at","$.items"]`,
+            sampled: 1,
+            skipped: 3,
+        },
+        {
+            name:     "no id and no timestamp",
+            msgs:     jsonMessages(`{"tags": ["a"], "name": "x", "gone": null}`),
+            cols:     []col{{"name", "VARCHAR(64)"}, {"tags", "JSON"}},
+            keys:     []string{"name"},
+            paths:    `["$.tags","$.name"]`,
+            sampled:  1,
+            warnings: []string{"field $.gone skipped: always null", "no *_id field found; review the duplicate key", "no timestamp field found; table has no event_time column"},
+        },
+        {
+            name:     "fallback key skips float and boolean",
+            msgs:     jsonMessages(`{"score": 1.5, "ok": true, "meta": {"a": [1]}, "label": "x"}`),
+            cols:     []col{{"label", "VARCHAR(64)"}, {"score", "DOUBLE"}, {"ok", "BOOLEAN"}, {"meta_a", "JSON"}},
+            keys:     []string{"label"},
+            paths:    `["$.score","$.ok","$.meta.a","$.label"]`,
+            sampled:  1,
+            warnings: []string{"no *_id field found; review the duplicate key", "no timestamp field found; table has no event_time column"},
+        },
+        {
+            name:     "no usable key",
+            msgs:     jsonMessages(`{"score": 1.5, "ok": false}`),
+            cols:     []col{{"score", "DOUBLE"}, {"ok", "BOOLEAN"}},
+            paths:    `["$.score","$.ok"]`,
+            sampled:  1,
+            warnings: []string{"no *_id field found; review the duplicate key", "no column usable as duplicate key; set table.duplicate_key manually", "no timestamp field found; table has no event_time column"},
+        },
+    }
+    for _, tc := range cases {
+        got, err := suggestSchema("page_views", "page_views", "kafka:9092", tc.msgs)
+        if err != nil {
+            t.Errorf("%s: %v", tc.name, err)
+            continue
+        }
+        var cols []col
+        for _, c := range got.Table.Columns { cols = append(cols, col{c.Name, c.Type}) }
+        if !reflect.DeepEqual(cols, tc.cols) {
+            t.Errorf("%s: columns = %v, want %v", tc.name, cols, tc.cols)
+        }
+        if !reflect.DeepEqual(got.Table.DuplicateKey, tc.keys) {
+            t.Errorf("%s: duplicate key = %v, want %v", tc.name, got.Table.DuplicateKey, tc.keys)
+        }
+        if len(tc.keys) > 0 && (got.Table.DistributedBy != tc.keys[0] || got.Table.Columns[0].Name != tc.keys[0]) {
+            t.Errorf("%s: distributed by %s, first column %s, want %s", tc.name, got.Table.DistributedBy, got.Table.Columns[0].Name, tc.keys[0])
+        }
+        if len(got.Table.Properties) != 0 {
+            t.Errorf("%s: table properties = %v, want none", tc.name, got.Table.Properties)
+        }
+        if !reflect.DeepEqual(got.RoutineLoad.Set, tc.set) {
+            t.Errorf("%s: set = %v, want %v", tc.name, got.RoutineLoad.Set, tc.set)
+        }
+        if got.Jsonpaths != tc.paths || got.RoutineLoad.Properties["jsonpaths"] != tc.paths {
+            t.Errorf("%s: jsonpaths = %s, want %s", tc.name, got.Jsonpaths, tc.paths)
+        }
+        if got.Sampled != tc.sampled || got.Skipped != tc.skipped {
+            t.Errorf("%s: sampled/skipped = %d/%d, want %d/%d", tc.name, got.Sampled, got.Skipped, tc.sampled, tc.skipped)
+        }
+        if !reflect.DeepEqual(got.Warnings, tc.warnings) {
+            t.Errorf("%s: warnings = %q, want %q", tc.name, got.Warnings, tc.warnings)
+        }
+        if !strings.HasPrefix(got.DDL, "CREATE TABLE IF NOT EXISTS page_views") {
+            t.Errorf("%s: ddl = %q", tc.name, got.DDL)
+        }
+        if got.RoutineLoad.Name != "page_views_rl" || got.RoutineLoad.Kafka.GroupID != "sr-page_views" {
+            t.Errorf("%s: routine load = %+v", tc.name, got.RoutineLoad)
+        }
+    }
+}
+
+func TestSuggestSchemaNoObjects(t *testing.T) {
+    _, err := suggestSchema("t", "t", "kafka:9092", jsonMessages(`1`, `"x"`, `[]`))
+    if !errors.Is(err, utils.ErrInvalid) {
+        t.Fatalf("err = %v, want ErrInvalid", err)
+    }
+}
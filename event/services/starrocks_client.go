@@ -12,23 +12,50 @@ import (
     "time"
 
     "event/config"
+    "event/utils"
     _ "github.com/go-sql-driver/mysql"
+    "go.uber.org/zap"
 )
 
+// StarRocksClient 通过 MySQL 协议访问 FE，持有一个长期存活的连接池；进程内应只创建一个并在退出时 Close
 type StarRocksClient struct {
-    cfg config.Config
+    cfg    config.Config
+    logger *zap.Logger
+    db     *sql.DB
 }
 
-func NewStarRocksClient(cfg config.Config) *StarRocksClient {
-    return &StarRocksClient{cfg: cfg}
+// NewStarRocksClient 按 starrocks.pool 配置创建连接池（sql.Open 不会立即建立连接）
+func NewStarRocksClient(cfg config.Config, logger *zap.Logger) (*StarRocksClient, error) {
+    db, err := sql.Open("mysql", (&StarRocksClient{cfg: cfg}).dsn())
+    if err != nil { return nil, err }
+    p := cfg.StarRocks.Pool
+    if p.MaxOpenConns > 0 { db.SetMaxOpenConns(p.MaxOpenConns) }
+    if p.MaxIdleConns > 0 { db.SetMaxIdleConns(p.MaxIdleConns) }
+    if p.ConnMaxLifetimeSec > 0 { db.SetConnMaxLifetime(time.Duration(p.ConnMaxLifetimeSec) * time.Second) }
+    return &StarRocksClient{cfg: cfg, logger: logger, db: db}, nil
 }
 
+// Close 关闭连接池
+func (c *StarRocksClient) Close() error { return c.db.Close() }
+
 type RLJob struct {
     Name  string `json:"name"`
     State string `json:"state"`
     Table string `json:"table"`
+    Topic string `json:"topic,omitempty"`
     Processed int `json:"processed"`
     Errors    int `json:"errors"`
+    Progress  []RLPartitionProgress `json:"progress,omitempty"`
+    Lag       *int64                `json:"lag,omitempty"` // 各分区积压之和，高水位未知时为空
+}
+
+// RLPartitionProgress 为作业在单个分区上的消费进度；Offset 为已消费的最后一个 offset，-1 表示尚未消费。
+// HighWatermark 与 Lag 由 KafkaAdmin.FillRoutineLoadLag 补齐
+type RLPartitionProgress struct {
+    Partition     int    `json:"partition"`
+    Offset        int64  `json:"offset"`
+    HighWatermark *int64 `json:"high_watermark,omitempty"`
+    Lag           *int64 `json:"lag,omitempty"`
 }
 
 // RLDetails 描述 Routine Load 的详细配置
@@ -55,11 +82,8 @@ func (c *StarRocksClient) dsn() string {
 
 // ListEventTables 返回包含 event_time 列的所有表名
 func (c *StarRocksClient) ListEventTables(ctx context.Context) ([]string, error) {
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return nil, err }
-    defer db.Close()
     q := "SELECT TABLE_NAME FROM information_schema.columns WHERE TABLE_SCHEMA = ? AND COLUMN_NAME = 'event_time' GROUP BY TABLE_NAME"
-    rows, err := db.QueryContext(ctx, q, c.cfg.StarRocks.Database)
+    rows, err := c.db.QueryContext(ctx, q, c.cfg.StarRocks.Database)
     if err != nil { return nil, err }
     defer rows.Close()
     var out []string
@@ -76,14 +100,11 @@ func (c *StarRocksClient) ListEventTables(ctx context.Context) ([]string, error)
 // CountRowsLastMinutes 统计指定表集合在最近 minutes 分钟内的总行数
 func (c *StarRocksClient) CountRowsLastMinutes(ctx context.Context, tables []string, minutes int) (int, error) {
     if minutes < 1 { minutes = 1 }
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return 0, err }
-    defer db.Close()
     total := 0
     for _, t := range tables {
         q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE event_time >= NOW() - INTERVAL %d MINUTE", t, minutes)
         var cnt int
-        if err := db.QueryRowContext(ctx, q).Scan(&cnt); err != nil {
+        if err := c.db.QueryRowContext(ctx, q).Scan(&cnt); err != nil {
             // 忽略单表错误，继续其他表
             continue
         }
@@ -95,25 +116,19 @@ func (c *StarRocksClient) CountRowsLastMinutes(ctx context.Context, tables []str
 // CountErrorsLastMinutes 统计 errors 表最近 minutes 分钟的错误行数
 func (c *StarRocksClient) CountErrorsLastMinutes(ctx context.Context, minutes int) (int, error) {
     if minutes < 1 { minutes = 1 }
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return 0, err }
-    defer db.Close()
     q := fmt.Sprintf("SELECT COUNT(*) FROM errors WHERE event_time >= NOW() - INTERVAL %d MINUTE", minutes)
     var cnt int
-    if err := db.QueryRowContext(ctx, q).Scan(&cnt); err != nil { return 0, nil }
+    if err := c.db.QueryRowContext(ctx, q).Scan(&cnt); err != nil { return 0, nil }
     return cnt, nil
 }
 
 // ComputeFreshnessLagMs 计算当前数据新鲜度延迟：now - max(event_time)（跨所有表）
 func (c *StarRocksClient) ComputeFreshnessLagMs(ctx context.Context, tables []string) (int, error) {
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return 0, err }
-    defer db.Close()
     latestSec := int64(0)
     for _, t := range tables {
         q := fmt.Sprintf("SELECT UNIX_TIMESTAMP(MAX(event_time)) FROM %s", t)
         var sec sql.NullInt64
-        if err := db.QueryRowContext(ctx, q).Scan(&sec); err != nil { continue }
+        if err := c.db.QueryRowContext(ctx, q).Scan(&sec); err != nil { continue }
         if sec.Valid && sec.Int64 > latestSec { latestSec = sec.Int64 }
     }
     if latestSec == 0 { return 0, nil }
@@ -123,60 +138,107 @@ func (c *StarRocksClient) ComputeFreshnessLagMs(ctx context.Context, tables []st
     return lagMs, nil
 }
 
-func (c *StarRocksClient) ListRoutineLoad(ctx context.Context) ([]RLJob, error) {
-    db, err := sql.Open("mysql", c.dsn())
-    if err != nil {
-        return nil, err
-    }
-    defer db.Close()
+// LatestEventTime 返回表中最新的 event_time；表中没有数据时返回 nil
+func (c *StarRocksClient) LatestEventTime(ctx context.Context, table string) (*time.Time, error) {
+    var sec sql.NullInt64
+    q := fmt.Sprintf("SELECT UNIX_TIMESTAMP(MAX(event_time)) FROM %s", table)
+    if err := c.db.QueryRowContext(ctx, q).Scan(&sec); err != nil { return nil, err }
+    if !sec.Valid { return nil, nil }
+    t := time.Unix(sec.Int64, 0)
+    return &t, nil
+}
 
-    rows, err := db.QueryContext(ctx, "SHOW ROUTINE LOAD FROM "+c.cfg.StarRocks.Database)
-    if err != nil {
-        return nil, err
-    }
+// scanRoutineLoad 执行 SHOW ROUTINE LOAD，逐行回调（列名 → 值），fn 返回 false 时停止；
+// 返回前释放连接，调用方可以紧接着执行下一条查询
+func (c *StarRocksClient) scanRoutineLoad(ctx context.Context, fn func(kv map[string]string) bool) error {
+    rows, err := c.db.QueryContext(ctx, "SHOW ROUTINE LOAD FROM "+c.cfg.StarRocks.Database)
+    if err != nil { return err }
     defer rows.Close()
-
     cols, err := rows.Columns()
-    if err != nil {
-        return nil, err
-    }
+    if err != nil { return err }
     raw := make([]sql.RawBytes, len(cols))
     scan := make([]interface{}, len(cols))
     for i := range raw { scan[i] = &raw[i] }
+    for rows.Next() {
+        if err := rows.Scan(scan...); err != nil { return err }
+        kv := make(map[string]string, len(cols))
+        for i, col := range cols { kv[strings.TrimSpace(col)] = string(raw[i]) }
+        if !fn(kv) { break }
+    }
+    return rows.Err()
+}
+
+// routineLoadRow 返回 SHOW ROUTINE LOAD 中指定作业的整行，作业不存在时返回 ErrNotFound
+func (c *StarRocksClient) routineLoadRow(ctx context.Context, name string) (map[string]string, error) {
+    var row map[string]string
+    err := c.scanRoutineLoad(ctx, func(kv map[string]string) bool {
+        if kv["Name"] == name { row = kv }
+        return row == nil
+    })
+    if err != nil { return nil, err }
+    if row == nil { return nil, fmt.Errorf("routine load %s: %w", name, utils.ErrNotFound) }
+    return row, nil
+}
 
+func (c *StarRocksClient) ListRoutineLoad(ctx context.Context) ([]RLJob, error) {
     var out []RLJob
-    for rows.Next() {
-        if err := rows.Scan(scan...); err != nil { return nil, err }
-        var job RLJob
-        for i, c := range cols {
-            key := strings.TrimSpace(c)
-            val := string(raw[i])
-            switch key {
-            case "Name":
-                job.Name = val
-            case "State":
-                job.State = val
-            case "TableName":
-                job.Table = val
-            case "Statistic", "STATISTIC":
-                // 解析统计文本，提取 loaded/success 与 error 行数
-                p, e := parseStatisticCounts(val)
-                if p >= 0 { job.Processed = p }
-                if e >= 0 { job.Errors = e }
-            default:
-                // 尝试识别可能存在的计数列（不同版本列名可能不同）
-                up := strings.ToUpper(key)
-                if strings.Contains(up, "SUCCESS") || strings.Contains(up, "LOADED") || strings.Contains(up, "PROCESSED") {
-                    if iv, err := strconv.Atoi(strings.TrimSpace(val)); err == nil { job.Processed = iv }
-                } else if strings.Contains(up, "ERROR") && strings.Contains(up, "ROW") {
-                    if iv, err := strconv.Atoi(strings.TrimSpace(val)); err == nil { job.Errors = iv }
-                }
-            }
+    err := c.scanRoutineLoad(ctx, func(kv map[string]string) bool {
+        out = append(out, rlJobFromRow(kv))
+        return true
+    })
+    if err != nil { return nil, err }
+    return out, nil
+}
+
+// rlJobFromRow 由 SHOW ROUTINE LOAD 的一行生成作业摘要
+func rlJobFromRow(kv map[string]string) RLJob {
+    job := RLJob{Name: kv["Name"], State: kv["State"], Table: kv["TableName"]}
+    if v, ok := kv["DataSourceProperties"]; ok { job.Topic = parseProps(v)["topic"] }
+    if v, ok := kv["Progress"]; ok { job.Progress = parseRLProgress(v) }
+    for key, val := range kv {
+        // 尝试识别可能存在的计数列（不同版本列名可能不同）
+        up := strings.ToUpper(key)
+        if up == "STATISTIC" { continue }
+        if strings.Contains(up, "SUCCESS") || strings.Contains(up, "LOADED") || strings.Contains(up, "PROCESSED") {
+            if iv, err := strconv.Atoi(strings.TrimSpace(val)); err == nil { job.Processed = iv }
+        } else if strings.Contains(up, "ERROR") && strings.Contains(up, "ROW") {
+            if iv, err := strconv.Atoi(strings.TrimSpace(val)); err == nil { job.Errors = iv }
         }
-        out = append(out, job)
     }
-    if err := rows.Err(); err != nil { return nil, err }
-    return out, nil
+    for _, key := range []string{"Statistic", "STATISTIC"} {
+        v, ok := kv[key]
+        if !ok { continue }
+        // 解析统计文本，提取 loaded/success 与 error 行数，优先于其他计数列
+        p, e := parseStatisticCounts(v)
+        if p >= 0 { job.Processed = p }
+        if e >= 0 { job.Errors = e }
+    }
+    return job
+}
+
+// parseRLProgress 解析 Progress 列，如 {"0":"1234","1":"OFFSET_BEGINNING"}。
+// OFFSET_BEGINNING / OFFSET_ZERO 视为尚未消费（-1，积压按高水位减低水位计）；OFFSET_END 表示从末尾开始、尚无可比较的位置，不计入。
+// 任务列表的 DataSourceProperties 形如 {"0":1234}，offset 为数字，同样可以解析
+func parseRLProgress(s string) []RLPartitionProgress {
+    var m map[string]any
+    dec := json.NewDecoder(strings.NewReader(strings.TrimSpace(s)))
+    dec.UseNumber()
+    if err := dec.Decode(&m); err != nil { return nil }
+    out := make([]RLPartitionProgress, 0, len(m))
+    for k, raw := range m {
+        part, err := strconv.Atoi(strings.TrimSpace(k))
+        if err != nil { continue }
+        var off int64
+        switch v := strings.TrimSpace(fmt.Sprint(raw)); v {
+        case "OFFSET_BEGINNING", "OFFSET_ZERO":
+            off = -1
+        default:
+            if off, err = strconv.ParseInt(v, 10, 64); err != nil { continue }
+        }
+        out = append(out, RLPartitionProgress{Partition: part, Offset: off})
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Partition < out[j].Partition })
+    return out
 }
 
 // parseProps 尝试解析属性字符串为键值对，兼容 JSON 或 key=value 形式
@@ -215,50 +277,23 @@ func parseProps(s string) map[string]string {
 // GetRoutineLoadDetails 返回指定作业的详细配置
 func (c *StarRocksClient) GetRoutineLoadDetails(ctx context.Context, name string) (*RLDetails, error) {
     if strings.TrimSpace(name) == "" { return nil, fmt.Errorf("empty name") }
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return nil, err }
-    defer db.Close()
 
-    rows, err := db.QueryContext(ctx, "SHOW ROUTINE LOAD FROM "+c.cfg.StarRocks.Database)
+    kv, err := c.routineLoadRow(ctx, name)
     if err != nil { return nil, err }
-    defer rows.Close()
-    cols, err := rows.Columns(); if err != nil { return nil, err }
-    raw := make([]sql.RawBytes, len(cols))
-    scan := make([]interface{}, len(cols))
-    for i := range raw { scan[i] = &raw[i] }
-    var detail RLDetails
-    var found bool
-    for rows.Next() {
-        if err := rows.Scan(scan...); err != nil { return nil, err }
-        kv := map[string]string{}
-        for i, c := range cols {
-            key := strings.TrimSpace(c)
-            val := string(raw[i])
-            kv[key] = val
-            switch key {
-            case "Name":
-                if val == name { found = true; detail.Name = val }
-            case "State":
-                detail.State = val
-            case "TableName":
-                detail.Table = val
-            case "JobProperties", "Properties":
-                if detail.Properties == nil { detail.Properties = map[string]string{} }
-                for k, v := range parseProps(val) { detail.Properties[k] = v }
-            case "DataSourceProperties":
-                if detail.Kafka == nil { detail.Kafka = map[string]string{} }
-                for k, v := range parseProps(val) { detail.Kafka[k] = v }
-            }
-        }
-        if found { break }
+    detail := RLDetails{Name: kv["Name"], State: kv["State"], Table: kv["TableName"]}
+    for _, key := range []string{"JobProperties", "Properties"} {
+        v, ok := kv[key]
+        if !ok { continue }
+        if detail.Properties == nil { detail.Properties = map[string]string{} }
+        for k, pv := range parseProps(v) { detail.Properties[k] = pv }
     }
-    if !found { return nil, fmt.Errorf("routine load not found: %s", name) }
+    if v, ok := kv["DataSourceProperties"]; ok { detail.Kafka = parseProps(v) }
 
     // 进一步获取 CREATE 语句（部分版本支持）
     // 如果失败则忽略，仅返回其他字段
     func() {
         q := fmt.Sprintf("SHOW CREATE ROUTINE LOAD FOR %s", name)
-        rows2, err2 := db.QueryContext(ctx, q)
+        rows2, err2 := c.db.QueryContext(ctx, q)
         if err2 != nil { return }
         defer rows2.Close()
         cols2, errc := rows2.Columns(); if errc != nil { return }
@@ -347,18 +382,53 @@ func toInt(v any) int {
 
 // RLCreateRequest 用于创建 Routine Load 作业的必要参数
 type RLCreateRequest struct {
-    Name   string            `json:"name"`
-    Table  string            `json:"table"`
-    Kafka  KafkaSource       `json:"kafka"`
-    Columns []string         `json:"columns"`
-    Set    map[string]string `json:"set"`
-    Properties map[string]string `json:"properties"`
+    Name   string            `json:"name" yaml:"name"`
+    Table  string            `json:"table" yaml:"table"`
+    Kafka  KafkaSource       `json:"kafka" yaml:"kafka"`
+    Columns []string         `json:"columns" yaml:"columns"`
+    Set    map[string]string `json:"set" yaml:"set"`
+    Properties map[string]string `json:"properties" yaml:"properties"`
 }
 
 type KafkaSource struct {
-    BrokerList string `json:"broker_list"`
-    Topic      string `json:"topic"`
-    GroupID    string `json:"group_id"`
+    BrokerList string `json:"broker_list" yaml:"broker_list"`
+    Topic      string `json:"topic" yaml:"topic"`
+    GroupID    string `json:"group_id" yaml:"group_id"`
+    // 可选：指定消费的分区及各分区的起始位置（数字或 OFFSET_BEGINNING / OFFSET_END），与 Partitions 一一对应
+    Partitions []int    `json:"partitions,omitempty" yaml:"partitions,omitempty"`
+    Offsets    []string `json:"offsets,omitempty" yaml:"offsets,omitempty"`
+}
+
+// defaultGroupID 返回未指定 group_id 时作业使用的消费组 sr-<topic>
+func defaultGroupID(topic string) string { return "sr-" + topic }
+
+// kafkaOffsetProps 校验并生成 kafka_partitions / kafka_offsets 两个数据源属性；未指定分区时返回空
+func kafkaOffsetProps(parts []int, offsets []string) ([]string, error) {
+    if len(parts) == 0 {
+        if len(offsets) > 0 { return nil, fmt.Errorf("%w: offsets require partitions", utils.ErrInvalid) }
+        return nil, nil
+    }
+    if len(offsets) > 0 && len(offsets) != len(parts) {
+        return nil, fmt.Errorf("%w: %d offsets for %d partitions", utils.ErrInvalid, len(offsets), len(parts))
+    }
+    ps := make([]string, len(parts))
+    for i, p := range parts {
+        if p < 0 { return nil, fmt.Errorf("%w: invalid partition %d", utils.ErrInvalid, p) }
+        ps[i] = strconv.Itoa(p)
+    }
+    props := []string{fmt.Sprintf("\"kafka_partitions\" = \"%s\"", strings.Join(ps, ","))}
+    if len(offsets) == 0 { return props, nil }
+    offs := make([]string, len(offsets))
+    for i, o := range offsets {
+        o = strings.ToUpper(strings.TrimSpace(o))
+        if o != "OFFSET_BEGINNING" && o != "OFFSET_END" {
+            if n, err := strconv.ParseInt(o, 10, 64); err != nil || n < 0 {
+                return nil, fmt.Errorf("%w: invalid offset %q", utils.ErrInvalid, offsets[i])
+            }
+        }
+        offs[i] = o
+    }
+    return append(props, fmt.Sprintf("\"kafka_offsets\" = \"%s\"", strings.Join(offs, ","))), nil
 }
 
 // CreateRoutineLoad 根据请求参数拼装 CREATE ROUTINE LOAD 并执行
@@ -398,15 +468,9 @@ func (c *StarRocksClient) CreateRoutineLoad(ctx context.Context, req RLCreateReq
     props := make([]string, 0, len(req.Properties))
     for k, v := range req.Properties {
         if !allowedProps[k] { continue }
-        // 规范化下限：max_batch_rows >= 200000
-        if k == "max_batch_rows" {
-            if iv, err := strconv.Atoi(v); err != nil || iv < 200000 {
-                v = "200000"
-            }
-        }
+        v = normalizeRLProperty(k, v)
         // 统一使用双引号包裹值
-        vv := strings.ReplaceAll(v, "\"", "\\\"")
-        props = append(props, fmt.Sprintf("\"%s\" = \"%s\"", k, vv))
+        props = append(props, fmt.Sprintf("\"%s\" = %s", k, sqlQuote(v)))
     }
     sort.Strings(props)
     propClause := ""
@@ -414,34 +478,85 @@ func (c *StarRocksClient) CreateRoutineLoad(ctx context.Context, req RLCreateReq
         propClause = fmt.Sprintf("\nPROPERTIES (\n  %s\n)", strings.Join(props, ",\n  "))
     }
 
-    // 组装 FROM KAFKA
-    fromKafka := fmt.Sprintf("\nFROM KAFKA (\n  \"kafka_broker_list\" = \"%s\",\n  \"kafka_topic\" = \"%s\",\n  \"property.group.id\" = \"%s\"\n)",
-        req.Kafka.BrokerList, req.Kafka.Topic, req.Kafka.GroupID,
-    )
-
-    // 完整 SQL
-    sql := fmt.Sprintf("CREATE ROUTINE LOAD %s\nON %s\nCOLUMNS(%s)%s%s%s",
-        req.Name,
-        req.Table,
-        cols,
-        setClause,
-        propClause,
-        fromKafka,
-    )
-
-    db, err := sqlOpen(c.dsn())
+    // 组装 FROM KAFKA，指定了分区与起始位置时一并写入
+    offsetProps, err := kafkaOffsetProps(req.Kafka.Partitions, req.Kafka.Offsets)
     if err != nil { return err }
-    defer db.Close()
-    // 调试输出 SQL，便于排查语法错误
-    fmt.Println("[sr.create_rl] SQL =>\n" + sql)
-    if _, err := db.ExecContext(ctx, sql); err != nil { return err }
+    source := append([]string{
+        fmt.Sprintf("\"kafka_broker_list\" = %s", sqlQuote(req.Kafka.BrokerList)),
+        fmt.Sprintf("\"kafka_topic\" = %s", sqlQuote(req.Kafka.Topic)),
+        fmt.Sprintf("\"property.group.id\" = %s", sqlQuote(req.Kafka.GroupID)),
+    }, offsetProps...)
+    // 集群启用了 SASL/TLS 时透传 property.security.protocol、property.sasl.* 等属性
+    secProps := RoutineLoadSecurityProperties(c.cfg.Kafka)
+    secKeys := make([]string, 0, len(secProps))
+    for k := range secProps { secKeys = append(secKeys, k) }
+    sort.Strings(secKeys)
+    // build 按给定的安全属性生成完整 SQL；日志使用屏蔽了密码的属性单独生成
+    build := func(sec map[string]string) string {
+        src := append([]string(nil), source...)
+        for _, k := range secKeys {
+            src = append(src, fmt.Sprintf("\"%s\" = %s", k, sqlQuote(sec[k])))
+        }
+        fromKafka := fmt.Sprintf("\nFROM KAFKA (\n  %s\n)", strings.Join(src, ",\n  "))
+        return fmt.Sprintf("CREATE ROUTINE LOAD %s\nON %s\nCOLUMNS(%s)%s%s%s",
+            req.Name,
+            req.Table,
+            cols,
+            setClause,
+            propClause,
+            fromKafka,
+        )
+    }
+    sql := build(secProps)
+
+    // 调试输出 SQL，便于排查语法错误；SASL 密码不输出
+    masked := make(map[string]string, len(secProps))
+    for k, v := range secProps { masked[k] = v }
+    if _, ok := masked["property.sasl.password"]; ok { masked["property.sasl.password"] = "******" }
+    c.logger.Debug("sr.create_rl", zap.String("sql", build(masked)))
+    if _, err := c.db.ExecContext(ctx, sql); err != nil { return err }
     return nil
 }
 
-// UpdateRoutineLoadProperties 通过 ALTER ROUTINE LOAD 更新作业属性（白名单）
-func (c *StarRocksClient) UpdateRoutineLoadProperties(ctx context.Context, name string, props map[string]string) error {
-    if strings.TrimSpace(name) == "" { return fmt.Errorf("empty name") }
-    if len(props) == 0 { return fmt.Errorf("no properties to update") }
+// sqlQuote 以双引号包裹字符串字面量，并转义其中的反斜杠与双引号
+func sqlQuote(s string) string {
+    return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s) + "\""
+}
+
+// normalizeRLProperty 对创建作业时的属性值做规范化：max_batch_rows 下限为 200000
+func normalizeRLProperty(k, v string) string {
+    if k == "max_batch_rows" {
+        if iv, err := strconv.Atoi(v); err != nil || iv < 200000 {
+            return "200000"
+        }
+    }
+    return v
+}
+
+// rlPropertyAliases 记录创建属性名在 SHOW ROUTINE LOAD 的 JobProperties 中可能的键名
+var rlPropertyAliases = map[string][]string{
+    "desired_concurrent_number": {"desireTaskConcurrentNum", "desired_concurrent_number"},
+    "max_batch_interval":        {"maxBatchIntervalS", "max_batch_interval"},
+    "max_batch_rows":            {"maxBatchRows", "max_batch_rows"},
+    "max_batch_size":            {"maxBatchSizeBytes", "max_batch_size"},
+    "max_error_number":          {"maxErrorNum", "max_error_number"},
+}
+
+// ActualProperty 按创建时的属性名读取作业当前的属性值
+func (d *RLDetails) ActualProperty(key string) (string, bool) {
+    names := rlPropertyAliases[key]
+    if len(names) == 0 { names = []string{key} }
+    for _, n := range names {
+        if v, ok := d.Properties[n]; ok { return v, true }
+    }
+    return "", false
+}
+
+// UpdateRoutineLoadProperties 通过 ALTER ROUTINE LOAD 更新作业属性（白名单），返回实际写入的属性；
+// 白名单之外的键被忽略
+func (c *StarRocksClient) UpdateRoutineLoadProperties(ctx context.Context, name string, props map[string]string) (map[string]string, error) {
+    if strings.TrimSpace(name) == "" { return nil, fmt.Errorf("empty name") }
+    if len(props) == 0 { return nil, fmt.Errorf("no properties to update") }
     // 允许更新的属性白名单（按用户需求）
     allowed := map[string]bool{
         "desired_concurrent_number": true,
@@ -456,6 +571,7 @@ func (c *StarRocksClient) UpdateRoutineLoadProperties(ctx context.Context, name
         "timezone": true,
     }
     pairs := make([]string, 0, len(props))
+    applied := map[string]string{}
     // 排序保证生成 SQL 可预期
     keys := make([]string, 0, len(props))
     for k := range props { keys = append(keys, k) }
@@ -463,46 +579,288 @@ func (c *StarRocksClient) UpdateRoutineLoadProperties(ctx context.Context, name
     for _, k := range keys {
         v := strings.TrimSpace(props[k])
         if !allowed[k] { continue }
+        applied[k] = v
         // 统一转义单引号，避免语法错误
         v = strings.ReplaceAll(v, "'", "''")
         pairs = append(pairs, fmt.Sprintf("'%s' = '%s'", k, v))
     }
-    if len(pairs) == 0 { return fmt.Errorf("no valid properties") }
+    if len(pairs) == 0 { return nil, fmt.Errorf("%w: no valid properties", utils.ErrInvalid) }
     q := fmt.Sprintf("ALTER ROUTINE LOAD FOR %s PROPERTIES ( %s )", name, strings.Join(pairs, ", "))
-    db, err := sqlOpen(c.dsn())
+    if _, err := c.db.ExecContext(ctx, q); err != nil { return nil, err }
+    return applied, nil
+}
+
+// AlterOutcome 记录"暂停 → ALTER → 恢复"流程中各步骤的结果
+type AlterOutcome struct {
+    Before    *RLDetails        // 修改前的作业详情，查询失败时为 nil
+    Applied   map[string]string // AlterWithPause 实际写入的属性
+    Paused    bool
+    Resumed   bool
+    PauseErr  error
+    ResumeErr error
+}
+
+// AlterRoutineLoadOffsets 通过 ALTER ROUTINE LOAD ... FROM KAFKA 修改作业各分区的消费起点，作业须处于 PAUSED 状态
+func (c *StarRocksClient) AlterRoutineLoadOffsets(ctx context.Context, name string, parts []int, offsets []string) error {
+    if strings.TrimSpace(name) == "" { return fmt.Errorf("empty name") }
+    if len(offsets) == 0 { return fmt.Errorf("%w: no offsets to alter", utils.ErrInvalid) }
+    props, err := kafkaOffsetProps(parts, offsets)
     if err != nil { return err }
-    defer db.Close()
-    _, err = db.ExecContext(ctx, q)
+    q := fmt.Sprintf("ALTER ROUTINE LOAD FOR %s FROM KAFKA ( %s )", name, strings.Join(props, ", "))
+    _, err = c.db.ExecContext(ctx, q)
     return err
 }
 
-// sqlOpen 抽象 sql.Open 以便在本文件中使用 strings 包
-func sqlOpen(dsn string) (*sql.DB, error) { return sql.Open("mysql", dsn) }
+// AlterWithPause 按官方要求仅在 PAUSED 状态下修改属性：非 PAUSED 时先暂停，修改后（无论成功与否）若原状态为 RUNNING 则自动恢复。
+// 查询状态失败时仍尝试直接修改（可能被 FE 拒绝）。
+func (c *StarRocksClient) AlterWithPause(ctx context.Context, name string, props map[string]string) (AlterOutcome, error) {
+    var applied map[string]string
+    out, err := c.withPause(ctx, name, func() (err error) {
+        applied, err = c.UpdateRoutineLoadProperties(ctx, name, props)
+        return err
+    })
+    out.Applied = applied
+    return out, err
+}
+
+// SeekWithPause 以与 AlterWithPause 相同的流程修改作业的消费起点
+func (c *StarRocksClient) SeekWithPause(ctx context.Context, name string, parts []int, offsets []string) (AlterOutcome, error) {
+    return c.withPause(ctx, name, func() error { return c.AlterRoutineLoadOffsets(ctx, name, parts, offsets) })
+}
+
+func (c *StarRocksClient) withPause(ctx context.Context, name string, apply func() error) (AlterOutcome, error) {
+    var out AlterOutcome
+    d, derr := c.GetRoutineLoadDetails(ctx, name)
+    if derr != nil {
+        return out, apply()
+    }
+    out.Before = d
+    st := strings.ToUpper(strings.TrimSpace(d.State))
+    if st != "PAUSED" {
+        if err := c.PauseRoutineLoad(ctx, name); err == nil {
+            out.Paused = true
+        } else {
+            out.PauseErr = err
+        }
+    } else {
+        out.Paused = true
+    }
+    // 修改失败时同样恢复原本 RUNNING 的作业，避免作业停留在 PAUSED
+    applyErr := apply()
+    if st == "RUNNING" {
+        if err := c.ResumeRoutineLoad(ctx, name); err == nil {
+            out.Resumed = true
+        } else {
+            out.ResumeErr = err
+        }
+    }
+    return out, applyErr
+}
 
 // 控制操作：暂停/恢复/停止 Routine Load
 func (c *StarRocksClient) PauseRoutineLoad(ctx context.Context, name string) error {
     if strings.TrimSpace(name) == "" { return fmt.Errorf("empty name") }
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return err }
-    defer db.Close()
-    _, err = db.ExecContext(ctx, fmt.Sprintf("PAUSE ROUTINE LOAD FOR %s", name))
+    _, err := c.db.ExecContext(ctx, fmt.Sprintf("PAUSE ROUTINE LOAD FOR %s", name))
     return err
 }
 
 func (c *StarRocksClient) ResumeRoutineLoad(ctx context.Context, name string) error {
     if strings.TrimSpace(name) == "" { return fmt.Errorf("empty name") }
-    db, err := sqlOpen(c.dsn())
-    if err != nil { return err }
-    defer db.Close()
-    _, err = db.ExecContext(ctx, fmt.Sprintf("RESUME ROUTINE LOAD FOR %s", name))
+    _, err := c.db.ExecContext(ctx, fmt.Sprintf("RESUME ROUTINE LOAD FOR %s", name))
     return err
 }
 
 func (c *StarRocksClient) StopRoutineLoad(ctx context.Context, name string) error {
     if strings.TrimSpace(name) == "" { return fmt.Errorf("empty name") }
-    db, err := sqlOpen(c.dsn())
+    _, err := c.db.ExecContext(ctx, fmt.Sprintf("STOP ROUTINE LOAD FOR %s", name))
+    return err
+}
+
+// TableSpec 描述待创建的 StarRocks 表；DDL 非空时直接执行，否则按结构化字段生成
+type TableSpec struct {
+    Name          string            `json:"name" yaml:"name"`
+    Columns       []ColumnSpec      `json:"columns,omitempty" yaml:"columns,omitempty"`
+    DuplicateKey  []string          `json:"duplicate_key,omitempty" yaml:"duplicate_key,omitempty"`
+    DistributedBy string            `json:"distributed_by,omitempty" yaml:"distributed_by,omitempty"`
+    Buckets       int               `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+    Properties    map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"`
+    DDL           string            `json:"ddl,omitempty" yaml:"ddl,omitempty"`
+}
+
+type ColumnSpec struct {
+    Name string `json:"name" yaml:"name"`
+    Type string `json:"type" yaml:"type"`
+}
+
+// columnTypeRe 限制列类型写法，如 BIGINT、VARCHAR(64)、DECIMAL(18, 2)
+var columnTypeRe = regexp.MustCompile(`^[A-Za-z]+(\(\s*\d+\s*(,\s*\d+\s*)?\))?$`)
+
+// ddlTableRe 提取 CREATE TABLE 语句中的表名（可带反引号或库名前缀）
+var ddlTableRe = regexp.MustCompile("(?is)^CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?((?:`[^`]+`|[A-Za-z0-9_]+)(?:\\.(?:`[^`]+`|[A-Za-z0-9_]+))?)")
+
+// BuildDDL 根据结构化字段生成 CREATE TABLE 语句（风格与 init/01_create_tables.sql 一致）；
+// 直接给出 DDL 时校验其创建的表名与 Name 一致
+func (t TableSpec) BuildDDL() (string, error) {
+    if strings.TrimSpace(t.DDL) != "" {
+        ddl := strings.TrimSuffix(strings.TrimSpace(t.DDL), ";")
+        m := ddlTableRe.FindStringSubmatch(ddl)
+        if m == nil {
+            return "", fmt.Errorf("%w: ddl must be a CREATE TABLE statement", utils.ErrInvalid)
+        }
+        // 存在检查与回滚删除均按 Name 进行，DDL 创建的必须是同一张表（且不带库名，落在配置的数据库中）
+        name := strings.Trim(m[1], "`")
+        if strings.Contains(m[1], ".") {
+            return "", fmt.Errorf("%w: ddl table name %s must not be qualified with a database", utils.ErrInvalid, m[1])
+        }
+        if name != t.Name {
+            return "", fmt.Errorf("%w: ddl creates table %s but table.name is %s", utils.ErrInvalid, name, t.Name)
+        }
+        return ddl, nil
+    }
+    if !utils.ValidIdentifier(t.Name) {
+        return "", fmt.Errorf("%w: invalid table name %q", utils.ErrInvalid, t.Name)
+    }
+    if len(t.Columns) == 0 {
+        return "", fmt.Errorf("%w: table %s has no columns", utils.ErrInvalid, t.Name)
+    }
+    known := map[string]bool{}
+    cols := make([]string, 0, len(t.Columns))
+    for _, c := range t.Columns {
+        if !utils.ValidIdentifier(c.Name) || !columnTypeRe.MatchString(strings.TrimSpace(c.Type)) {
+            return "", fmt.Errorf("%w: invalid column %s %s", utils.ErrInvalid, c.Name, c.Type)
+        }
+        known[c.Name] = true
+        cols = append(cols, fmt.Sprintf("  %-11s %s", c.Name, strings.ToUpper(strings.TrimSpace(c.Type))))
+    }
+    keys := t.DuplicateKey
+    if len(keys) == 0 { keys = []string{t.Columns[0].Name} }
+    for _, k := range keys {
+        if !known[k] { return "", fmt.Errorf("%w: duplicate key %s is not a column", utils.ErrInvalid, k) }
+    }
+    dist := t.DistributedBy
+    if dist == "" { dist = keys[0] }
+    if !known[dist] { return "", fmt.Errorf("%w: distributed_by %s is not a column", utils.ErrInvalid, dist) }
+    buckets := t.Buckets
+    if buckets < 1 { buckets = 8 }
+
+    var sb strings.Builder
+    fmt.Fprintf(&sb, "CREATE TABLE IF NOT EXISTS %s (\n%s\n)\n", t.Name, strings.Join(cols, ",\n"))
+    fmt.Fprintf(&sb, "DUPLICATE KEY(%s)\n", strings.Join(keys, ", "))
+    fmt.Fprintf(&sb, "DISTRIBUTED BY HASH(%s) BUCKETS %d", dist, buckets)
+    if len(t.Properties) > 0 {
+        pk := make([]string, 0, len(t.Properties))
+        for k := range t.Properties { pk = append(pk, k) }
+        sort.Strings(pk)
+        props := make([]string, 0, len(pk))
+        for _, k := range pk {
+            props = append(props, fmt.Sprintf("  \"%s\" = \"%s\"", k, strings.ReplaceAll(t.Properties[k], "\"", "\\\"")))
+        }
+        fmt.Fprintf(&sb, "\nPROPERTIES (\n%s\n)", strings.Join(props, ",\n"))
+    }
+    return sb.String(), nil
+}
+
+// ShowCreateTable 返回表的建表语句（SHOW CREATE TABLE 的第二列）
+func (c *StarRocksClient) ShowCreateTable(ctx context.Context, table string) (string, error) {
+    if !utils.ValidIdentifier(table) {
+        return "", fmt.Errorf("%w: invalid table name %q", utils.ErrInvalid, table)
+    }
+    var name, ddl string
+    if err := c.db.QueryRowContext(ctx, "SHOW CREATE TABLE "+table).Scan(&name, &ddl); err != nil { return "", err }
+    return ddl, nil
+}
+
+// TableExists 判断目标库中是否存在指定表
+func (c *StarRocksClient) TableExists(ctx context.Context, table string) (bool, error) {
+    q := "SELECT COUNT(*) FROM information_schema.tables WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?"
+    var cnt int
+    if err := c.db.QueryRowContext(ctx, q, c.cfg.StarRocks.Database, table).Scan(&cnt); err != nil { return false, err }
+    return cnt > 0, nil
+}
+
+// CreateTable 按 TableSpec 建表
+func (c *StarRocksClient) CreateTable(ctx context.Context, spec TableSpec) error {
+    ddl, err := spec.BuildDDL()
     if err != nil { return err }
-    defer db.Close()
-    _, err = db.ExecContext(ctx, fmt.Sprintf("STOP ROUTINE LOAD FOR %s", name))
+    _, err = c.db.ExecContext(ctx, ddl)
+    return err
+}
+
+// DropTable 删除表（用于回滚刚创建的表）
+func (c *StarRocksClient) DropTable(ctx context.Context, table string) error {
+    if !utils.ValidIdentifier(table) {
+        return fmt.Errorf("%w: invalid table name %q", utils.ErrInvalid, table)
+    }
+    _, err := c.db.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", table))
     return err
+}
+
+// TableInfo 为 information_schema.tables 中的一行
+type TableInfo struct {
+    Name string `json:"name"`
+    Type string `json:"type"` // BASE TABLE/VIEW 等
+}
+
+// ListTables 返回目标库中的全部表与视图
+func (c *StarRocksClient) ListTables(ctx context.Context) ([]TableInfo, error) {
+    q := "SELECT TABLE_NAME, TABLE_TYPE FROM information_schema.tables WHERE TABLE_SCHEMA = ? ORDER BY TABLE_NAME"
+    rows, err := c.db.QueryContext(ctx, q, c.cfg.StarRocks.Database)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    var out []TableInfo
+    for rows.Next() {
+        var t TableInfo
+        var typ sql.NullString
+        if err := rows.Scan(&t.Name, &typ); err != nil { return nil, err }
+        t.Type = typ.String
+        out = append(out, t)
+    }
+    return out, rows.Err()
+}
+
+// MaterializedView 描述一个物化视图及其定义（同步与异步物化视图均包含）
+type MaterializedView struct {
+    Name        string `json:"name"`
+    RefreshType string `json:"refresh_type,omitempty"`
+    Active      string `json:"is_active,omitempty"`
+    Definition  string `json:"definition"`
+}
+
+// ListMaterializedViews 通过 SHOW MATERIALIZED VIEWS 读取物化视图元数据；
+// 旧版本不支持时退回 information_schema.materialized_views（仅含异步物化视图）
+func (c *StarRocksClient) ListMaterializedViews(ctx context.Context) ([]MaterializedView, error) {
+    rows, err := c.db.QueryContext(ctx, "SHOW MATERIALIZED VIEWS FROM "+c.cfg.StarRocks.Database)
+    if err != nil {
+        q := "SELECT TABLE_NAME, REFRESH_TYPE, IS_ACTIVE, MATERIALIZED_VIEW_DEFINITION FROM information_schema.materialized_views WHERE TABLE_SCHEMA = ?"
+        rows, err = c.db.QueryContext(ctx, q, c.cfg.StarRocks.Database)
+        if err != nil { return nil, err }
+    }
+    defer rows.Close()
+    cols, err := rows.Columns()
+    if err != nil { return nil, err }
+    raw := make([]sql.RawBytes, len(cols))
+    scan := make([]interface{}, len(cols))
+    for i := range raw { scan[i] = &raw[i] }
+    var out []MaterializedView
+    for rows.Next() {
+        if err := rows.Scan(scan...); err != nil { return nil, err }
+        var mv MaterializedView
+        for i, col := range cols {
+            val := string(raw[i])
+            switch strings.ToLower(strings.TrimSpace(col)) {
+            case "name", "table_name":
+                mv.Name = val
+            case "refresh_type":
+                mv.RefreshType = val
+            case "is_active":
+                mv.Active = val
+            case "text", "query", "materialized_view_definition":
+                if mv.Definition == "" { mv.Definition = val }
+            }
+        }
+        if mv.Name != "" { out = append(out, mv) }
+    }
+    if err := rows.Err(); err != nil { return nil, err }
+    sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+    return out, nil
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package services
+
+import (
+    "errors"
+    "reflect"
+    "testing"
+
+    "event/utils"
+)
+
+func TestParseRLProgress(t *testing.T) {
+    cases := []struct {
+        in   string
+        want []RLPartitionProgress
+    }{
+        {`{"0":"1234","1":"OFFSET_BEGINNING"}`, []RLPartitionProgress{{Partition: 0, Offset: 1234}, {Partition: 1, Offset: -1}}},
+        {`{"2":"OFFSET_ZERO","1":"7","0":"5"}`, []RLPartitionProgress{{Partition: 0, Offset: 5}, {Partition: 1, Offset: 7}, {Partition: 2, Offset: -1}}},
+        // 任务列表中 offset 为数字
+        {`{"0":1234,"1":99}`, []RLPartitionProgress{{Partition: 0, Offset: 1234}, {Partition: 1, Offset: 99}}},
+        // OFFSET_END 与无法识别的分区/取值不计入
+        {`{"0":"OFFSET_END","1":"12","x":"3"}`, []RLPartitionProgress{{Partition: 1, Offset: 12}}},
+        {` {"0":"1"} `, []RLPartitionProgress{{Partition: 0, Offset: 1}}},
+        {`{}`, []RLPartitionProgress{}},
+        {``, nil},
+        {`not json`, nil},
+    }
+    for _, tc := range cases {
+        if got := parseRLProgress(tc.in); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("parseRLProgress(%q) = %+v, want %+v", tc.in, got, tc.want)
+        }
+    }
+}
+
+func TestRLJobFromRowProgress(t *testing.T) {
+    job := rlJobFromRow(map[string]string{
+        "Name":                 "page_views_rl",
+        "State":                "RUNNING",
+        "TableName":            "page_views",
+        "DataSourceProperties": `{"topic":"page_views","currentKafkaPartitions":"0,1"}`,
+        "Progress":             `{"1":"41","0":"OFFSET_BEGINNING"}`,
+        "Statistic":            `{"loadedRows":42,"errorRows":3}`,
+    })
+    want := RLJob{Name: "page_views_rl", State: "RUNNING", Table: "page_views", Topic: "page_views", Processed: 42, Errors: 3,
+        Progress: []RLPartitionProgress{{Partition: 0, Offset: -1}, {Partition: 1, Offset: 41}}}
+    if !reflect.DeepEqual(job, want) {
+        t.Errorf("job = %+v, want %+v", job, want)
+    }
+}
+
+func TestKafkaOffsetProps(t *testing.T) {
+    cases := []struct {
+        name    string
+        parts   []int
+        offsets []string
+        want    []string
+        invalid bool
+    }{
+        {"none", nil, nil, nil, false},
+        {"partitions only", []int{0, 1, 2}, nil, []string{`"kafka_partitions" = "0,1,2"`}, false},
+        {"offsets", []int{0, 1, 2}, []string{"100", " offset_beginning ", "OFFSET_END"},
+            []string{`"kafka_partitions" = "0,1,2"`, `"kafka_offsets" = "100,OFFSET_BEGINNING,OFFSET_END"`}, false},
+        {"offsets without partitions", nil, []string{"1"}, nil, true},
+        {"count mismatch", []int{0, 1}, []string{"1"}, nil, true},
+        {"negative partition", []int{-1}, nil, nil, true},
+        {"negative offset", []int{0}, []string{"-5"}, nil, true},
+        {"bad offset", []int{0}, []string{"latest"}, nil, true},
+        {"injection", []int{0}, []string{`1", "x" = "y`}, nil, true},
+    }
+    for _, tc := range cases {
+        got, err := kafkaOffsetProps(tc.parts, tc.offsets)
+        if tc.invalid {
+            if !errors.Is(err, utils.ErrInvalid) {
+                t.Errorf("%s: err = %v, want ErrInvalid", tc.name, err)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("%s: unexpected error %v", tc.name, err)
+            continue
+        }
+        if !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+        }
+    }
+}
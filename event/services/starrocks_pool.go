@@ -0,0 +1,35 @@
+package services
+
+// PoolStats 为连接池的运行统计，字段含义同 sql.DBStats
+type PoolStats struct {
+    MaxOpenConns       int   `json:"max_open_conns"`
+    MaxIdleConns       int   `json:"max_idle_conns"`
+    ConnMaxLifetimeSec int   `json:"conn_max_lifetime_sec"`
+    OpenConns          int   `json:"open_conns"`
+    InUse              int   `json:"in_use"`
+    Idle               int   `json:"idle"`
+    WaitCount          int64 `json:"wait_count"`
+    WaitMs             int64 `json:"wait_ms"`
+    MaxIdleClosed      int64 `json:"max_idle_closed"`
+    MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+    MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// PoolStats 返回客户端连接池的统计；尚未发起过查询时连接数均为 0
+func (c *StarRocksClient) PoolStats() PoolStats {
+    s := c.db.Stats()
+    p := c.cfg.StarRocks.Pool
+    return PoolStats{
+        MaxOpenConns:       s.MaxOpenConnections,
+        MaxIdleConns:       p.MaxIdleConns,
+        ConnMaxLifetimeSec: p.ConnMaxLifetimeSec,
+        OpenConns:          s.OpenConnections,
+        InUse:              s.InUse,
+        Idle:               s.Idle,
+        WaitCount:          s.WaitCount,
+        WaitMs:             s.WaitDuration.Milliseconds(),
+        MaxIdleClosed:      s.MaxIdleClosed,
+        MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+        MaxLifetimeClosed:  s.MaxLifetimeClosed,
+    }
+}
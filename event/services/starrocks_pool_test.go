@@ -0,0 +1,32 @@
+package services
+
+import (
+    "testing"
+
+    "event/config"
+    "go.uber.org/zap"
+)
+
+func TestPoolStats(t *testing.T) {
+    cases := []struct {
+        name string
+        pool config.StarRocksPoolConfig
+        open int
+    }{
+        {"configured", config.StarRocksPoolConfig{MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetimeSec: 120}, 7},
+        // 为 0 时沿用 database/sql 的默认值（不限制）
+        {"unset", config.StarRocksPoolConfig{}, 0},
+    }
+    for _, tc := range cases {
+        cfg := config.Config{StarRocks: config.StarRocksConfig{FEHost: "127.0.0.1", FEPort: 1, User: "root", Database: "eventdb", Pool: tc.pool}}
+        c, err := NewStarRocksClient(cfg, zap.NewNop())
+        if err != nil { t.Fatalf("%s: %v", tc.name, err) }
+        // 创建客户端不建立连接
+        got := c.PoolStats()
+        want := PoolStats{MaxOpenConns: tc.open, MaxIdleConns: tc.pool.MaxIdleConns, ConnMaxLifetimeSec: tc.pool.ConnMaxLifetimeSec}
+        if got != want {
+            t.Errorf("%s: PoolStats = %+v, want %+v", tc.name, got, want)
+        }
+        c.Close()
+    }
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+    "bufio"
+    "context"
+    "database/sql"
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "event/utils"
+)
+
+const (
+    defaultErrorLogRows = 50   // 默认返回的错误行数
+    maxErrorLogRows     = 1000 // 单次最多返回的错误行数
+)
+
+// RLTask 为 Routine Load 作业当前的一个导入任务（SHOW ROUTINE LOAD TASK）。
+// Offsets 为任务在各分区上的起始消费位置；Message 为 FE 记录的最近一次调度或执行信息
+type RLTask struct {
+    TaskID            string                `json:"task_id"`
+    TxnID             string                `json:"txn_id,omitempty"`
+    TxnStatus         string                `json:"txn_status,omitempty"`
+    JobID             string                `json:"job_id,omitempty"`
+    CreateTime        string                `json:"create_time,omitempty"`
+    LastScheduledTime string                `json:"last_scheduled_time,omitempty"`
+    ExecuteStartTime  string                `json:"execute_start_time,omitempty"`
+    TimeoutSec        int                   `json:"timeout_sec,omitempty"`
+    BeID              string                `json:"be_id,omitempty"`
+    Offsets           []RLPartitionProgress `json:"offsets,omitempty"`
+    Message           string                `json:"message,omitempty"`
+}
+
+// RLErrorRow 为错误日志中的一条被拒绝的行：Reason 为拒绝原因，Row 为原始数据（日志中没有时为空）
+type RLErrorRow struct {
+    Reason string `json:"reason"`
+    Row    string `json:"row,omitempty"`
+}
+
+// RLErrorLog 为作业的错误日志摘要。URLs 取自 SHOW ROUTINE LOAD 的 ErrorLogUrls 列，
+// 依次读取直到取满 limit 行；读取失败的地址记入 FetchErrors
+type RLErrorLog struct {
+    Job         string       `json:"job"`
+    ErrorRows   int          `json:"error_rows"`
+    URLs        []string     `json:"urls"`
+    Rows        []RLErrorRow `json:"rows"`
+    Truncated   bool         `json:"truncated,omitempty"`
+    FetchErrors []string     `json:"fetch_errors,omitempty"`
+}
+
+// ListRoutineLoadTasks 返回作业当前的导入任务（按创建时间排序）；作业不存在时返回 ErrNotFound，
+// 作业存在但没有运行中的任务（如已暂停）时返回空列表
+func (c *StarRocksClient) ListRoutineLoadTasks(ctx context.Context, name string) ([]RLTask, error) {
+    if !utils.ValidIdentifier(name) {
+        return nil, fmt.Errorf("%w: invalid job name %q", utils.ErrInvalid, name)
+    }
+    if _, err := c.routineLoadRow(ctx, name); err != nil { return nil, err }
+    q := fmt.Sprintf("SHOW ROUTINE LOAD TASK FROM %s WHERE JobName = \"%s\"", c.cfg.StarRocks.Database, name)
+    rows, err := c.db.QueryContext(ctx, q)
+    if err != nil { return nil, err }
+    defer rows.Close()
+    cols, err := rows.Columns()
+    if err != nil { return nil, err }
+    raw := make([]sql.RawBytes, len(cols))
+    scan := make([]interface{}, len(cols))
+    for i := range raw { scan[i] = &raw[i] }
+    out := []RLTask{}
+    for rows.Next() {
+        if err := rows.Scan(scan...); err != nil { return nil, err }
+        var t RLTask
+        for i, col := range cols {
+            val := strings.TrimSpace(string(raw[i]))
+            switch strings.TrimSpace(col) {
+            case "TaskId":
+                t.TaskID = val
+            case "TxnId":
+                t.TxnID = val
+            case "TxnStatus":
+                t.TxnStatus = val
+            case "JobId":
+                t.JobID = val
+            case "CreateTime":
+                t.CreateTime = val
+            case "LastScheduledTime":
+                t.LastScheduledTime = val
+            case "ExecuteStartTime":
+                t.ExecuteStartTime = val
+            case "Timeout":
+                t.TimeoutSec, _ = strconv.Atoi(val)
+            case "BeId":
+                t.BeID = val
+            case "DataSourceProperties":
+                t.Offsets = parseRLProgress(val)
+            case "Message":
+                t.Message = val
+            }
+        }
+        out = append(out, t)
+    }
+    if err := rows.Err(); err != nil { return nil, err }
+    sort.SliceStable(out, func(i, j int) bool { return out[i].CreateTime < out[j].CreateTime })
+    return out, nil
+}
+
+// splitErrorLogURLs 拆分 ErrorLogUrls 列，多个地址以逗号或空白分隔，NULL 表示没有错误日志
+func splitErrorLogURLs(s string) []string {
+    out := []string{}
+    for _, u := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' }) {
+        if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") { out = append(out, u) }
+    }
+    return out
+}
+
+// parseErrorLogLine 解析 BE 错误日志中的一行，兼容两种格式：
+// "Error: <原因>. Row: <数据>"（3.x）与 "Reason: <原因>. src line: [<数据>];"（早期版本）
+func parseErrorLogLine(line string) RLErrorRow {
+    line = strings.TrimSpace(line)
+    for _, sep := range []string{". Row: ", " Row: ", ". src line: ", " src line: "} {
+        if i := strings.Index(line, sep); i >= 0 {
+            reason, row := line[:i], strings.TrimSuffix(strings.TrimSpace(line[i+len(sep):]), ";")
+            if strings.Contains(sep, "src line") { row = strings.TrimSuffix(strings.TrimPrefix(row, "["), "]") }
+            return RLErrorRow{Reason: trimErrorPrefix(reason), Row: row}
+        }
+    }
+    return RLErrorRow{Reason: trimErrorPrefix(strings.TrimSuffix(line, ";"))}
+}
+
+func trimErrorPrefix(s string) string {
+    for _, p := range []string{"Error: ", "Reason: "} { s = strings.TrimPrefix(s, p) }
+    return strings.TrimSpace(s)
+}
+
+// errorLogClient 用于读取 BE 错误日志。日志地址取自 FE 返回的结果，不跟随重定向，
+// 避免被引导去访问其他地址
+var errorLogClient = &http.Client{
+    Timeout:       10 * time.Second,
+    CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// fetchErrorLog 读取一个错误日志地址，最多返回 limit 行；more 表示日志中还有未读取的行
+func fetchErrorLog(ctx context.Context, url string, limit int) (rows []RLErrorRow, more bool, err error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil { return nil, false, err }
+    resp, err := errorLogClient.Do(req)
+    if err != nil { return nil, false, err }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK { return nil, false, fmt.Errorf("status %s", resp.Status) }
+    sc := bufio.NewScanner(resp.Body)
+    // 被拒绝的行可能很长
+    sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+    for sc.Scan() {
+        if strings.TrimSpace(sc.Text()) == "" { continue }
+        if len(rows) >= limit { return rows, true, nil }
+        rows = append(rows, parseErrorLogLine(sc.Text()))
+    }
+    return rows, false, sc.Err()
+}
+
+// RoutineLoadErrors 读取作业 ErrorLogUrls 指向的 BE 错误日志，返回前 limit 条被拒绝的行及原因。
+// BE 只保留最近若干任务的错误日志，ErrorRows 为作业累计的错误行数，可能多于日志中能读到的行
+func (c *StarRocksClient) RoutineLoadErrors(ctx context.Context, name string, limit int) (*RLErrorLog, error) {
+    if !utils.ValidIdentifier(name) {
+        return nil, fmt.Errorf("%w: invalid job name %q", utils.ErrInvalid, name)
+    }
+    if limit <= 0 { limit = defaultErrorLogRows }
+    if limit > maxErrorLogRows { limit = maxErrorLogRows }
+    kv, err := c.routineLoadRow(ctx, name)
+    if err != nil { return nil, err }
+    out := &RLErrorLog{Job: name, URLs: splitErrorLogURLs(kv["ErrorLogUrls"]), Rows: []RLErrorRow{}}
+    if _, e := parseStatisticCounts(kv["Statistic"]); e > 0 { out.ErrorRows = e }
+    for _, u := range out.URLs {
+        if len(out.Rows) >= limit {
+            out.Truncated = true
+            break
+        }
+        rows, more, err := fetchErrorLog(ctx, u, limit-len(out.Rows))
+        if err != nil {
+            out.FetchErrors = append(out.FetchErrors, fmt.Sprintf("%s: %v", u, err))
+            continue
+        }
+        out.Rows = append(out.Rows, rows...)
+        if more { out.Truncated = true }
+    }
+    return out, nil
+}
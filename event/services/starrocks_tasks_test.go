@@ -0,0 +1,111 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+
+    "event/config"
+    "event/utils"
+    "go.uber.org/zap"
+)
+
+func TestSplitErrorLogURLs(t *testing.T) {
+    cases := []struct {
+        in   string
+        want []string
+    }{
+        {"", []string{}},
+        {"NULL", []string{}},
+        {"http://be1:8040/api/_load_error_log?file=a", []string{"http://be1:8040/api/_load_error_log?file=a"}},
+        {"http://be1:8040/a, http://be2:8040/b\nhttps://be3/c", []string{"http://be1:8040/a", "http://be2:8040/b", "https://be3/c"}},
+        {"file:///etc/passwd,http://be1/a", []string{"http://be1/a"}},
+    }
+    for _, tc := range cases {
+        if got := splitErrorLogURLs(tc.in); !reflect.DeepEqual(got, tc.want) {
+            t.Errorf("splitErrorLogURLs(%q) = %#v, want %#v", tc.in, got, tc.want)
+        }
+    }
+}
+
+func TestParseErrorLogLine(t *testing.T) {
+    cases := []struct {
+        line string
+        want RLErrorRow
+    }{
+        {"Error: Value count does not match column count. Expect 3, but got 2. Row: [1, \"a\"]",
+            RLErrorRow{Reason: "Value count does not match column count. Expect 3, but got 2", Row: "[1, \"a\"]"}},
+        {"Reason: column(ts) value is null while columns is not nullable. src line: [1\t\\N];",
+            RLErrorRow{Reason: "column(ts) value is null while columns is not nullable", Row: "1\t\\N"}},
+        {"  Error: parse json failed Row: {bad ", RLErrorRow{Reason: "parse json failed", Row: "{bad"}},
+        {"Reason: too many filtered rows;", RLErrorRow{Reason: "too many filtered rows"}},
+        {"unrecognized line", RLErrorRow{Reason: "unrecognized line"}},
+    }
+    for _, tc := range cases {
+        if got := parseErrorLogLine(tc.line); got != tc.want {
+            t.Errorf("parseErrorLogLine(%q) = %+v, want %+v", tc.line, got, tc.want)
+        }
+    }
+}
+
+func TestFetchErrorLog(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/log":
+            for i := 1; i <= 3; i++ { fmt.Fprintf(w, "Error: bad value %d. Row: %d\n\n", i, i) }
+        case "/redirect":
+            http.Redirect(w, r, "/log", http.StatusFound)
+        default:
+            http.NotFound(w, r)
+        }
+    }))
+    defer srv.Close()
+    ctx := context.Background()
+    cases := []struct {
+        name  string
+        path  string
+        limit int
+        rows  int
+        more  bool
+        err   bool
+    }{
+        {"all rows", "/log", 10, 3, false, false},
+        {"exactly limit", "/log", 3, 3, false, false},
+        {"more than limit", "/log", 2, 2, true, false},
+        // 不跟随重定向
+        {"redirect refused", "/redirect", 10, 0, false, true},
+        {"not found", "/missing", 10, 0, false, true},
+    }
+    for _, tc := range cases {
+        rows, more, err := fetchErrorLog(ctx, srv.URL+tc.path, tc.limit)
+        if tc.err != (err != nil) || len(rows) != tc.rows || more != tc.more {
+            t.Errorf("%s: %d rows, more %v, err %v", tc.name, len(rows), more, err)
+        }
+    }
+    rows, _, _ := fetchErrorLog(ctx, srv.URL+"/log", 1)
+    if len(rows) != 1 || rows[0] != (RLErrorRow{Reason: "bad value 1", Row: "1"}) {
+        t.Errorf("first row = %+v", rows)
+    }
+}
+
+func TestTasksAndErrorsInvalidName(t *testing.T) {
+    cfg := config.Config{StarRocks: config.StarRocksConfig{FEHost: "127.0.0.1", FEPort: 1, User: "root", Database: "eventdb"}}
+    c, err := NewStarRocksClient(cfg, zap.NewNop())
+    if err != nil { t.Fatal(err) }
+    defer c.Close()
+    ctx := context.Background()
+    if _, err := c.ListRoutineLoadTasks(ctx, "a' OR '1"); !errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("tasks: err = %v, want ErrInvalid", err)
+    }
+    if _, err := c.RoutineLoadErrors(ctx, "a b", 10); !errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("errors: err = %v, want ErrInvalid", err)
+    }
+    // FE 不可达不是作业不存在
+    if _, err := c.ListRoutineLoadTasks(ctx, "clicks_rl"); err == nil || errors.Is(err, utils.ErrNotFound) || errors.Is(err, utils.ErrInvalid) {
+        t.Errorf("unreachable FE: err = %v", err)
+    }
+}
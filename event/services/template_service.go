@@ -0,0 +1,143 @@
+package services
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    "event/config"
+    "event/utils"
+    "gopkg.in/yaml.v3"
+)
+
+// PipelineTemplate 描述一类常见事件形态：建表结构与 Routine Load 列映射/属性，主题与表名在展开时填入
+type PipelineTemplate struct {
+    Name        string          `json:"name" yaml:"name"`
+    Title       string          `json:"title" yaml:"title"`
+    Description string          `json:"description,omitempty" yaml:"description,omitempty"`
+    Topic       TopicSpec       `json:"topic" yaml:"topic"`
+    Table       TableSpec       `json:"table" yaml:"table"`
+    RoutineLoad RLCreateRequest `json:"routine_load" yaml:"routine_load"`
+    File        string          `json:"file" yaml:"-"`
+}
+
+// TemplateParams 为展开模板时由用户填写的参数
+type TemplateParams struct {
+    Topic      string `json:"topic"`
+    Table      string `json:"table"`
+    JobName    string `json:"job_name,omitempty"`
+    GroupID    string `json:"group_id,omitempty"`
+    BrokerList string `json:"broker_list,omitempty"`
+}
+
+// TemplateExpansion 是模板展开结果：可直接提交给 /api/starrocks/jobs 的请求、建表语句，
+// 以及可提交给 /api/pipelines/apply 的完整描述
+type TemplateExpansion struct {
+    Template    string          `json:"template"`
+    RoutineLoad RLCreateRequest `json:"routine_load"`
+    TableDDL    string          `json:"table_ddl"`
+    Spec        PipelineSpec    `json:"spec"`
+}
+
+// TemplateService 从磁盘目录加载模板，每次请求重新读取，新增模板文件无需重启或重新编译
+type TemplateService struct {
+    cfg config.Config
+    dir string
+}
+
+func NewTemplateService(cfg config.Config) *TemplateService {
+    return &TemplateService{cfg: cfg, dir: cfg.Templates.Dir}
+}
+
+// List 读取目录下全部 *.yaml / *.yml 模板，按名称排序；单个文件解析失败时返回错误以便及时发现
+func (s *TemplateService) List() ([]PipelineTemplate, error) {
+    entries, err := os.ReadDir(s.dir)
+    if err != nil {
+        if os.IsNotExist(err) { return []PipelineTemplate{}, nil }
+        return nil, err
+    }
+    out := make([]PipelineTemplate, 0, len(entries))
+    seen := map[string]string{}
+    for _, e := range entries {
+        ext := strings.ToLower(filepath.Ext(e.Name()))
+        if e.IsDir() || (ext != ".yaml" && ext != ".yml") { continue }
+        t, err := loadTemplate(filepath.Join(s.dir, e.Name()))
+        if err != nil { return nil, err }
+        if prev, ok := seen[t.Name]; ok {
+            return nil, fmt.Errorf("template %s defined in both %s and %s", t.Name, prev, t.File)
+        }
+        seen[t.Name] = t.File
+        out = append(out, *t)
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+    return out, nil
+}
+
+func loadTemplate(path string) (*PipelineTemplate, error) {
+    b, err := os.ReadFile(path)
+    if err != nil { return nil, err }
+    var t PipelineTemplate
+    if err := yaml.Unmarshal(b, &t); err != nil {
+        return nil, fmt.Errorf("template %s: %w", path, err)
+    }
+    t.File = filepath.Base(path)
+    if t.Name == "" { t.Name = strings.TrimSuffix(t.File, filepath.Ext(t.File)) }
+    return &t, nil
+}
+
+// Get 按名称返回模板
+func (s *TemplateService) Get(name string) (*PipelineTemplate, error) {
+    list, err := s.List()
+    if err != nil { return nil, err }
+    for _, t := range list {
+        if t.Name == name { return &t, nil }
+    }
+    return nil, fmt.Errorf("template %s: %w", name, utils.ErrNotFound)
+}
+
+// Expand 用主题与表名展开模板
+func (s *TemplateService) Expand(name string, params TemplateParams) (*TemplateExpansion, error) {
+    t, err := s.Get(name)
+    if err != nil { return nil, err }
+    return t.Expand(params, s.cfg.Kafka.Brokers)
+}
+
+// Expand 展开模板：表名与主题必填，作业名默认 <table>_rl，消费组默认 sr-<topic>
+func (t PipelineTemplate) Expand(params TemplateParams, defaultBrokers []string) (*TemplateExpansion, error) {
+    params.Topic = strings.TrimSpace(params.Topic)
+    params.Table = strings.TrimSpace(params.Table)
+    if !utils.ValidTopicName(params.Topic) {
+        return nil, fmt.Errorf("%w: invalid topic %q", utils.ErrInvalid, params.Topic)
+    }
+    if !utils.ValidIdentifier(params.Table) {
+        return nil, fmt.Errorf("%w: invalid table %q", utils.ErrInvalid, params.Table)
+    }
+    table := t.Table
+    table.Name = params.Table
+    ddl, err := table.BuildDDL()
+    if err != nil { return nil, err }
+
+    rl := t.RoutineLoad
+    rl.Name = strings.TrimSpace(params.JobName)
+    if rl.Name == "" { rl.Name = params.Table + "_rl" }
+    rl.Table = params.Table
+    rl.Kafka = KafkaSource{BrokerList: params.BrokerList, Topic: params.Topic, GroupID: params.GroupID}
+    if rl.Kafka.BrokerList == "" { rl.Kafka.BrokerList = strings.Join(defaultBrokers, ",") }
+    if rl.Kafka.GroupID == "" { rl.Kafka.GroupID = defaultGroupID(params.Topic) }
+    // 复制可变字段，避免多次展开共享同一份 map/slice
+    rl.Columns = append([]string(nil), t.RoutineLoad.Columns...)
+    rl.Set = copyProps(t.RoutineLoad.Set)
+    rl.Properties = copyProps(t.RoutineLoad.Properties)
+
+    topic := t.Topic
+    topic.Name = params.Topic
+    topic.Configs = copyProps(t.Topic.Configs)
+    return &TemplateExpansion{
+        Template:    t.Name,
+        RoutineLoad: rl,
+        TableDDL:    ddl,
+        Spec:        PipelineSpec{Name: params.Table, Topic: topic, Table: table, RoutineLoad: rl},
+    }, nil
+}
\ No newline at end of file
@@ -0,0 +1,87 @@
+package services
+
+import (
+    "errors"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "event/config"
+    "event/utils"
+)
+
+func TestBuiltinTemplatesExpand(t *testing.T) {
+    s := NewTemplateService(config.Config{Templates: config.TemplatesConfig{Dir: "../templates"}})
+    list, err := s.List()
+    if err != nil || len(list) == 0 {
+        t.Fatalf("templates = %+v, err %v", list, err)
+    }
+    for _, tpl := range list {
+        x, err := tpl.Expand(TemplateParams{Topic: "web_events", Table: "web_events"}, []string{"k1:9092", "k2:9092"})
+        if err != nil {
+            t.Errorf("%s: %v", tpl.Name, err)
+            continue
+        }
+        rl := x.RoutineLoad
+        if rl.Name != "web_events_rl" || rl.Table != "web_events" || rl.Kafka.BrokerList != "k1:9092,k2:9092" || rl.Kafka.GroupID != "sr-web_events" {
+            t.Errorf("%s: routine load = %+v", tpl.Name, rl)
+        }
+        if !strings.Contains(x.TableDDL, "CREATE TABLE IF NOT EXISTS web_events (") || x.Spec.Topic.Name != "web_events" {
+            t.Errorf("%s: ddl = %s, topic = %s", tpl.Name, x.TableDDL, x.Spec.Topic.Name)
+        }
+    }
+}
+
+func TestTemplateExpand(t *testing.T) {
+    tpl := PipelineTemplate{
+        Name:        "events",
+        Table:       TableSpec{Columns: []ColumnSpec{{Name: "id", Type: "BIGINT"}}},
+        RoutineLoad: RLCreateRequest{Properties: map[string]string{"format": "json"}},
+    }
+    cases := []struct {
+        name    string
+        params  TemplateParams
+        invalid bool
+    }{
+        {"explicit names", TemplateParams{Topic: " events ", Table: "events", JobName: "ev_rl", GroupID: "g1", BrokerList: "k:9092"}, false},
+        {"bad topic", TemplateParams{Topic: "a b", Table: "events"}, true},
+        {"bad table", TemplateParams{Topic: "events", Table: "events-1"}, true},
+    }
+    for _, tc := range cases {
+        x, err := tpl.Expand(tc.params, nil)
+        if tc.invalid {
+            if !errors.Is(err, utils.ErrInvalid) { t.Errorf("%s: err = %v, want ErrInvalid", tc.name, err) }
+            continue
+        }
+        if err != nil { t.Fatalf("%s: %v", tc.name, err) }
+        if k := x.RoutineLoad.Kafka; x.RoutineLoad.Name != "ev_rl" || k.Topic != "events" || k.GroupID != "g1" || k.BrokerList != "k:9092" {
+            t.Errorf("%s: routine load = %+v", tc.name, x.RoutineLoad)
+        }
+        // 展开结果不与模板共享 map
+        x.RoutineLoad.Properties["format"] = "csv"
+        if tpl.RoutineLoad.Properties["format"] != "json" {
+            t.Errorf("%s: expansion modified the template", tc.name)
+        }
+    }
+}
+
+func TestTemplateListDuplicates(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, body string) {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil { t.Fatal(err) }
+    }
+    write("a.yaml", "title: A\n")
+    write("notes.txt", "ignored")
+    s := NewTemplateService(config.Config{Templates: config.TemplatesConfig{Dir: dir}})
+    if tpl, err := s.Get("a"); err != nil || tpl.File != "a.yaml" {
+        t.Fatalf("get a = %+v, err %v (name defaults to the file name)", tpl, err)
+    }
+    if _, err := s.Get("notes"); !errors.Is(err, utils.ErrNotFound) {
+        t.Errorf("get notes: err = %v, want ErrNotFound", err)
+    }
+    write("b.yml", "name: a\n")
+    if _, err := s.List(); err == nil {
+        t.Error("duplicate template names: want error")
+    }
+}
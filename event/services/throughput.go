@@ -0,0 +1,208 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "event/config"
+    "event/utils"
+    "go.uber.org/zap"
+)
+
+// throughputWindows 为对外提供的速率窗口，最长窗口同时决定采样保留时长
+var throughputWindows = []struct {
+    name string
+    d    time.Duration
+}{
+    {"5m", 5 * time.Minute},
+    {"1h", time.Hour},
+    {"24h", 24 * time.Hour},
+}
+
+// RateWindow 为某个窗口内的写入量与速率；Seconds 为实际覆盖的秒数，
+// 服务启动不足一个窗口时小于窗口长度，没有足够采样时为 0
+type RateWindow struct {
+    Seconds  int     `json:"seconds"`
+    Messages int64   `json:"messages"`
+    PerSec   float64 `json:"per_sec"`
+}
+
+// PartitionRate 为单个分区的高水位与各窗口速率
+type PartitionRate struct {
+    Partition     int                   `json:"partition"`
+    HighWatermark int64                 `json:"high_watermark"`
+    Rates         map[string]RateWindow `json:"rates"`
+}
+
+// TopicRate 为主题的写入速率（各分区之和）
+type TopicRate struct {
+    Topic         string                `json:"topic"`
+    HighWatermark int64                 `json:"high_watermark"`
+    Rates         map[string]RateWindow `json:"rates"`
+    Partitions    []PartitionRate       `json:"partitions,omitempty"`
+}
+
+// hwSample 为某一时刻全部主题分区的高水位（主题 → 分区 → offset）
+type hwSample struct {
+    at      time.Time
+    offsets map[string]map[int]int64
+}
+
+// ThroughputSampler 按固定间隔采样每个主题的高水位，由相邻采样之差计算 Kafka 侧的写入速率，
+// 与 StarRocks 侧的入库行数对照可区分吞吐下降来自生产端还是导入作业；采样只保存在内存中
+type ThroughputSampler struct {
+    cfg    config.Config
+    logger *zap.Logger
+    ka     *KafkaAdmin
+
+    mu      sync.Mutex
+    samples []hwSample
+}
+
+func NewThroughputSampler(cfg config.Config, logger *zap.Logger) *ThroughputSampler {
+    return &ThroughputSampler{cfg: cfg, logger: logger, ka: NewKafkaAdmin(cfg)}
+}
+
+// Run 启动后立即采样一次，之后按配置的间隔采样，直到 ctx 结束
+func (s *ThroughputSampler) Run(ctx context.Context) {
+    interval := time.Duration(s.cfg.Throughput.IntervalSec) * time.Second
+    if interval < 10*time.Second { interval = 10 * time.Second }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        if err := s.Sample(ctx); err != nil {
+            s.logger.Sugar().Warnw("throughput.sample.failed", "err", err)
+        }
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Sample 读取全部主题（内部主题除外）的高水位并追加一条采样
+func (s *ThroughputSampler) Sample(ctx context.Context) error {
+    parts, err := s.ka.readPartitions(ctx)
+    if err != nil { return err }
+    topics := map[string][]int{}
+    for _, p := range parts {
+        if strings.HasPrefix(p.Topic, "__") { continue }
+        topics[p.Topic] = append(topics[p.Topic], p.ID)
+    }
+    hw, err := s.ka.HighWatermarks(ctx, topics)
+    if err != nil { return err }
+    s.observe(hwSample{at: time.Now(), offsets: hw})
+    return nil
+}
+
+func (s *ThroughputSampler) observe(sample hwSample) {
+    keep := throughputWindows[len(throughputWindows)-1].d
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.samples = append(s.samples, sample)
+    // 保留最长窗口内的采样，另留一条窗口外的作为基线
+    cut := 0
+    for cut < len(s.samples)-1 && sample.at.Sub(s.samples[cut+1].at) >= keep { cut++ }
+    s.samples = s.samples[cut:]
+}
+
+// baseline 返回窗口起点对应的采样下标：窗口开始前的最后一条，不足一个窗口时为最早一条
+func baseline(samples []hwSample, since time.Time) int {
+    i := sort.Search(len(samples), func(i int) bool { return samples[i].at.After(since) })
+    if i > 0 { i-- }
+    return i
+}
+
+// partitionRate 计算分区在 [samples[from], 最新采样] 之间的写入量；分区在基线之后才出现时从其首次出现算起，
+// 高水位回退（主题被重建）时计为 0
+func partitionRate(samples []hwSample, from int, topic string, partition int) RateWindow {
+    last := samples[len(samples)-1]
+    cur, ok := last.offsets[topic][partition]
+    if !ok { return RateWindow{} }
+    for i := from; i < len(samples)-1; i++ {
+        base, ok := samples[i].offsets[topic][partition]
+        if !ok { continue }
+        rw := RateWindow{Seconds: int(last.at.Sub(samples[i].at).Seconds())}
+        rw.Messages = max(cur-base, 0)
+        if rw.Seconds > 0 { rw.PerSec = float64(rw.Messages) / last.at.Sub(samples[i].at).Seconds() }
+        return rw
+    }
+    return RateWindow{}
+}
+
+// Rates 返回各主题在 5m、1h、24h 窗口内的写入速率（按主题名排序），withPartitions 为 true 时附带分区明细；
+// 尚无采样时返回空列表
+func (s *ThroughputSampler) Rates(withPartitions bool) []TopicRate {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    out := []TopicRate{}
+    if len(s.samples) == 0 { return out }
+    for topic := range s.samples[len(s.samples)-1].offsets {
+        out = append(out, s.topicRate(topic, withPartitions))
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].Topic < out[j].Topic })
+    return out
+}
+
+// TopicRates 返回单个主题的速率及分区明细；主题不在最近一次采样中时返回 ErrNotFound
+func (s *ThroughputSampler) TopicRates(topic string) (*TopicRate, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if len(s.samples) == 0 {
+        return nil, fmt.Errorf("topic %s has no throughput samples: %w", topic, utils.ErrNotFound)
+    }
+    if _, ok := s.samples[len(s.samples)-1].offsets[topic]; !ok {
+        return nil, fmt.Errorf("topic %s has no throughput samples: %w", topic, utils.ErrNotFound)
+    }
+    tr := s.topicRate(topic, true)
+    return &tr, nil
+}
+
+// topicRate 计算主题在各窗口内的速率，调用方需持有锁且至少有一条采样
+func (s *ThroughputSampler) topicRate(topic string, withPartitions bool) TopicRate {
+    last := s.samples[len(s.samples)-1]
+    parts := last.offsets[topic]
+    tr := TopicRate{Topic: topic, Rates: map[string]RateWindow{}}
+    ids := make([]int, 0, len(parts))
+    for p, hw := range parts {
+        ids = append(ids, p)
+        tr.HighWatermark += hw
+    }
+    sort.Ints(ids)
+    if withPartitions {
+        tr.Partitions = make([]PartitionRate, len(ids))
+        for i, p := range ids {
+            tr.Partitions[i] = PartitionRate{Partition: p, HighWatermark: parts[p], Rates: map[string]RateWindow{}}
+        }
+    }
+    for _, w := range throughputWindows {
+        from := baseline(s.samples, last.at.Add(-w.d))
+        var total RateWindow
+        for i, p := range ids {
+            pr := partitionRate(s.samples, from, topic, p)
+            total.Messages += pr.Messages
+            total.PerSec += pr.PerSec
+            total.Seconds = max(total.Seconds, pr.Seconds)
+            if withPartitions { tr.Partitions[i].Rates[w.name] = pr }
+        }
+        tr.Rates[w.name] = total
+    }
+    return tr
+}
+
+// TotalPerSec 返回全部主题在 5 分钟窗口内的写入速率之和，尚无足够采样时返回 false
+func (s *ThroughputSampler) TotalPerSec() (float64, bool) {
+    var total float64
+    var ok bool
+    for _, tr := range s.Rates(false) {
+        rw := tr.Rates[throughputWindows[0].name]
+        if rw.Seconds > 0 { ok = true }
+        total += rw.PerSec
+    }
+    return total, ok
+}
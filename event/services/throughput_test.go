@@ -0,0 +1,108 @@
+package services
+
+import (
+    "errors"
+    "testing"
+    "time"
+
+    "event/utils"
+)
+
+func TestBaseline(t *testing.T) {
+    t0 := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+    samples := []hwSample{{at: t0}, {at: t0.Add(time.Minute)}, {at: t0.Add(2 * time.Minute)}, {at: t0.Add(3 * time.Minute)}}
+    cases := []struct {
+        name  string
+        since time.Time
+        want  int
+    }{
+        {"before first sample", t0.Add(-time.Hour), 0},
+        {"on first sample", t0, 0},
+        {"between samples", t0.Add(90 * time.Second), 1},
+        {"on a sample", t0.Add(2 * time.Minute), 2},
+        {"after last sample", t0.Add(time.Hour), 3},
+    }
+    for _, tc := range cases {
+        if got := baseline(samples, tc.since); got != tc.want {
+            t.Errorf("%s: baseline = %d, want %d", tc.name, got, tc.want)
+        }
+    }
+}
+
+func TestPartitionRate(t *testing.T) {
+    t0 := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+    sample := func(sec int, offs map[int]int64) hwSample {
+        s := hwSample{at: t0.Add(time.Duration(sec) * time.Second), offsets: map[string]map[int]int64{}}
+        if offs != nil { s.offsets["events"] = offs }
+        return s
+    }
+    cases := []struct {
+        name      string
+        samples   []hwSample
+        from      int
+        partition int
+        want      RateWindow
+    }{
+        {"steady", []hwSample{sample(0, map[int]int64{0: 100}), sample(60, map[int]int64{0: 160}), sample(120, map[int]int64{0: 220})},
+            0, 0, RateWindow{Seconds: 120, Messages: 120, PerSec: 1}},
+        {"from later baseline", []hwSample{sample(0, map[int]int64{0: 100}), sample(60, map[int]int64{0: 160}), sample(120, map[int]int64{0: 220})},
+            1, 0, RateWindow{Seconds: 60, Messages: 60, PerSec: 1}},
+        {"partition appears after baseline", []hwSample{sample(0, map[int]int64{0: 100}), sample(60, map[int]int64{0: 160, 1: 10}), sample(120, map[int]int64{0: 220, 1: 40})},
+            0, 1, RateWindow{Seconds: 60, Messages: 30, PerSec: 0.5}},
+        {"high watermark went back", []hwSample{sample(0, map[int]int64{0: 500}), sample(60, map[int]int64{0: 20})},
+            0, 0, RateWindow{Seconds: 60}},
+        {"missing in latest sample", []hwSample{sample(0, map[int]int64{0: 100}), sample(60, nil)},
+            0, 0, RateWindow{}},
+        {"single sample", []hwSample{sample(0, map[int]int64{0: 100})},
+            0, 0, RateWindow{}},
+        {"only in latest sample", []hwSample{sample(0, nil), sample(60, map[int]int64{0: 100})},
+            0, 0, RateWindow{}},
+    }
+    for _, tc := range cases {
+        if got := partitionRate(tc.samples, tc.from, "events", tc.partition); got != tc.want {
+            t.Errorf("%s: partitionRate = %+v, want %+v", tc.name, got, tc.want)
+        }
+    }
+}
+
+func TestSamplerRates(t *testing.T) {
+    s := &ThroughputSampler{}
+    if got := s.Rates(false); got == nil || len(got) != 0 {
+        t.Errorf("no samples: Rates = %#v, want empty slice", got)
+    }
+    if _, ok := s.TotalPerSec(); ok {
+        t.Error("no samples: TotalPerSec ok = true")
+    }
+    t0 := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+    // 超过 24h 的旧采样只保留一条作为基线
+    s.observe(hwSample{at: t0.Add(-48 * time.Hour), offsets: map[string]map[int]int64{"views": {0: 0}}})
+    s.observe(hwSample{at: t0.Add(-25 * time.Hour), offsets: map[string]map[int]int64{"views": {0: 0}}})
+    s.observe(hwSample{at: t0, offsets: map[string]map[int]int64{"views": {0: 0}, "clicks": {0: 0, 1: 0}}})
+    s.observe(hwSample{at: t0.Add(5 * time.Minute), offsets: map[string]map[int]int64{"views": {0: 600}, "clicks": {0: 300, 1: 300}}})
+    if len(s.samples) != 3 || !s.samples[0].at.Equal(t0.Add(-25*time.Hour)) {
+        t.Fatalf("retained %d samples starting at %v", len(s.samples), s.samples[0].at)
+    }
+
+    rates := s.Rates(false)
+    if len(rates) != 2 || rates[0].Topic != "clicks" || rates[1].Topic != "views" || rates[0].Partitions != nil {
+        t.Fatalf("Rates = %+v", rates)
+    }
+    if rw := rates[0].Rates["5m"]; rw != (RateWindow{Seconds: 300, Messages: 600, PerSec: 2}) || rates[0].HighWatermark != 600 {
+        t.Errorf("clicks 5m = %+v, hw %d", rw, rates[0].HighWatermark)
+    }
+    // views 在 24h 窗口内以窗口外的最后一条采样为基线
+    if rw := rates[1].Rates["24h"]; rw.Seconds != int((25*time.Hour + 5*time.Minute).Seconds()) || rw.Messages != 600 {
+        t.Errorf("views 24h = %+v", rw)
+    }
+    if total, ok := s.TotalPerSec(); !ok || total != 4 {
+        t.Errorf("TotalPerSec = %v, %v, want 4, true", total, ok)
+    }
+
+    tr, err := s.TopicRates("clicks")
+    if err != nil || len(tr.Partitions) != 2 || tr.Partitions[1].Partition != 1 || tr.Partitions[1].Rates["1h"].Messages != 300 {
+        t.Errorf("TopicRates = %+v, err %v", tr, err)
+    }
+    if _, err := s.TopicRates("orders"); !errors.Is(err, utils.ErrNotFound) {
+        t.Errorf("unknown topic: err = %v, want ErrNotFound", err)
+    }
+}
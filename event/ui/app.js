@@ -40,6 +40,10 @@ document.addEventListener('DOMContentLoaded', () => {
   setupJobsSearch();
   // 分页控件交互绑定
   setupJobsPagination();
+  // 血缘页节点聚焦
+  setupLineagePage();
+  // 主题详情弹窗
+  setupTopicDetail();
   // 初始化分页默认值
   window.__jobsPage = 1;
   window.__jobsPageSize = 12;
@@ -58,9 +62,12 @@ function initNavRouting() {
     if (target === 'overview') {
       loadSummary();
     } else if (target === 'topics') {
+      loadKafkaCluster();
       loadKafkaTopicsInto('topics-page-list');
     } else if (target === 'jobs') {
       loadStarRocksJobsInto('jobs-page-list');
+    } else if (target === 'lineage') {
+      loadLineage();
     }
   }
   navItems.forEach(link => {
@@ -86,9 +93,19 @@ async function loadSummary() {
     const tp = s?.throughput?.current ?? 0;
     setStatByTitle('每分钟吞吐', formatNumber(tp));
     setProgressPercent(tp > 0 ? Math.min(100, Math.round(tp / 1000)) : 0);
+    const rateBox = document.getElementById('stat-kafka-rate');
+    if (rateBox) {
+      const kpm = s?.throughput?.kafka_per_min;
+      rateBox.textContent = kpm != null ? 'Kafka 写入 ' + formatNumber(Math.round(kpm)) + ' 条/分钟' : '';
+    }
     setStatByTitle('错误行（近10分钟）', s?.errors?.last_10m ?? 0, true);
     const lagMs = s?.lag?.p95_ms ?? 0;
     setStatByTitle('消费延迟', (lagMs/1000).toFixed(1) + 's');
+    const lagBox = document.getElementById('stat-lag-messages');
+    if (lagBox) {
+      const msgs = Number(s?.lag?.messages ?? 0);
+      lagBox.textContent = '积压 ' + formatNumber(msgs) + ' 条' + (s?.lag?.top_job && msgs > 0 ? '（最多：' + s.lag.top_job + '）' : '');
+    }
 
     // 状态分布
     const distBox = document.getElementById('distribution-grid');
@@ -100,6 +117,13 @@ async function loadSummary() {
     if (anBox) {
       anBox.innerHTML = anoms.length ? anoms.map(renderAnomalyCard).join('') : '<div class="empty muted">暂无异常</div>';
     }
+
+    // 管道健康：列出非 healthy 的管道及全部原因
+    const hBox = document.getElementById('pipeline-health-list');
+    const unhealthy = Array.isArray(s?.pipelines?.unhealthy) ? s.pipelines.unhealthy : [];
+    if (hBox) {
+      hBox.innerHTML = unhealthy.length ? unhealthy.map(renderPipelineHealthCard).join('') : '<div class="empty muted">全部管道健康</div>';
+    }
   } catch (e) {
     console.warn('加载摘要失败', e);
     const distBox = document.getElementById('distribution-grid');
@@ -279,6 +303,8 @@ function setupJobDetailModal() {
   if (overlay) overlay.addEventListener('click', close);
   if (closeBtn) closeBtn.addEventListener('click', close);
   document.addEventListener('keydown', (e) => { if (e.key === 'Escape') close(); });
+  document.getElementById('btn-jd-tasks')?.addEventListener('click', () => loadJobTasks(modal.dataset.job));
+  document.getElementById('btn-jd-errors')?.addEventListener('click', () => loadJobErrors(modal.dataset.job));
 }
 
 async function openJobDetail(name) {
@@ -328,7 +354,154 @@ async function openJobDetail(name) {
       });
     }
   }
+  modal.dataset.job = d.name || name;
+  document.getElementById('jd-errors').textContent = '—';
   modal.classList.remove('hidden');
+  loadJobImpact(d.name || name);
+  loadJobTasks(d.name || name);
+}
+
+// 作业详情中展示当前导入任务（SHOW ROUTINE LOAD TASK）
+async function loadJobTasks(name) {
+  const box = document.getElementById('jd-tasks');
+  if (!box || !name) return;
+  box.textContent = '加载中...';
+  try {
+    const res = await fetch(`/api/starrocks/jobs/${encodeURIComponent(name)}/tasks`);
+    const tasks = await res.json();
+    if (!res.ok) throw new Error(tasks?.error || '请求失败');
+    if (!tasks.length) { box.textContent = '无运行中的任务'; return; }
+    const rows = tasks.map(t => {
+      const offs = (t.offsets || []).map(o => `${o.partition}:${o.offset}`).join(' ');
+      return `<tr><td>${escapeHTML(t.task_id)}</td><td>${escapeHTML(t.txn_status || '—')}</td><td>${escapeHTML(t.be_id || '—')}</td><td>${escapeHTML(t.execute_start_time || t.create_time || '—')}</td><td>${escapeHTML(offs || '—')}</td><td>${escapeHTML(t.message || '')}</td></tr>`;
+    }).join('');
+    box.innerHTML = `<table class="partition-table"><thead><tr><th>任务</th><th>事务状态</th><th>BE</th><th>开始时间</th><th>分区:起始 offset</th><th>信息</th></tr></thead><tbody>${rows}</tbody></table>`;
+  } catch (e) {
+    box.textContent = '任务加载失败：' + e.message;
+  }
+}
+
+// 作业详情中读取 BE 错误日志，展示被拒绝的行及原因
+async function loadJobErrors(name) {
+  const box = document.getElementById('jd-errors');
+  if (!box || !name) return;
+  box.textContent = '读取中...';
+  try {
+    const res = await fetch(`/api/starrocks/jobs/${encodeURIComponent(name)}/errors`);
+    const log = await res.json();
+    if (!res.ok && !log?.urls) throw new Error(log?.error || '请求失败');
+    const fetchErrors = (log.fetch_errors || []).map(e => `<li>${escapeHTML(e)}</li>`).join('');
+    if (!log.urls.length) { box.textContent = `累计错误 ${log.error_rows} 行，暂无错误日志`; return; }
+    const rows = log.rows.map(r => `<tr><td>${escapeHTML(r.reason)}</td><td><code>${escapeHTML(r.row || '')}</code></td></tr>`).join('');
+    box.innerHTML = `
+      <p class="muted">累计错误 ${log.error_rows} 行，显示 ${log.rows.length} 行${log.truncated ? '（已截断）' : ''}</p>
+      ${rows ? `<table class="partition-table"><thead><tr><th>原因</th><th>数据</th></tr></thead><tbody>${rows}</tbody></table>` : ''}
+      ${fetchErrors ? `<ul class="muted">${fetchErrors}</ul>` : ''}`;
+  } catch (e) {
+    box.textContent = '错误日志读取失败：' + e.message;
+  }
+}
+
+// 作业详情中展示暂停/修改该作业会影响的下游表与物化视图
+async function loadJobImpact(name) {
+  const box = document.getElementById('jd-impact');
+  if (!box) return;
+  box.textContent = '加载中...';
+  try {
+    const res = await fetch('/api/lineage?node=' + encodeURIComponent('job:' + name));
+    const g = await res.json();
+    if (!res.ok) throw new Error(g?.error || '请求失败');
+    const down = downstreamOf(g, 'job:' + name).filter(n => n.type === 'table' || n.type === 'mv');
+    box.textContent = down.length ? down.map(n => (n.type === 'mv' ? '物化视图 ' : '表 ') + n.name).join('，') : '无下游';
+  } catch (e) {
+    box.textContent = '血缘加载失败：' + e.message;
+  }
+}
+
+function downstreamOf(g, id) {
+  const adj = {};
+  (g.edges || []).forEach(e => { (adj[e.from] = adj[e.from] || []).push(e.to); });
+  const seen = new Set([id]);
+  const stack = [id];
+  while (stack.length) {
+    (adj[stack.pop()] || []).forEach(n => { if (!seen.has(n)) { seen.add(n); stack.push(n); } });
+  }
+  seen.delete(id);
+  return (g.nodes || []).filter(n => seen.has(n.id));
+}
+
+// ===== 数据血缘 =====
+function setupLineagePage() {
+  const input = document.getElementById('lineage-node-input');
+  const reset = document.getElementById('btn-lineage-reset');
+  if (input) input.addEventListener('keydown', (e) => { if (e.key === 'Enter') loadLineage(input.value.trim()); });
+  if (reset) reset.addEventListener('click', () => { if (input) input.value = ''; loadLineage(); });
+  const box = document.getElementById('lineage-graph');
+  if (box) box.addEventListener('click', (e) => {
+    const node = e.target.closest('.lineage-node');
+    if (!node) return;
+    if (input) input.value = node.dataset.id;
+    loadLineage(node.dataset.id);
+  });
+  window.addEventListener('resize', () => drawLineageEdges());
+}
+
+async function loadLineage(node) {
+  const box = document.getElementById('lineage-graph');
+  if (!box) return;
+  try {
+    const url = node ? '/api/lineage?node=' + encodeURIComponent(node) : '/api/lineage';
+    const res = await fetch(url);
+    const g = await res.json();
+    if (!res.ok) throw new Error(g?.error || '请求失败');
+    window.__lineage = g;
+    renderLineage(box, g);
+  } catch (e) {
+    console.warn('加载血缘失败', e);
+    box.innerHTML = `<div class="empty muted">加载失败：${e.message}</div>`;
+  }
+}
+
+function renderLineage(box, g) {
+  const nodes = g.nodes || [];
+  if (nodes.length === 0) {
+    box.innerHTML = '<div class="empty muted">暂无血缘数据</div>';
+    return;
+  }
+  const cols = [['topic', 'Kafka 主题'], ['job', 'Routine Load'], ['table', '表'], ['mv', '物化视图']];
+  const html = cols.map(([type, title]) => {
+    const items = nodes.filter(n => n.type === type).map(n => {
+      const sub = [n.state, n.pipeline ? '管道 ' + n.pipeline : ''].filter(Boolean).join(' · ');
+      const cls = n.state === 'MISSING' ? 'lineage-node missing' : 'lineage-node';
+      return `<div class="${cls}" data-id="${n.id}">${n.name}${sub ? `<span class="muted">${sub}</span>` : ''}</div>`;
+    }).join('');
+    return `<div class="lineage-col"><h4>${title}</h4>${items || '<span class="muted">—</span>'}</div>`;
+  }).join('');
+  const warn = (g.warnings || []).length ? `<div class="empty muted">${g.warnings.join('；')}</div>` : '';
+  box.innerHTML = html + warn + '<svg class="lineage-edges"></svg>';
+  drawLineageEdges();
+}
+
+// 按节点的实际位置绘制连线（从左列节点右侧到右列节点左侧的贝塞尔曲线）
+function drawLineageEdges() {
+  const box = document.getElementById('lineage-graph');
+  const svg = box && box.querySelector('.lineage-edges');
+  const g = window.__lineage;
+  if (!svg || !g) return;
+  const origin = box.getBoundingClientRect();
+  const pos = {};
+  box.querySelectorAll('.lineage-node').forEach(el => {
+    const r = el.getBoundingClientRect();
+    pos[el.dataset.id] = { l: r.left - origin.left, r: r.right - origin.left, y: r.top - origin.top + r.height / 2 };
+  });
+  svg.innerHTML = (g.edges || []).map(e => {
+    const a = pos[e.from], b = pos[e.to];
+    if (!a || !b) return '';
+    // 物化视图之间的依赖位于同一列，改为从右侧绕出
+    const x1 = a.r, x2 = (a.l === b.l) ? b.r : b.l;
+    const mid = (a.l === b.l) ? x1 + 24 : (x1 + x2) / 2;
+    return `<path d="M${x1},${a.y} C${mid},${a.y} ${mid},${b.y} ${x2},${b.y}"/>`;
+  }).join('');
 }
 
 function setupEditJobForm() {
@@ -457,9 +630,17 @@ function renderDistributionCards(s) {
     { label: '欠副本', value: under, color: 'var(--warn)' },
   ];
   const kafkaTitle = 'Kafka 副本健康';
+  const p = s?.pipelines || {};
+  const healthItems = [
+    { label: '健康', value: Number(p.healthy || 0), color: 'var(--success)' },
+    { label: '降级', value: Number(p.degraded || 0), color: 'var(--warn)' },
+    { label: '停滞', value: Number(p.stalled || 0), color: 'var(--info)' },
+    { label: '失败', value: Number(p.failed || 0), color: 'var(--error)' },
+  ];
   const cards = [
     renderPieCard('作业状态分布', jobsTotal, jobsItems),
-    renderPieCard(kafkaTitle, parts, kafkaItems, { extra: `<div class="kv"><div><span class="key">主题</span><span class="val">${Number(k.topics||0)}</span></div></div>` })
+    renderPieCard('管道健康度', Number(p.total || 0), healthItems),
+    renderPieCard(kafkaTitle, parts, kafkaItems, { extra: `<div class="kv"><div><span class="key">主题</span><span class="val">${Number(k.topics||0)}</span></div><div><span class="key">无 leader 分区</span><span class="val">${Number(k.offline_partitions||0)}</span></div></div>` })
   ];
   return cards.join('');
 }
@@ -511,6 +692,7 @@ function renderAnomalyCard(a) {
   const type = a?.type || '-';
   const state = (a?.state || '-').toUpperCase();
   const cls = state === 'FAILED' ? 'warn' : state === 'PAUSED' ? 'warn' : 'info';
+  const reason = a?.reason ? `<p class="muted">${a.reason}</p>` : '';
   return `
     <article class="data-card">
       <div class="card-header">
@@ -520,6 +702,26 @@ function renderAnomalyCard(a) {
         </div>
         <span class="badge ${cls}">${state}</span>
       </div>
+      ${reason}
+    </article>
+  `;
+}
+
+function renderPipelineHealthCard(h) {
+  const health = (h?.health || '-').toUpperCase();
+  const cls = health === 'FAILED' ? 'error' : health === 'DEGRADED' ? 'warn' : 'info';
+  const reasons = (h?.reasons || []).map(r => `<li>${r}</li>`).join('');
+  const team = h?.team ? ` · ${h.team}` : '';
+  return `
+    <article class="data-card">
+      <div class="card-header">
+        <div>
+          <h3>${h?.pipeline || '-'}</h3>
+          <p class="muted">作业 ${h?.job || '-'} · ${h?.state || '-'}${team}</p>
+        </div>
+        <span class="badge ${cls}">${health}</span>
+      </div>
+      <ul class="muted">${reasons}</ul>
     </article>
   `;
 }
@@ -557,20 +759,62 @@ async function loadKafkaTopicsInto(containerId) {
   }
 }
 
+// 集群概览：每个 broker 一张卡片，另列出不可达的配置地址
+async function loadKafkaCluster() {
+  const box = document.getElementById('kafka-cluster');
+  if (!box) return;
+  try {
+    const res = await fetch('/api/kafka/cluster');
+    const c = await res.json();
+    const down = (c.configured || []).filter(a => !a.reachable);
+    const brokers = (c.brokers || []).map(b => {
+      const badge = b.reachable ? `<span class="badge info">${b.latency_ms} ms</span>` : '<span class="badge error">不可达</span>';
+      return `
+        <article class="data-card">
+          <div class="card-header">
+            <div>
+              <h3>Broker ${b.id}${b.controller ? ' · controller' : ''}</h3>
+              <p class="muted">${escapeHTML(b.host)}:${b.port}${b.rack ? ' · ' + escapeHTML(b.rack) : ''}</p>
+            </div>
+            ${badge}
+          </div>
+          ${b.error ? `<p class="muted">${escapeHTML(b.error)}</p>` : ''}
+        </article>`;
+    }).join('');
+    const addrs = down.map(a => `
+      <article class="data-card">
+        <div class="card-header">
+          <div><h3>${escapeHTML(a.address)}</h3><p class="muted">配置地址</p></div>
+          <span class="badge error">不可达</span>
+        </div>
+        <p class="muted">${escapeHTML(a.error || '')}</p>
+      </article>`).join('');
+    box.innerHTML = brokers + addrs || `<div class="empty muted">${escapeHTML(c.error || '暂无集群信息')}</div>`;
+  } catch (e) {
+    console.warn('加载 Kafka 集群信息失败', e);
+    box.innerHTML = '<div class="empty muted">加载失败，请稍后重试</div>';
+  }
+}
+
 function renderTopicCard(t) {
   const name = t.name || '-';
   const partitions = t.partitions != null ? t.partitions : '-';
+  const offline = Number(t.offline || 0);
+  const under = Number(t.under_replicated || 0);
+  const badge = offline > 0 ? `<span class="badge error">${offline} 分区无 leader</span>`
+    : under > 0 ? `<span class="badge warn">${under} 分区欠副本</span>`
+    : `<span class="badge info">${partitions} 分区</span>`;
   return `
-    <article class="data-card">
+    <article class="data-card" data-name="${name}">
       <div class="card-header">
         <div>
           <h3>${name}</h3>
-          <p class="muted">Kafka 主题</p>
+          <p class="muted">Kafka 主题 · ${partitions} 分区</p>
         </div>
-        <span class="badge info">${partitions} 分区</span>
+        ${badge}
       </div>
       <div class="kv">
-        <div><span class="key">副本因子</span><span class="val">—</span></div>
+        <div><span class="key">副本因子</span><span class="val">${t.replication_factor || '—'}</span></div>
         <div><span class="key">消费组</span><span class="val">—</span></div>
       </div>
     </article>
@@ -610,14 +854,17 @@ async function loadStarRocksJobsInto(containerId) {
     if (q) size = Math.max(size, 60);
     const params = new URLSearchParams({ page: String(page), page_size: String(size) });
     if (filter !== 'ALL') params.set('state', filter);
-    const res = await fetch('/api/starrocks/jobs?' + params.toString());
+    const [res, pipesByJob] = await Promise.all([
+      fetch('/api/starrocks/jobs?' + params.toString()),
+      loadPipelinesByJob(),
+    ]);
     const jobs = await res.json();
     if (!Array.isArray(jobs) || jobs.length === 0) {
       box.innerHTML = '<div class="empty muted">暂无作业数据</div>';
       updateJobsPagination(0);
       return;
     }
-    box.innerHTML = jobs.map(j => renderJobCard(j)).join('');
+    box.innerHTML = jobs.map(j => renderJobCard(j, pipesByJob[j.name])).join('');
     // 更新分页信息
     const total = Number(res.headers.get('X-Total-Count') || '0');
     updateJobsPagination(total);
@@ -629,7 +876,27 @@ async function loadStarRocksJobsInto(containerId) {
   }
 }
 
-function renderJobCard(j) {
+// 拉取管道定义并按作业名索引，用于在作业卡片上展示负责团队并支持按团队、标签、描述搜索
+async function loadPipelinesByJob() {
+  try {
+    const res = await fetch('/api/pipelines');
+    const pipes = await res.json();
+    const out = {};
+    if (Array.isArray(pipes)) pipes.forEach(p => { if (p.job_name) out[p.job_name] = p; });
+    return out;
+  } catch (e) {
+    console.warn('加载管道定义失败', e);
+    return {};
+  }
+}
+
+function pipelineSearchText(p) {
+  if (!p) return '';
+  const labels = Object.entries(p.labels || {}).map(([k, v]) => `${k}=${v}`);
+  return [p.name, p.team, p.owner, p.description, p.source_topic, ...labels].filter(Boolean).join(' ').toLowerCase();
+}
+
+function renderJobCard(j, pipe) {
   const name = j.name || '-';
   const state = (j.state || '-').toUpperCase();
   const table = j.table || '-';
@@ -641,8 +908,9 @@ function renderJobCard(j) {
     : (state === 'PAUSED')
       ? '<button class="primary" data-action="resume">恢复</button><button class="ghost" data-action="edit">修改</button><button class="secondary" data-action="stop">停止</button>'
       : '<button class="ghost" data-action="edit">修改</button><button class="secondary" data-action="delete">删除</button>';
+  const team = pipe ? (pipe.team || pipe.owner || '—') : '—';
   return `
-    <article class="data-card" data-name="${name}" data-table="${table}" data-state="${state}">
+    <article class="data-card" data-name="${name}" data-table="${table}" data-state="${state}" data-search="${pipelineSearchText(pipe).replace(/"/g, '&quot;')}">
       <div class="card-header">
         <div>
           <h3>${name}</h3>
@@ -653,6 +921,8 @@ function renderJobCard(j) {
       <div class="kv">
         <div><span class="key">已处理行</span><span class="val">${processed!=null ? processed : '—'}</span></div>
         <div><span class="key">错误行</span><span class="val">${errors!=null ? errors : '—'}</span></div>
+        <div><span class="key">积压消息</span><span class="val">${typeof j.lag === 'number' ? formatNumber(j.lag) : '—'}</span></div>
+        <div><span class="key">负责团队</span><span class="val">${team}</span></div>
       </div>
       <div class="card-footer">
         ${footer}
@@ -719,7 +989,9 @@ function applyJobFilter() {
     const byFilter = (filter === 'ALL') || (state === filter);
     const name = (card.dataset.name || '').toLowerCase();
     const table = (card.dataset.table || '').toLowerCase();
-    const byQuery = !q || name.includes(q) || table.includes(q);
+    const extra = card.dataset.search || '';
+    // 多个词须全部命中（与 /api/pipelines?q= 一致），可按团队、负责人、标签 key=value 或描述搜索
+    const byQuery = !q || q.split(/\s+/).every(w => name.includes(w) || table.includes(w) || extra.includes(w));
     const show = byFilter && byQuery;
     card.style.display = show ? '' : 'none';
     if (show) anyVisible = true;
@@ -774,4 +1046,177 @@ function setupJobsSearch() {
     window.__jobsPage = 1;
     loadStarRocksJobsInto('jobs-page-list');
   });
-}
\ No newline at end of file
+}
+
+// ===== 主题详情 =====
+function setupTopicDetail() {
+  const box = document.getElementById('topics-page-list');
+  const modal = document.getElementById('modal-topic-detail');
+  if (!box || !modal) return;
+  box.addEventListener('click', (e) => {
+    const name = e.target.closest('h3') && e.target.closest('.data-card')?.dataset?.name;
+    if (!name) return;
+    openTopicDetail(name).catch(err => {
+      console.warn('加载主题详情失败', err);
+      alert('加载主题详情失败：' + (err?.message || '未知错误'));
+    });
+  });
+  const close = () => modal.classList.add('hidden');
+  modal.querySelector('.modal-overlay')?.addEventListener('click', close);
+  document.getElementById('btn-close-topic-detail')?.addEventListener('click', close);
+  document.getElementById('btn-td-messages')?.addEventListener('click', () => loadTopicMessages(modal.dataset.topic));
+  document.getElementById('btn-td-schema')?.addEventListener('click', () => loadTopicSchema(modal.dataset.topic));
+  document.getElementById('btn-td-config-save')?.addEventListener('click', () => saveTopicConfig(modal.dataset.topic));
+  document.getElementById('btn-td-partitions')?.addEventListener('click', () => addTopicPartitions(modal.dataset.topic));
+  document.addEventListener('keydown', (e) => { if (e.key === 'Escape') close(); });
+}
+
+async function openTopicDetail(name) {
+  const res = await fetch(`/api/kafka/topics/${encodeURIComponent(name)}`);
+  const d = await res.json();
+  if (!res.ok) throw new Error(d?.error || '请求失败');
+  const modal = document.getElementById('modal-topic-detail');
+  modal.dataset.topic = d.name;
+  document.getElementById('td-messages').textContent = '—';
+  document.getElementById('td-schema').textContent = '—';
+  document.getElementById('td-title').textContent = d.name;
+  const kv = [
+    ['分区数', d.partitions], ['副本因子', d.replication_factor], ['消息数', d.messages],
+    ['欠副本分区', d.under_replicated], ['无 leader 分区', d.offline],
+  ];
+  document.getElementById('td-basic').innerHTML = kv.map(([k, v]) => `<div><span class="key">${k}</span><span class="val">${v ?? '—'}</span></div>`).join('');
+  const rows = (d.partition_list || []).map(p => {
+    const cls = p.leader < 0 ? 'error' : p.under_replicated ? 'warn' : '';
+    return `<tr class="${cls}">
+      <td>${p.partition}</td><td>${p.leader < 0 ? '无' : p.leader}</td>
+      <td>${(p.replicas || []).join(', ')}</td><td>${(p.isr || []).join(', ')}</td>
+      <td>${p.low_watermark}</td><td>${p.high_watermark}</td><td>${p.error || ''}</td>
+    </tr>`;
+  }).join('');
+  document.getElementById('td-partitions').innerHTML = `<table class="partition-table">
+    <thead><tr><th>分区</th><th>Leader</th><th>副本</th><th>ISR</th><th>低水位</th><th>高水位</th><th></th></tr></thead>
+    <tbody>${rows}</tbody></table>`;
+  modal.classList.remove('hidden');
+  loadTopicConfig(d.name);
+}
+
+// 可在主题详情中直接修改的配置项，其余主题级覆盖只读展示
+const EDITABLE_TOPIC_CONFIGS = ['retention.ms', 'retention.bytes', 'max.message.bytes', 'cleanup.policy'];
+
+async function loadTopicConfig(topic) {
+  const box = document.getElementById('td-config');
+  if (!box || !topic) return;
+  box.textContent = '加载中...';
+  try {
+    const res = await fetch(`/api/kafka/topics/${encodeURIComponent(topic)}/config`);
+    const d = await res.json();
+    if (!res.ok) throw new Error(d?.error || '请求失败');
+    const byName = Object.fromEntries((d.configs || []).map(c => [c.name, c]));
+    const editable = EDITABLE_TOPIC_CONFIGS.filter(n => byName[n]).map(n => {
+      const c = byName[n];
+      return `<tr><td>${n}</td><td><input data-config="${n}" data-orig="${escapeHTML(c.value)}" value="${escapeHTML(c.value)}"></td><td>${c.default ? '默认' : '已覆盖'}</td></tr>`;
+    }).join('');
+    const others = (d.configs || []).filter(c => !c.default && !EDITABLE_TOPIC_CONFIGS.includes(c.name))
+      .map(c => `<tr><td>${escapeHTML(c.name)}</td><td>${escapeHTML(c.value)}</td><td>已覆盖</td></tr>`).join('');
+    const history = (d.history || []).slice(0, 10).map(h =>
+      `<li>${new Date(h.at).toLocaleString()} ${escapeHTML(h.author)}：${escapeHTML(h.config)} ${escapeHTML(h.old)} → ${h.reset ? '默认值' : escapeHTML(h.new)}</li>`).join('');
+    box.innerHTML = `<table class="partition-table"><thead><tr><th>配置</th><th>值</th><th>来源</th></tr></thead>
+      <tbody>${editable}${others}</tbody></table>
+      ${history ? `<ul class="muted">${history}</ul>` : ''}`;
+  } catch (e) {
+    box.textContent = '加载配置失败：' + e.message;
+  }
+}
+
+async function addTopicPartitions(topic) {
+  if (!topic) return;
+  const input = prompt(`将主题 ${topic} 的分区数增加到（不能减少）：`);
+  const count = parseInt(input, 10);
+  if (!input || !(count > 0)) return;
+  try {
+    const res = await fetch(`/api/kafka/topics/${encodeURIComponent(topic)}/partitions`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ count, restart_jobs: confirm('是否暂停并恢复尚未覆盖新分区的运行中作业？') }),
+    });
+    const d = await res.json();
+    if (!res.ok) throw new Error(d?.error || '请求失败');
+    const lines = (d.jobs || []).map(j => `${j.job}：${j.picked_up ? '已覆盖全部分区' : `缺少分区 ${j.missing.join(', ')}`}${j.explicit ? '（指定了 kafka_partitions，需要重建）' : ''}${j.restarted ? '，已重启' : ''}${j.error ? '，' + j.error : ''}`);
+    alert(`分区数 ${d.previous} → ${d.partitions}\n` + (lines.length ? lines.join('\n') : (d.jobs_error ? '作业检查失败：' + d.jobs_error : '没有消费该主题的作业')));
+    openTopicDetail(topic).catch(() => {});
+  } catch (e) {
+    alert('扩容失败：' + e.message);
+  }
+}
+
+async function saveTopicConfig(topic) {
+  const inputs = [...document.querySelectorAll('#td-config input[data-config]')];
+  const configs = {};
+  inputs.forEach(i => { if (i.value.trim() !== i.dataset.orig) configs[i.dataset.config] = i.value.trim(); });
+  if (!Object.keys(configs).length) { alert('没有修改'); return; }
+  try {
+    const res = await fetch(`/api/kafka/topics/${encodeURIComponent(topic)}/config`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ configs }),
+    });
+    const d = await res.json();
+    if (!res.ok) throw new Error(d?.error || '请求失败');
+    loadTopicConfig(topic);
+  } catch (e) {
+    alert('保存配置失败：' + e.message);
+  }
+}
+
+async function loadTopicMessages(topic) {
+  const box = document.getElementById('td-messages');
+  if (!box || !topic) return;
+  const params = new URLSearchParams();
+  ['partition', 'offset', 'limit'].forEach(k => {
+    const v = document.getElementById('td-msg-' + k)?.value.trim();
+    if (v) params.set(k, v);
+  });
+  box.textContent = '加载中...';
+  try {
+    const res = await fetch(`/api/kafka/topics/${encodeURIComponent(topic)}/messages?${params}`);
+    const msgs = await res.json();
+    if (!res.ok) throw new Error(msgs?.error || '请求失败');
+    if (!msgs.length) { box.textContent = '没有消息'; return; }
+    box.innerHTML = msgs.map(m => {
+      const headers = m.headers ? Object.entries(m.headers).map(([k, v]) => `${escapeHTML(k)}=${escapeHTML(v)}`).join(', ') : '';
+      const meta = [`分区 ${m.partition}`, `offset ${m.offset}`, new Date(m.timestamp).toLocaleString()];
+      if (m.key) meta.push('key ' + escapeHTML(m.key));
+      if (headers) meta.push(headers);
+      if (m.encoding) meta.push(m.encoding);
+      if (m.truncated) meta.push('已截断');
+      return `<div class="message-item"><div class="muted">${meta.join(' · ')}</div><pre class="code">${escapeHTML(m.value)}</pre></div>`;
+    }).join('');
+  } catch (e) {
+    box.textContent = '加载消息失败：' + e.message;
+  }
+}
+
+async function loadTopicSchema(topic) {
+  const box = document.getElementById('td-schema');
+  if (!box || !topic) return;
+  box.textContent = '采样中...';
+  try {
+    const res = await fetch(`/api/kafka/topics/${encodeURIComponent(topic)}/schema`);
+    const s = await res.json();
+    if (!res.ok) throw new Error(s?.error || '请求失败');
+    const rows = (s.fields || []).map(f => `<tr><td>${escapeHTML(f.path)}</td><td>${escapeHTML(f.column)}</td><td>${f.type}</td><td>${f.column_type}</td><td>${f.presence}/${s.sampled}</td></tr>`).join('');
+    const warnings = (s.warnings || []).map(w => `<li>${escapeHTML(w)}</li>`).join('');
+    box.innerHTML = `
+      <p class="muted">样本 ${s.sampled} 条，跳过 ${s.skipped} 条非 JSON 消息</p>
+      <table class="partition-table"><thead><tr><th>jsonpath</th><th>列</th><th>类型</th><th>列类型</th><th>出现</th></tr></thead><tbody>${rows}</tbody></table>
+      ${warnings ? `<ul class="muted">${warnings}</ul>` : ''}
+      <pre class="code">${escapeHTML(s.ddl)};</pre>
+      <pre class="code">${escapeHTML(JSON.stringify(s.routine_load, null, 2))}</pre>`;
+  } catch (e) {
+    box.textContent = '推断失败：' + e.message;
+  }
+}
+
+function escapeHTML(s) {
+  return String(s ?? '').replace(/[&<>"']/g, c => ({ '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' }[c]));
+}
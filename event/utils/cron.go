@@ -0,0 +1,104 @@
+package utils
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// CronExpr 为标准 5 段 cron 表达式（分 时 日 月 周），支持 *、*/n、a-b、a-b/n 与逗号列表；
+// 周取值 0-7，0 与 7 均为周日。日与周同时受限时按 cron 惯例任一匹配即可
+type CronExpr struct {
+    minute, hour, dom, month, dow uint64
+    domAny, dowAny                bool
+}
+
+var cronBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// ParseCron 解析 cron 表达式
+func ParseCron(s string) (*CronExpr, error) {
+    fields := strings.Fields(s)
+    if len(fields) != 5 {
+        return nil, fmt.Errorf("%w: cron %q must have 5 fields", ErrInvalid, s)
+    }
+    var bits [5]uint64
+    for i, f := range fields {
+        b, err := parseCronField(f, cronBounds[i][0], cronBounds[i][1])
+        if err != nil { return nil, fmt.Errorf("%w: cron %q: %v", ErrInvalid, s, err) }
+        bits[i] = b
+    }
+    // 周日统一为 0
+    if bits[4]&(1<<7) != 0 { bits[4] = bits[4]&^(1<<7) | 1 }
+    return &CronExpr{
+        minute: bits[0], hour: bits[1], dom: bits[2], month: bits[3], dow: bits[4],
+        domAny: fields[2] == "*", dowAny: fields[4] == "*",
+    }, nil
+}
+
+func parseCronField(f string, lo, hi int) (uint64, error) {
+    var out uint64
+    for _, part := range strings.Split(f, ",") {
+        rng, stepStr, hasStep := strings.Cut(part, "/")
+        step := 1
+        if hasStep {
+            n, err := strconv.Atoi(stepStr)
+            if err != nil || n < 1 { return 0, fmt.Errorf("invalid step %q", part) }
+            step = n
+        }
+        start, end := lo, hi
+        switch {
+        case rng == "*":
+        case strings.Contains(rng, "-"):
+            a, b, _ := strings.Cut(rng, "-")
+            x, err1 := strconv.Atoi(a)
+            y, err2 := strconv.Atoi(b)
+            if err1 != nil || err2 != nil || x > y { return 0, fmt.Errorf("invalid range %q", part) }
+            start, end = x, y
+        default:
+            x, err := strconv.Atoi(rng)
+            if err != nil { return 0, fmt.Errorf("invalid value %q", part) }
+            start, end = x, x
+            if hasStep { end = hi }
+        }
+        if start < lo || end > hi { return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi) }
+        for v := start; v <= end; v += step { out |= 1 << uint(v) }
+    }
+    return out, nil
+}
+
+// Match 判断 t 所在的分钟是否命中表达式（使用 t 自身的时区）
+func (c *CronExpr) Match(t time.Time) bool {
+    if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+        return false
+    }
+    return c.dayMatch(t)
+}
+
+func (c *CronExpr) dayMatch(t time.Time) bool {
+    domOK := c.dom&(1<<uint(t.Day())) != 0
+    dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+    if c.domAny || c.dowAny { return domOK && dowOK }
+    return domOK || dowOK
+}
+
+// Next 返回 after 之后（不含）第一个命中的分钟；五年内无命中（如 2 月 30 日）时返回零值
+func (c *CronExpr) Next(after time.Time) time.Time {
+    t := after.Truncate(time.Minute).Add(time.Minute)
+    limit := t.AddDate(5, 0, 0)
+    for t.Before(limit) {
+        switch {
+        case c.month&(1<<uint(t.Month())) == 0:
+            t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+        case !c.dayMatch(t):
+            t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+        case c.hour&(1<<uint(t.Hour())) == 0:
+            t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+        case c.minute&(1<<uint(t.Minute())) == 0:
+            t = t.Add(time.Minute)
+        default:
+            return t
+        }
+    }
+    return time.Time{}
+}
\ No newline at end of file
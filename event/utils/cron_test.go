@@ -0,0 +1,104 @@
+package utils
+
+import (
+    "errors"
+    "testing"
+    "time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+    cases := []string{
+        "",
+        "* * * *",
+        "* * * * * *",
+        "60 * * * *",
+        "* 24 * * *",
+        "* * 0 * *",
+        "* * * 13 *",
+        "* * * * 8",
+        "*/0 * * * *",
+        "5-1 * * * *",
+        "a * * * *",
+        "1-x * * * *",
+    }
+    for _, s := range cases {
+        if _, err := ParseCron(s); !errors.Is(err, ErrInvalid) {
+            t.Errorf("ParseCron(%q) err = %v, want ErrInvalid", s, err)
+        }
+    }
+}
+
+func TestCronMatch(t *testing.T) {
+    // 2024-03-04 为周一
+    at := func(day, hour, min int) time.Time { return time.Date(2024, 3, day, hour, min, 0, 0, time.UTC) }
+    cases := []struct {
+        expr string
+        t    time.Time
+        want bool
+    }{
+        {"* * * * *", at(4, 13, 37), true},
+        {"0 2 * * *", at(4, 2, 0), true},
+        {"0 2 * * *", at(4, 2, 1), false},
+        {"*/15 * * * *", at(4, 9, 45), true},
+        {"*/15 * * * *", at(4, 9, 50), false},
+        {"5/20 * * * *", at(4, 9, 25), true},
+        {"5/20 * * * *", at(4, 9, 5), true},
+        {"5/20 * * * *", at(4, 9, 20), false},
+        {"0-10/5 * * * *", at(4, 9, 10), true},
+        {"0-10/5 * * * *", at(4, 9, 15), false},
+        {"0 1,13 * * *", at(4, 13, 0), true},
+        {"0 1,13 * * *", at(4, 12, 0), false},
+        {"0 0 * * 1", at(4, 0, 0), true},
+        {"0 0 * * 1-5", at(9, 0, 0), false}, // 周六
+        {"0 0 * * 0", at(10, 0, 0), true},   // 周日
+        {"0 0 * * 7", at(10, 0, 0), true},   // 7 同为周日
+        {"0 0 * 3 *", at(4, 0, 0), true},
+        {"0 0 * 4 *", at(4, 0, 0), false},
+        // 日与周都受限时任一匹配即可
+        {"0 0 15 * 1", at(4, 0, 0), true},
+        {"0 0 15 * 1", at(15, 0, 0), true},
+        {"0 0 15 * 1", at(14, 0, 0), false},
+        // 只限制其一时须同时满足
+        {"0 0 15 * *", at(4, 0, 0), false},
+        {"0 0 * * 1", at(15, 0, 0), false},
+    }
+    for _, tc := range cases {
+        c, err := ParseCron(tc.expr)
+        if err != nil {
+            t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+        }
+        if got := c.Match(tc.t); got != tc.want {
+            t.Errorf("%q.Match(%s) = %v, want %v", tc.expr, tc.t.Format("Mon 2006-01-02 15:04"), got, tc.want)
+        }
+    }
+}
+
+func TestCronNext(t *testing.T) {
+    base := time.Date(2024, 3, 4, 13, 37, 20, 0, time.UTC)
+    cases := []struct {
+        expr  string
+        after time.Time
+        want  time.Time
+    }{
+        {"* * * * *", base, time.Date(2024, 3, 4, 13, 38, 0, 0, time.UTC)},
+        {"0 2 * * *", base, time.Date(2024, 3, 5, 2, 0, 0, 0, time.UTC)},
+        {"*/15 * * * *", base, time.Date(2024, 3, 4, 13, 45, 0, 0, time.UTC)},
+        {"30 13 * * *", base, time.Date(2024, 3, 5, 13, 30, 0, 0, time.UTC)},
+        {"0 0 1 * *", base, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+        {"0 0 * * 0", base, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+        {"0 0 29 2 *", base, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+        // 不含 after 本身
+        {"37 13 * * *", time.Date(2024, 3, 4, 13, 37, 0, 0, time.UTC), time.Date(2024, 3, 5, 13, 37, 0, 0, time.UTC)},
+        // 永不命中
+        {"0 0 30 2 *", base, time.Time{}},
+    }
+    for _, tc := range cases {
+        c, err := ParseCron(tc.expr)
+        if err != nil {
+            t.Fatalf("ParseCron(%q): %v", tc.expr, err)
+        }
+        if got := c.Next(tc.after); !got.Equal(tc.want) {
+            t.Errorf("%q.Next(%s) = %s, want %s", tc.expr, tc.after, got, tc.want)
+        }
+    }
+}
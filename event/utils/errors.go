@@ -4,4 +4,6 @@ import "errors"
 
 var (
     ErrNotFound = errors.New("not found")
+    ErrConflict = errors.New("already exists")
+    ErrInvalid  = errors.New("invalid argument")
 )
\ No newline at end of file
@@ -1,3 +1,22 @@
 package utils
 
-// 预留：配置与请求体校验
\ No newline at end of file
+import "regexp"
+
+// identRe 约束 StarRocks 表名、作业名以及管道名：字母或下划线开头，仅包含字母数字下划线
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+
+// topicRe 约束 Kafka 主题名（与 Kafka 自身的合法字符集一致）
+var topicRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,249}$`)
+
+// labelKeyRe / labelValueRe 约束管道标签，与选择器语法（逗号、等号、叹号）不冲突
+var labelKeyRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,62})$`)
+var labelValueRe = regexp.MustCompile(`^[A-Za-z0-9._/-]{0,63}$`)
+
+// ValidIdentifier 校验 SQL 标识符，防止拼接 SQL 时注入
+func ValidIdentifier(s string) bool { return identRe.MatchString(s) }
+
+// ValidTopicName 校验 Kafka 主题名
+func ValidTopicName(s string) bool { return topicRe.MatchString(s) && s != "." && s != ".." }
+
+// ValidLabel 校验管道标签的键与值
+func ValidLabel(k, v string) bool { return labelKeyRe.MatchString(k) && labelValueRe.MatchString(v) }
\ No newline at end of file